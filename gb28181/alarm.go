@@ -0,0 +1,121 @@
+package gb28181
+
+import (
+	"encoding/xml"
+	"time"
+
+	"github.com/EasyDarwin/EasyDarwin/models"
+	"github.com/teris-io/shortid"
+)
+
+// AlarmNotify of device by MESSAGE or NOTIFY of subscription,
+// AlarmMethod 1 phone, 2 device, 3 SMS, 4 GPS, 5 video, 6 device fault, 7 other
+type AlarmNotify struct {
+	XMLName          xml.Name `xml:"Notify"`
+	CmdType          string   `xml:"CmdType"`
+	SN               int      `xml:"SN"`
+	DeviceID         string   `xml:"DeviceID"`
+	AlarmPriority    string   `xml:"AlarmPriority"`
+	AlarmMethod      string   `xml:"AlarmMethod"`
+	AlarmTime        string   `xml:"AlarmTime"`
+	AlarmDescription string   `xml:"AlarmDescription,omitempty"`
+	Longitude        string   `xml:"Longitude,omitempty"`
+	Latitude         string   `xml:"Latitude,omitempty"`
+	Info             *struct {
+		AlarmType string `xml:"AlarmType"`
+	} `xml:"Info,omitempty"`
+}
+
+// AlarmResponse to device, device sends the alarm again without it
+type AlarmResponse struct {
+	XMLName  xml.Name `xml:"Response"`
+	CmdType  string   `xml:"CmdType"`
+	SN       int      `xml:"SN"`
+	DeviceID string   `xml:"DeviceID"`
+	Result   string   `xml:"Result"`
+}
+
+// AlarmQuery of SUBSCRIBE, priority and method 0 means all
+type AlarmQuery struct {
+	XMLName            xml.Name `xml:"Query"`
+	CmdType            string   `xml:"CmdType"`
+	SN                 int      `xml:"SN"`
+	DeviceID           string   `xml:"DeviceID"`
+	StartAlarmPriority int      `xml:"StartAlarmPriority"`
+	EndAlarmPriority   int      `xml:"EndAlarmPriority"`
+	AlarmMethod        int      `xml:"AlarmMethod"`
+}
+
+func newAlarmQuery(deviceID string, sn int) *AlarmQuery {
+	return &AlarmQuery{
+		CmdType:  "Alarm",
+		SN:       sn,
+		DeviceID: deviceID,
+	}
+}
+
+// OnAlarmHandle is called after alarm stored, alarm may drive recording or notification
+type OnAlarmHandle func(alarm *models.Alarm)
+
+// AddOnAlarmHandle to GB28181 server
+func (server *Server) AddOnAlarmHandle(handle OnAlarmHandle) {
+	server.onAlarmHandles = append(server.onAlarmHandles, handle)
+}
+
+// handleAlarm of device, store it and response to device
+func (server *Server) handleAlarm(device *Device, body []byte) {
+	notify := &AlarmNotify{}
+	if err := decodeMANSCDP(body, notify); err != nil {
+		log.WithError(err).WithField("id", device.ID()).Warn("decode Alarm")
+		return
+	}
+	go server.responseAlarm(device, notify)
+
+	alarm := &models.Alarm{
+		ID:          shortid.MustGenerate(),
+		DeviceID:    device.ID(),
+		ChannelID:   notify.DeviceID,
+		Priority:    atoi(notify.AlarmPriority),
+		Method:      atoi(notify.AlarmMethod),
+		Description: notify.AlarmDescription,
+		Longitude:   atof(notify.Longitude),
+		Latitude:    atof(notify.Latitude),
+	}
+	if nil != notify.Info {
+		alarm.Type = atoi(notify.Info.AlarmType)
+	}
+	if t, err := parseRecordTime(notify.AlarmTime); nil == err {
+		alarm.Time = t
+	} else {
+		alarm.Time = time.Now().Unix()
+	}
+	if err := models.AddAlarm(alarm); err != nil {
+		log.WithError(err).WithField("id", device.ID()).Error("add alarm")
+		return
+	}
+	log.WithField("id", device.ID()).Infof("channel[%s] alarm priority[%d] method[%d] type[%d]",
+		alarm.ChannelID, alarm.Priority, alarm.Method, alarm.Type)
+
+	go server.forwardAlarm(notify)
+	for _, handle := range server.onAlarmHandles {
+		handle(alarm)
+	}
+}
+
+func (server *Server) responseAlarm(device *Device, notify *AlarmNotify) {
+	body, err := encodeMANSCDP(&AlarmResponse{
+		CmdType:  "Alarm",
+		SN:       notify.SN,
+		DeviceID: notify.DeviceID,
+		Result:   "OK",
+	})
+	if err != nil {
+		log.WithError(err).WithField("id", device.ID()).Error("encode Alarm response")
+		return
+	}
+	req := server.NewDeviceRequest(device, MESSAGE)
+	req.SetBody(ContentTypeMANSCDP, body)
+	if err := server.SendRequest(device, req); err != nil {
+		log.WithError(err).WithField("id", device.ID()).Warn("response Alarm")
+	}
+}
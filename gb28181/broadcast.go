@@ -0,0 +1,319 @@
+package gb28181
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/EasyDarwin/EasyDarwin/rtsp"
+	"github.com/teris-io/shortid"
+)
+
+// broadcastSourceType of source ID in Broadcast notify, 136 is voice input device
+const broadcastSourceType = "136"
+
+// broadcastFrameSize of G.711 samples queued each 20ms
+const broadcastFrameSize = 160
+
+// BroadcastNotify to device, device INVITEs SourceID to pull audio
+type BroadcastNotify struct {
+	XMLName  xml.Name `xml:"Notify"`
+	CmdType  string   `xml:"CmdType"`
+	SN       int      `xml:"SN"`
+	SourceID string   `xml:"SourceID"`
+	TargetID string   `xml:"TargetID"`
+}
+
+// Broadcast of audio to channel of device.
+// Talkback is broadcast with live of the same channel, which carries audio of device
+type Broadcast struct {
+	// ID is the SourceID device INVITEs
+	ID        string
+	server    *Server
+	device    *Device
+	channelID string
+	// audio from RTSP pusher, or samples uploaded
+	pusher  rtsp.Pusher
+	codec   string
+	samples []byte
+
+	lock    sync.Mutex
+	sender  *rtsp.PSSender
+	invited chan int
+	stoped  bool
+}
+
+func (broadcast *Broadcast) String() string {
+	return fmt.Sprintf("broadcast[%s][%s][%s]", broadcast.device.ID(), broadcast.channelID, broadcast.ID)
+}
+
+// Stop sending audio and bye
+func (broadcast *Broadcast) Stop() {
+	broadcast.lock.Lock()
+	if broadcast.stoped {
+		broadcast.lock.Unlock()
+		return
+	}
+	broadcast.stoped = true
+	sender := broadcast.sender
+	broadcast.lock.Unlock()
+
+	broadcast.server.removeBroadcast(broadcast.ID)
+	if nil != sender {
+		// dialog byes when sender stops
+		sender.Stop()
+	}
+	log.WithField("id", broadcast.device.ID()).Infof("%s stop", broadcast)
+}
+
+// Broadcast audio of RTSP pusher to channel of device, audio of pusher must be G.711
+func (server *Server) Broadcast(deviceID string, channelID string, path string) (*Broadcast, error) {
+	_pusher, ok := rtsp.GetServer().GetPushers().Get(path)
+	if !ok {
+		return nil, fmt.Errorf("pusher %s not found", path)
+	}
+	pusher := _pusher.(rtsp.Pusher)
+	codecs := pusher.ACodec()
+	if len(codecs) == 0 || codecs[0] == "" {
+		return nil, fmt.Errorf("pusher %s without audio", path)
+	}
+	return server.broadcast(deviceID, channelID, &Broadcast{
+		pusher: pusher,
+		codec:  strings.ToUpper(codecs[0]),
+	})
+}
+
+// BroadcastSamples of G.711 to channel of device, it stops after all samples sent
+func (server *Server) BroadcastSamples(deviceID string, channelID string, codec string, samples []byte) (*Broadcast, error) {
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("broadcast without samples")
+	}
+	return server.broadcast(deviceID, channelID, &Broadcast{
+		codec:   strings.ToUpper(codec),
+		samples: samples,
+	})
+}
+
+func (server *Server) broadcast(deviceID string, channelID string, broadcast *Broadcast) (*Broadcast, error) {
+	if broadcast.codec != "PCMA" && broadcast.codec != "PCMU" {
+		return nil, fmt.Errorf("broadcast unsupported codec %s", broadcast.codec)
+	}
+	device := server.GetDevice(deviceID)
+	if nil == device {
+		return nil, ErrorDeviceNotFound
+	}
+	if !device.Online() {
+		return nil, ErrorDeviceOffline
+	}
+	if channelID == "" {
+		channelID = deviceID
+	}
+	broadcast.ID = fmt.Sprintf("%s%s%07d", config.SIP.Domain, broadcastSourceType, atomic.AddUint32(&server.broadcastSeq, 1)%10000000)
+	broadcast.server = server
+	broadcast.device = device
+	broadcast.channelID = channelID
+	broadcast.invited = make(chan int)
+	server.broadcastsLock.Lock()
+	server.broadcasts[broadcast.ID] = broadcast
+	server.broadcastsLock.Unlock()
+
+	sn := server.nextSN()
+	body, err := encodeMANSCDP(&BroadcastNotify{
+		CmdType:  "Broadcast",
+		SN:       sn,
+		SourceID: broadcast.ID,
+		TargetID: channelID,
+	})
+	if err != nil {
+		broadcast.Stop()
+		return nil, err
+	}
+	err = server.Query(device, "Broadcast", sn, body, func(body []byte) (bool, error) {
+		res := &ControlResponse{}
+		if err := decodeMANSCDP(body, res); err != nil {
+			return false, err
+		}
+		if !strings.EqualFold(res.Result, "OK") {
+			return false, fmt.Errorf("%s broadcast result %s", device, res.Result)
+		}
+		return true, nil
+	})
+	if err != nil {
+		broadcast.Stop()
+		return nil, err
+	}
+
+	// device INVITEs after response of notify
+	select {
+	case <-broadcast.invited:
+	case <-time.After(streamTimeout()):
+		broadcast.Stop()
+		return nil, ErrorTimeout
+	}
+	log.WithField("id", deviceID).Infof("%s start, codec %s", broadcast, broadcast.codec)
+	return broadcast, nil
+}
+
+// GetBroadcast by ID, nil if not found
+func (server *Server) GetBroadcast(ID string) *Broadcast {
+	server.broadcastsLock.RLock()
+	broadcast := server.broadcasts[ID]
+	server.broadcastsLock.RUnlock()
+
+	return broadcast
+}
+
+func (server *Server) removeBroadcast(ID string) {
+	server.broadcastsLock.Lock()
+	delete(server.broadcasts, ID)
+	server.broadcastsLock.Unlock()
+}
+
+// handleInvite of device, only broadcast is supported, device pulls audio from source ID
+func (server *Server) handleInvite(packet *Packet) {
+	server.Reply(packet, NewResponse(packet.Message, 100, "Trying"))
+	broadcast := server.GetBroadcast(getURIUser(packet.RequestURI))
+	if nil == broadcast {
+		server.Reply(packet, NewResponse(packet.Message, 404, "Not Found"))
+		return
+	}
+	device := broadcast.device
+	request, err := parseMediaRequest(packet.Body)
+	if err != nil {
+		log.WithError(err).WithField("id", device.ID()).Warnf("%s invite", broadcast)
+		server.Reply(packet, NewResponse(packet.Message, 400, "Bad Request"))
+		return
+	}
+
+	// raw G.711 is preferred, PS if device asks for it only
+	payloadType, rtpmap := 8, "8 PCMA/8000"
+	if broadcast.codec == "PCMU" {
+		payloadType, rtpmap = 0, "0 PCMU/8000"
+	}
+	if !request.hasPayloadType(payloadType) {
+		if !request.hasPayloadType(96) {
+			log.WithField("id", device.ID()).Warnf("%s invite payload types %v", broadcast, request.payloadTypes)
+			server.Reply(packet, NewResponse(packet.Message, 488, "Not Acceptable Here"))
+			return
+		}
+		payloadType, rtpmap = 96, "96 PS/90000"
+	}
+
+	sender, err := rtsp.NewAudioSender(broadcast.pusher, broadcast.codec, shortid.MustGenerate(),
+		request.transType, request.remote, request.SSRC(), payloadType)
+	if err != nil {
+		log.WithError(err).WithField("id", device.ID()).Errorf("%s invite", broadcast)
+		server.Reply(packet, NewResponse(packet.Message, 488, "Not Acceptable Here"))
+		return
+	}
+
+	broadcast.lock.Lock()
+	if broadcast.stoped || nil != broadcast.sender {
+		broadcast.lock.Unlock()
+		sender.Stop()
+		server.Reply(packet, NewResponse(packet.Message, 486, "Busy Here"))
+		return
+	}
+	broadcast.sender = sender
+	broadcast.lock.Unlock()
+
+	sdp := request.answer(broadcast.ID, "audio", sender.Port(), rtpmap)
+	dialog := server.acceptInvite(device, packet, broadcast.channelID, SessionPlay, sdp, broadcast.Stop)
+	sender.AddOnStopHandle(func() {
+		go func() {
+			if err := dialog.Bye(); nil != err {
+				log.WithError(err).WithField("id", device.ID()).Warn("bye")
+			}
+		}()
+		broadcast.Stop()
+	})
+	close(broadcast.invited)
+
+	if nil != broadcast.pusher {
+		broadcast.pusher.AddPlayer(sender)
+	} else {
+		go sender.Start()
+		go broadcast.sendSamples(sender)
+	}
+}
+
+// sendSamples in real time, broadcast stops after the last one
+func (broadcast *Broadcast) sendSamples(sender *rtsp.PSSender) {
+	payloadType := 8
+	if broadcast.codec == "PCMU" {
+		payloadType = 0
+	}
+	packetizer := rtsp.NewRTPPacketizer(rtsp.RTP_TYPE_AUDIO, payloadType)
+	ticker := time.NewTicker(20 * time.Millisecond)
+	defer ticker.Stop()
+
+	samples := broadcast.samples
+	timestamp := uint32(0)
+	for len(samples) > 0 && !sender.Stoped() {
+		<-ticker.C
+		size := broadcastFrameSize
+		if size > len(samples) {
+			size = len(samples)
+		}
+		for _, pack := range packetizer.PackG711(samples[:size], timestamp) {
+			sender.QueueRTP(pack)
+		}
+		timestamp += uint32(size)
+		samples = samples[size:]
+	}
+	// let the queue drain
+	time.Sleep(200 * time.Millisecond)
+	broadcast.Stop()
+}
+
+// ParseG711WAV returns codec and samples of WAV in A-law or mu-law, 8000Hz mono
+func ParseG711WAV(data []byte) (codec string, samples []byte, err error) {
+	if len(data) < 12 || !bytes.Equal(data[0:4], []byte("RIFF")) || !bytes.Equal(data[8:12], []byte("WAVE")) {
+		return "", nil, fmt.Errorf("not a WAV file")
+	}
+	data = data[12:]
+	for len(data) >= 8 {
+		id := string(data[0:4])
+		size := int(binary.LittleEndian.Uint32(data[4:8]))
+		data = data[8:]
+		if size > len(data) {
+			size = len(data)
+		}
+		switch id {
+		case "fmt ":
+			if size < 16 {
+				return "", nil, fmt.Errorf("WAV fmt chunk too short")
+			}
+			format := binary.LittleEndian.Uint16(data[0:2])
+			channels := binary.LittleEndian.Uint16(data[2:4])
+			sampleRate := binary.LittleEndian.Uint32(data[4:8])
+			switch format {
+			case 6:
+				codec = "PCMA"
+			case 7:
+				codec = "PCMU"
+			default:
+				return "", nil, fmt.Errorf("WAV format %d is not G.711", format)
+			}
+			if channels != 1 || sampleRate != 8000 {
+				return "", nil, fmt.Errorf("WAV of %d channels %dHz, mono 8000Hz required", channels, sampleRate)
+			}
+		case "data":
+			if codec == "" {
+				return "", nil, fmt.Errorf("WAV data before fmt chunk")
+			}
+			return codec, data[:size], nil
+		}
+		// chunks are word aligned
+		if size += size % 2; size > len(data) {
+			size = len(data)
+		}
+		data = data[size:]
+	}
+	return "", nil, fmt.Errorf("WAV without data chunk")
+}
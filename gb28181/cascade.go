@@ -0,0 +1,265 @@
+package gb28181
+
+import (
+	"fmt"
+	"hash/crc32"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/EasyDarwin/EasyDarwin/models"
+	"github.com/EasyDarwin/EasyDarwin/rtsp"
+	"github.com/teris-io/shortid"
+)
+
+// cascadeRequestTimeout of each MESSAGE to upstream
+const cascadeRequestTimeout = 3 * time.Second
+
+// cascadeChannelType of RTSP pushers in catalog, 131 is IPC
+const cascadeChannelType = "131"
+
+// CascadeChannelID of RTSP pusher path announced to upstream
+func CascadeChannelID(path string) string {
+	return fmt.Sprintf("%s%s%07d", config.SIP.Domain, cascadeChannelType, crc32.ChecksumIEEE([]byte(path))%10000000)
+}
+
+// civilCode is the first 6 digits of domain
+func civilCode(domain string) string {
+	if len(domain) > 6 {
+		return domain[:6]
+	}
+	return domain
+}
+
+// cascadeSource of channel ID for upstream, GB channel or RTSP pusher
+type cascadeSource struct {
+	deviceID string
+	path     string
+}
+
+// cascadeCatalog of RTSP pushers and channels of GB devices, with sources keyed by channel ID
+func (server *Server) cascadeCatalog() ([]CatalogItem, map[string]*cascadeSource) {
+	items := make([]CatalogItem, 0)
+	sources := make(map[string]*cascadeSource)
+
+	for it := rtsp.GetServer().GetPushers().Iterator(); !it.Done(); {
+		_, _pusher := it.Next()
+		pusher, ok := _pusher.(rtsp.Pusher)
+		if !ok || strings.HasPrefix(pusher.Path(), "/gb/") {
+			// GB channels are in device catalog
+			continue
+		}
+		ID := CascadeChannelID(pusher.Path())
+		sources[ID] = &cascadeSource{path: pusher.Path()}
+		items = append(items, CatalogItem{
+			DeviceID:     ID,
+			Name:         pusher.Path(),
+			Manufacturer: userAgent,
+			Model:        "RTSP",
+			Owner:        "Owner",
+			CivilCode:    civilCode(config.SIP.Domain),
+			Address:      "Address",
+			Parental:     "0",
+			ParentID:     server.ID(),
+			RegisterWay:  "1",
+			Secrecy:      "0",
+			Status:       "ON",
+		})
+	}
+
+	for _, device := range server.GetDevices() {
+		channels, err := models.GetDeviceChannels(device.ID())
+		if err != nil {
+			log.WithError(err).WithField("id", device.ID()).Warn("get channels for upstream catalog")
+			continue
+		}
+		for _, channel := range channels {
+			sources[channel.ID] = &cascadeSource{deviceID: device.ID()}
+			status := channel.Status
+			if !device.Online() {
+				status = "OFF"
+			}
+			items = append(items, CatalogItem{
+				DeviceID:     channel.ID,
+				Name:         channel.Name,
+				Manufacturer: channel.Manufacturer,
+				Model:        channel.Model,
+				Owner:        channel.Owner,
+				CivilCode:    channel.CivilCode,
+				Address:      channel.Address,
+				Parental:     strconv.Itoa(int(channel.Parental)),
+				ParentID:     server.ID(),
+				RegisterWay:  strconv.Itoa(int(channel.RegisterWay)),
+				Secrecy:      strconv.Itoa(int(channel.Secrecy)),
+				IPAddress:    channel.IPAddress,
+				Port:         strconv.Itoa(int(channel.Port)),
+				Status:       status,
+				Longitude:    strconv.FormatFloat(channel.Longitude, 'f', -1, 64),
+				Latitude:     strconv.FormatFloat(channel.Latitude, 'f', -1, 64),
+				PTZType:      strconv.Itoa(int(channel.PTZType)),
+			})
+		}
+	}
+
+	return items, sources
+}
+
+// handleUpstream request from upstream platform
+func (server *Server) handleUpstream(upstream *Upstream, packet *Packet) {
+	switch packet.Method {
+	case MESSAGE:
+		server.handleUpstreamMessage(upstream, packet)
+	case INVITE:
+		server.Reply(packet, NewResponse(packet.Message, 100, "Trying"))
+		// GB channel is invited while reading SIP messages
+		go server.handleUpstreamInvite(upstream, packet)
+	case BYE:
+		server.handleBye(packet)
+	case ACK:
+		// no response for ACK
+	default:
+		server.Reply(packet, NewResponse(packet.Message, 405, "Method Not Allowed"))
+	}
+}
+
+func (server *Server) handleUpstreamMessage(upstream *Upstream, packet *Packet) {
+	header := &manscdpHeader{}
+	if err := decodeMANSCDP(packet.Body, header); err != nil {
+		log.WithError(err).WithField("id", upstream.ID()).Warn("decode upstream MANSCDP")
+		server.Reply(packet, NewResponse(packet.Message, 400, "Bad Request"))
+		return
+	}
+	server.Reply(packet, NewResponse(packet.Message, 200, "OK"))
+
+	switch header.XMLName.Local + ":" + header.CmdType {
+	case "Query:Catalog":
+		go server.responseCatalog(upstream, header.SN)
+	default:
+		log.WithField("id", upstream.ID()).Infof("unhandled upstream MANSCDP %s:%s", header.XMLName.Local, header.CmdType)
+	}
+}
+
+// responseCatalog to upstream, one item each MESSAGE on UDP for the size limit
+func (server *Server) responseCatalog(upstream *Upstream, sn int) {
+	items, _ := server.cascadeCatalog()
+	batch := 20
+	if transport, _ := upstream.Addr(); transport == TransportUDP {
+		batch = 1
+	}
+
+	for from := 0; from < len(items) || from == 0; from += batch {
+		to := from + batch
+		if to > len(items) {
+			to = len(items)
+		}
+		res := &CatalogResponse{
+			CmdType:  "Catalog",
+			SN:       sn,
+			DeviceID: server.ID(),
+			SumNum:   len(items),
+		}
+		res.DeviceList.Items = items[from:to]
+		res.DeviceList.Num = len(res.DeviceList.Items)
+		body, err := encodeMANSCDP(res)
+		if err != nil {
+			log.WithError(err).WithField("id", upstream.ID()).Error("encode catalog")
+			return
+		}
+		req := upstream.newRequest(MESSAGE)
+		req.SetBody(ContentTypeMANSCDP, body)
+		if _, err := server.Request(upstream, req, cascadeRequestTimeout); err != nil {
+			log.WithError(err).WithField("id", upstream.ID()).Error("response catalog")
+			return
+		}
+		if len(items) == 0 {
+			break
+		}
+	}
+	log.WithField("id", upstream.ID()).Infof("response catalog %d channels", len(items))
+}
+
+// handleUpstreamInvite sends PS of RTSP pusher or GB channel to upstream
+func (server *Server) handleUpstreamInvite(upstream *Upstream, packet *Packet) {
+	channelID := getURIUser(packet.RequestURI)
+	if sessionName := sdpField(packet.Body, "s"); sessionName != SessionPlay {
+		log.WithField("id", upstream.ID()).Warnf("upstream invite %s session %s not supported", channelID, sessionName)
+		server.Reply(packet, NewResponse(packet.Message, 488, "Not Acceptable Here"))
+		return
+	}
+
+	request, err := parseMediaRequest(packet.Body)
+	if err != nil {
+		log.WithError(err).WithField("id", upstream.ID()).Warnf("upstream invite %s", channelID)
+		server.Reply(packet, NewResponse(packet.Message, 400, "Bad Request"))
+		return
+	}
+
+	pusher, err := server.cascadePusher(channelID)
+	if err != nil {
+		log.WithError(err).WithField("id", upstream.ID()).Warnf("upstream invite %s", channelID)
+		server.Reply(packet, NewResponse(packet.Message, 404, "Not Found"))
+		return
+	}
+	sender, err := rtsp.NewPSSender(pusher, shortid.MustGenerate(), request.transType, request.remote, request.SSRC())
+	if err != nil {
+		log.WithError(err).WithField("id", upstream.ID()).Errorf("upstream invite %s", channelID)
+		server.Reply(packet, NewResponse(packet.Message, 488, "Not Acceptable Here"))
+		return
+	}
+
+	sdp := request.answer(channelID, "video", sender.Port(), "96 PS/90000")
+	dialog := server.acceptInvite(upstream, packet, channelID, SessionPlay, sdp, sender.Stop)
+	sender.AddOnStopHandle(func() {
+		go func() {
+			if err := dialog.Bye(); nil != err {
+				log.WithError(err).WithField("id", upstream.ID()).Warn("bye")
+			}
+		}()
+	})
+
+	pusher.AddPlayer(sender)
+	log.WithField("id", upstream.ID()).Infof("%s send %s to %s", dialog, pusher.Path(), request.remote)
+}
+
+// cascadePusher of channel ID in cascade catalog, GB channel is invited if not playing
+func (server *Server) cascadePusher(channelID string) (rtsp.Pusher, error) {
+	_, sources := server.cascadeCatalog()
+	source, ok := sources[channelID]
+	if !ok {
+		return nil, ErrorChannelNotFound
+	}
+	if source.deviceID != "" {
+		return server.Play(source.deviceID, channelID)
+	}
+	if _pusher, ok := rtsp.GetServer().GetPushers().Get(source.path); ok {
+		return _pusher.(rtsp.Pusher), nil
+	}
+	return nil, ErrorChannelNotFound
+}
+
+// forwardAlarm of channel to online upstream platforms
+func (server *Server) forwardAlarm(notify *AlarmNotify) {
+	server.upstreamsLock.RLock()
+	upstreams := make([]*Upstream, 0, len(server.upstreams))
+	for _, upstream := range server.upstreams {
+		if upstream.Online() {
+			upstreams = append(upstreams, upstream)
+		}
+	}
+	server.upstreamsLock.RUnlock()
+
+	for _, upstream := range upstreams {
+		forward := *notify
+		forward.SN = server.nextSN()
+		body, err := encodeMANSCDP(&forward)
+		if err != nil {
+			log.WithError(err).WithField("id", upstream.ID()).Error("encode Alarm")
+			return
+		}
+		req := upstream.newRequest(MESSAGE)
+		req.SetBody(ContentTypeMANSCDP, body)
+		if _, err := server.Request(upstream, req, cascadeRequestTimeout); err != nil {
+			log.WithError(err).WithField("id", upstream.ID()).Warn("forward alarm")
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package gb28181
+
+import (
+	"encoding/xml"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/EasyDarwin/EasyDarwin/models"
+)
+
+// CatalogItem of device catalog response, numbers are kept as string for devices leave them empty
+type CatalogItem struct {
+	DeviceID     string `xml:"DeviceID"`
+	Name         string `xml:"Name"`
+	Manufacturer string `xml:"Manufacturer"`
+	Model        string `xml:"Model"`
+	Owner        string `xml:"Owner"`
+	CivilCode    string `xml:"CivilCode"`
+	Address      string `xml:"Address"`
+	Parental     string `xml:"Parental"`
+	ParentID     string `xml:"ParentID"`
+	RegisterWay  string `xml:"RegisterWay"`
+	Secrecy      string `xml:"Secrecy"`
+	IPAddress    string `xml:"IPAddress"`
+	Port         string `xml:"Port"`
+	Status       string `xml:"Status"`
+	Longitude    string `xml:"Longitude"`
+	Latitude     string `xml:"Latitude"`
+	PTZType      string `xml:"PTZType"`
+	Info         struct {
+		PTZType string `xml:"PTZType"`
+	} `xml:"Info"`
+}
+
+// CatalogResponse of device, may be split into many MESSAGE
+type CatalogResponse struct {
+	XMLName    xml.Name `xml:"Response"`
+	CmdType    string   `xml:"CmdType"`
+	SN         int      `xml:"SN"`
+	DeviceID   string   `xml:"DeviceID"`
+	SumNum     int      `xml:"SumNum"`
+	DeviceList struct {
+		Num   int           `xml:"Num,attr"`
+		Items []CatalogItem `xml:"Item"`
+	} `xml:"DeviceList"`
+}
+
+func atoi(value string) int32 {
+	i, _ := strconv.Atoi(strings.TrimSpace(value))
+	return int32(i)
+}
+
+func atof(value string) float64 {
+	f, _ := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	return f
+}
+
+func (item *CatalogItem) channel(deviceID string, now int64) *models.Channel {
+	ptzType := item.Info.PTZType
+	if ptzType == "" {
+		ptzType = item.PTZType
+	}
+	return &models.Channel{
+		ID:           item.DeviceID,
+		DeviceID:     deviceID,
+		Name:         item.Name,
+		Manufacturer: item.Manufacturer,
+		Model:        item.Model,
+		Owner:        item.Owner,
+		CivilCode:    item.CivilCode,
+		Address:      item.Address,
+		Parental:     atoi(item.Parental),
+		ParentID:     item.ParentID,
+		RegisterWay:  atoi(item.RegisterWay),
+		Secrecy:      atoi(item.Secrecy),
+		IPAddress:    item.IPAddress,
+		Port:         atoi(item.Port),
+		Status:       item.Status,
+		Longitude:    atof(item.Longitude),
+		Latitude:     atof(item.Latitude),
+		PTZType:      atoi(ptzType),
+		UpdateAt:     now,
+	}
+}
+
+// catalogMerge of responses of a catalog query, a channel repeated in pages is the last one received
+type catalogMerge struct {
+	deviceID string
+	now      int64
+	received map[string]*models.Channel
+	// order of channels first received
+	order []string
+}
+
+func newCatalogMerge(deviceID string, now int64) *catalogMerge {
+	return &catalogMerge{
+		deviceID: deviceID,
+		now:      now,
+		received: make(map[string]*models.Channel),
+		order:    make([]string, 0),
+	}
+}
+
+// add response body, done if SumNum channels are received
+func (merge *catalogMerge) add(body []byte) (bool, error) {
+	res := &CatalogResponse{}
+	if err := decodeMANSCDP(body, res); err != nil {
+		return false, err
+	}
+	for i := range res.DeviceList.Items {
+		channel := res.DeviceList.Items[i].channel(merge.deviceID, merge.now)
+		if channel.ID == "" {
+			continue
+		}
+		if _, ok := merge.received[channel.ID]; !ok {
+			merge.order = append(merge.order, channel.ID)
+		}
+		merge.received[channel.ID] = channel
+	}
+	return len(merge.received) >= res.SumNum, nil
+}
+
+// result of channels received, in order of first received
+func (merge *catalogMerge) result() []*models.Channel {
+	channels := make([]*models.Channel, 0, len(merge.order))
+	for _, ID := range merge.order {
+		channels = append(channels, merge.received[ID])
+	}
+	return channels
+}
+
+// QueryCatalog of device and store the channels, partial result is stored on timeout
+func (server *Server) QueryCatalog(device *Device) ([]*models.Channel, error) {
+	if !device.Online() {
+		return nil, ErrorDeviceOffline
+	}
+	sn := server.nextSN()
+	body, err := encodeMANSCDP(&Query{
+		CmdType:  "Catalog",
+		SN:       sn,
+		DeviceID: device.ID(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	merge := newCatalogMerge(device.ID(), time.Now().Unix())
+	err = server.Query(device, "Catalog", sn, body, merge.add)
+	channels := merge.result()
+	if err == ErrorTimeout && len(channels) > 0 {
+		log.WithField("id", device.ID()).Warnf("catalog timeout, %d channels received", len(channels))
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := models.SetDeviceChannels(device.ID(), channels); err != nil {
+		return nil, err
+	}
+	log.WithField("id", device.ID()).Infof("catalog %d channels", len(channels))
+	return channels, nil
+}
+
+func (server *Server) refreshCatalog(device *Device) {
+	if _, err := server.QueryCatalog(device); err != nil {
+		log.WithError(err).WithField("id", device.ID()).Error("query catalog")
+	}
+}
@@ -0,0 +1,113 @@
+package gb28181
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// catalogPage of response with items of "ID" or "ID:name"
+func catalogPage(sumNum int, items ...string) []byte {
+	body := make([]string, 0)
+	for _, item := range items {
+		pair := strings.SplitN(item+":", ":", 3)
+		body = append(body, fmt.Sprintf("<Item><DeviceID>%s</DeviceID><Name>%s</Name></Item>", pair[0], pair[1]))
+	}
+	return []byte(fmt.Sprintf(`<?xml version="1.0" encoding="GB2312"?>
+<Response><CmdType>Catalog</CmdType><SN>1</SN><DeviceID>34020000001320000001</DeviceID>
+<SumNum>%d</SumNum><DeviceList Num="%d">%s</DeviceList></Response>`, sumNum, len(items), strings.Join(body, "")))
+}
+
+func TestCatalogMerge(t *testing.T) {
+	tests := []struct {
+		name  string
+		pages [][]byte
+		done  []bool
+		err   bool
+		// channels of "ID:name"
+		channels []string
+	}{
+		{
+			name:     "one page",
+			pages:    [][]byte{catalogPage(2, "1:a", "2:b")},
+			done:     []bool{true},
+			channels: []string{"1:a", "2:b"},
+		},
+		{
+			name:     "pages",
+			pages:    [][]byte{catalogPage(3, "1:a", "2:b"), catalogPage(3, "3:c")},
+			done:     []bool{false, true},
+			channels: []string{"1:a", "2:b", "3:c"},
+		},
+		{
+			name:     "channel repeated is the last one in first order",
+			pages:    [][]byte{catalogPage(3, "1:a", "2:b"), catalogPage(3, "1:a2"), catalogPage(3, "3:c")},
+			done:     []bool{false, false, true},
+			channels: []string{"1:a2", "2:b", "3:c"},
+		},
+		{
+			name:     "item without DeviceID skipped",
+			pages:    [][]byte{catalogPage(2, ":x", "1:a"), catalogPage(2, "2:b")},
+			done:     []bool{false, true},
+			channels: []string{"1:a", "2:b"},
+		},
+		{
+			name:     "partial result",
+			pages:    [][]byte{catalogPage(3, "1:a")},
+			done:     []bool{false},
+			channels: []string{"1:a"},
+		},
+		{
+			name:     "empty catalog",
+			pages:    [][]byte{catalogPage(0)},
+			done:     []bool{true},
+			channels: []string{},
+		},
+		{
+			name:  "malformed",
+			pages: [][]byte{[]byte("<Response><SumNum>1</Sum")},
+			err:   true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			merge := newCatalogMerge("34020000001320000001", 100)
+			for i, page := range test.pages {
+				done, err := merge.add(page)
+				if test.err {
+					assert.NotNil(t, err)
+					return
+				}
+				assert.Nil(t, err)
+				assert.Equal(t, test.done[i], done)
+			}
+			channels := make([]string, 0)
+			for _, channel := range merge.result() {
+				assert.Equal(t, "34020000001320000001", channel.DeviceID)
+				assert.Equal(t, int64(100), channel.UpdateAt)
+				channels = append(channels, channel.ID+":"+channel.Name)
+			}
+			assert.Equal(t, test.channels, channels)
+		})
+	}
+}
+
+func TestCatalogItemChannel(t *testing.T) {
+	body := []byte(`<?xml version="1.0"?>
+<Response><CmdType>Catalog</CmdType><SN>1</SN><DeviceID>34020000001320000001</DeviceID><SumNum>1</SumNum>
+<DeviceList Num="1"><Item><DeviceID>34020000001310000001</DeviceID><Parental> 1 </Parental><Port></Port>
+<Longitude>116.3</Longitude><PTZType>3</PTZType><Info><PTZType>1</PTZType></Info><Status>ON</Status></Item></DeviceList></Response>`)
+	merge := newCatalogMerge("34020000001320000001", 100)
+	done, err := merge.add(body)
+	assert.Nil(t, err)
+	assert.True(t, done)
+	channel := merge.result()[0]
+	assert.Equal(t, int32(1), channel.Parental)
+	assert.Equal(t, int32(0), channel.Port)
+	assert.Equal(t, 116.3, channel.Longitude)
+	// PTZType of Info first
+	assert.Equal(t, int32(1), channel.PTZType)
+	assert.Equal(t, "ON", channel.Status)
+}
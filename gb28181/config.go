@@ -0,0 +1,129 @@
+package gb28181
+
+import (
+	"strings"
+
+	"github.com/go-ini/ini"
+)
+
+// ConfigSIP of GB28181 signal server
+type ConfigSIP struct {
+	// ID of this server, 20 digits
+	ID string `ini:"sip_id"`
+	// Domain is the first 10 digits of ID, also used as digest realm
+	Domain string `ini:"sip_domain"`
+	// Host announced in Via/Contact, local IP if empty
+	Host     string `ini:"sip_host"`
+	Port     int    `ini:"sip_port"`
+	Password string `ini:"sip_password"`
+	// KeepaliveTimeout in seconds, device will be offline without keepalive
+	KeepaliveTimeout int `ini:"keepalive_timeout"`
+	// RegisterExpires in seconds, the max expires accept from device
+	RegisterExpires int `ini:"register_expires"`
+	// QueryTimeout in seconds, wait for all responses of a query like Catalog
+	QueryTimeout int `ini:"query_timeout"`
+	// AlarmSubscribeExpires in seconds, alarm is subscribed after device registers, 0 to disable
+	AlarmSubscribeExpires int `ini:"alarm_subscribe_expires"`
+	// PositionSubscribeExpires in seconds, mobile position is subscribed after device registers, 0 to disable
+	PositionSubscribeExpires int `ini:"position_subscribe_expires"`
+	// PositionInterval in seconds of device reporting mobile position
+	PositionInterval int `ini:"position_interval"`
+	// PositionKeepDays of mobile position history, 0 to keep all
+	PositionKeepDays int `ini:"position_keep_days"`
+}
+
+// ConfigMedia of GB28181 stream receiving
+type ConfigMedia struct {
+	// Host in SDP for device sending stream, SIP host if empty
+	Host string `ini:"media_host"`
+	// Transport of stream, UDP or TCP(device connects to server)
+	Transport string `ini:"media_transport"`
+	// StreamTimeout in seconds, wait for INVITE response and first key frame
+	StreamTimeout int `ini:"stream_timeout"`
+	// DownloadSpeed of record download, times of normal speed
+	DownloadSpeed int `ini:"download_speed"`
+}
+
+// upstreamSectionPrefix of ini sections, one section for each upstream platform
+const upstreamSectionPrefix = "gb28181_upstream"
+
+// ConfigUpstream of superior platform this server registers to
+type ConfigUpstream struct {
+	// ID of upstream platform, 20 digits
+	ID string `ini:"id"`
+	// Domain of upstream, the first 10 digits of ID if empty
+	Domain    string `ini:"domain"`
+	Host      string `ini:"host"`
+	Port      int    `ini:"port"`
+	Transport string `ini:"transport"`
+	Password  string `ini:"password"`
+	// Expires of register in seconds
+	Expires int `ini:"expires"`
+	// Keepalive interval in seconds
+	Keepalive int `ini:"keepalive"`
+}
+
+type ConfigLog struct {
+	Level string `ini:"level"`
+}
+
+// Config of GB28181
+type Config struct {
+	SIP       ConfigSIP   `ini:"gb28181"`
+	Media     ConfigMedia `ini:"gb28181"`
+	Log       ConfigLog   `ini:"log"`
+	Upstreams []*ConfigUpstream
+}
+
+var config *Config
+
+func initConfig() error {
+	config = &Config{
+		SIP: ConfigSIP{
+			ID:                       "34020000002000000001",
+			Domain:                   "3402000000",
+			Port:                     5060,
+			KeepaliveTimeout:         180,
+			RegisterExpires:          3600,
+			QueryTimeout:             30,
+			AlarmSubscribeExpires:    3600,
+			PositionSubscribeExpires: 3600,
+			PositionInterval:         5,
+			PositionKeepDays:         7,
+		},
+		Media: ConfigMedia{
+			Transport:     "UDP",
+			StreamTimeout: 10,
+			DownloadSpeed: 4,
+		},
+		Log: ConfigLog{
+			Level: "info",
+		},
+	}
+	file, err := ini.Load("./easydarwin.ini")
+	if err != nil {
+		return err
+	}
+	if err = file.MapTo(config); err != nil {
+		return err
+	}
+	for _, section := range file.Sections() {
+		if !strings.HasPrefix(section.Name(), upstreamSectionPrefix) {
+			continue
+		}
+		upstream := &ConfigUpstream{
+			Port:      5060,
+			Transport: TransportUDP,
+			Expires:   3600,
+			Keepalive: 60,
+		}
+		if err = section.MapTo(upstream); err != nil {
+			return err
+		}
+		if upstream.Domain == "" {
+			upstream.Domain = deviceDomain(upstream.ID)
+		}
+		config.Upstreams = append(config.Upstreams, upstream)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package gb28181
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// DeviceControl command names of API
+const (
+	ControlIFrame     = "iframe"
+	ControlTeleBoot   = "teleboot"
+	ControlRecord     = "record"
+	ControlStopRecord = "stoprecord"
+	ControlSetGuard   = "setguard"
+	ControlResetGuard = "resetguard"
+	ControlResetAlarm = "resetalarm"
+)
+
+// Control command to device, only one command should be set
+type Control struct {
+	XMLName   xml.Name     `xml:"Control"`
+	CmdType   string       `xml:"CmdType"`
+	SN        int          `xml:"SN"`
+	DeviceID  string       `xml:"DeviceID"`
+	PTZCmd    string       `xml:"PTZCmd,omitempty"`
+	TeleBoot  string       `xml:"TeleBoot,omitempty"`
+	RecordCmd string       `xml:"RecordCmd,omitempty"`
+	GuardCmd  string       `xml:"GuardCmd,omitempty"`
+	AlarmCmd  string       `xml:"AlarmCmd,omitempty"`
+	IFameCmd  string       `xml:"IFameCmd,omitempty"`
+	Info      *ControlInfo `xml:"Info,omitempty"`
+}
+
+// ControlInfo of PTZ control
+type ControlInfo struct {
+	ControlPriority int `xml:"ControlPriority"`
+}
+
+// ControlResponse of device, only for record, guard and alarm commands
+type ControlResponse struct {
+	XMLName  xml.Name `xml:"Response"`
+	CmdType  string   `xml:"CmdType"`
+	SN       int      `xml:"SN"`
+	DeviceID string   `xml:"DeviceID"`
+	Result   string   `xml:"Result"`
+}
+
+// NewControl of command name, DeviceID should be set before sending
+func NewControl(command string) (*Control, error) {
+	control := &Control{CmdType: "DeviceControl"}
+	switch strings.ToLower(command) {
+	case ControlIFrame:
+		control.IFameCmd = "Send"
+	case ControlTeleBoot:
+		control.TeleBoot = "Boot"
+	case ControlRecord:
+		control.RecordCmd = "Record"
+	case ControlStopRecord:
+		control.RecordCmd = "StopRecord"
+	case ControlSetGuard:
+		control.GuardCmd = "SetGuard"
+	case ControlResetGuard:
+		control.GuardCmd = "ResetGuard"
+	case ControlResetAlarm:
+		control.AlarmCmd = "ResetAlarm"
+	default:
+		return nil, fmt.Errorf("unknown control command %s", command)
+	}
+	return control, nil
+}
+
+// NewPTZControl of channel
+func NewPTZControl(channelID string, cmd PTZCmd) *Control {
+	return &Control{
+		CmdType:  "DeviceControl",
+		DeviceID: channelID,
+		PTZCmd:   cmd.String(),
+		Info:     &ControlInfo{ControlPriority: 5},
+	}
+}
+
+// hasResponse of device besides the SIP response
+func (control *Control) hasResponse() bool {
+	return control.RecordCmd != "" || control.GuardCmd != "" || control.AlarmCmd != ""
+}
+
+// DeviceControl sends control to device, returns Result of device response or OK when device accepts the command without response
+func (server *Server) DeviceControl(device *Device, control *Control) (string, error) {
+	if !device.Online() {
+		return "", ErrorDeviceOffline
+	}
+	if control.DeviceID == "" {
+		control.DeviceID = device.ID()
+	}
+	control.SN = server.nextSN()
+	body, err := encodeMANSCDP(control)
+	if err != nil {
+		return "", err
+	}
+
+	if !control.hasResponse() {
+		req := server.NewDeviceRequest(device, MESSAGE)
+		req.SetBody(ContentTypeMANSCDP, body)
+		res, err := server.Request(device, req, queryTimeout())
+		if err != nil {
+			return "", err
+		}
+		if res.StatusCode != 200 {
+			return "", fmt.Errorf("%s control response %d %s", device, res.StatusCode, res.Reason)
+		}
+		return "OK", nil
+	}
+
+	result := ""
+	err = server.Query(device, control.CmdType, control.SN, body, func(body []byte) (bool, error) {
+		res := &ControlResponse{}
+		if err := decodeMANSCDP(body, res); err != nil {
+			return false, err
+		}
+		result = res.Result
+		return true, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	log.WithField("id", device.ID()).Infof("control %s result %s", control.DeviceID, result)
+	return result, nil
+}
@@ -0,0 +1,270 @@
+package gb28181
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/EasyDarwin/EasyDarwin/models"
+	proto "github.com/golang/protobuf/proto"
+)
+
+// DeviceInfoResponse of device
+type DeviceInfoResponse struct {
+	XMLName      xml.Name `xml:"Response"`
+	CmdType      string   `xml:"CmdType"`
+	SN           int      `xml:"SN"`
+	DeviceID     string   `xml:"DeviceID"`
+	DeviceName   string   `xml:"DeviceName"`
+	Result       string   `xml:"Result"`
+	Manufacturer string   `xml:"Manufacturer"`
+	Model        string   `xml:"Model"`
+	Firmware     string   `xml:"Firmware"`
+	Channel      string   `xml:"Channel"`
+}
+
+// DeviceAlarmStatusList of DeviceStatus response
+type DeviceAlarmStatusList struct {
+	Items []struct {
+		DeviceID   string `xml:"DeviceID"`
+		DutyStatus string `xml:"DutyStatus"`
+	} `xml:"Item"`
+}
+
+// DeviceStatusResponse of device, Online is ONLINE or OFFLINE, Encode and Record are ON or OFF
+type DeviceStatusResponse struct {
+	XMLName    xml.Name `xml:"Response"`
+	CmdType    string   `xml:"CmdType"`
+	SN         int      `xml:"SN"`
+	DeviceID   string   `xml:"DeviceID"`
+	Result     string   `xml:"Result"`
+	Online     string   `xml:"Online"`
+	Status     string   `xml:"Status"`
+	Reason     string   `xml:"Reason"`
+	Encode     string   `xml:"Encode"`
+	Record     string   `xml:"Record"`
+	DeviceTime string   `xml:"DeviceTime"`
+	// Alarmstatus in standard, some devices use AlarmStatus
+	Alarmstatus DeviceAlarmStatusList `xml:"Alarmstatus"`
+	AlarmStatus DeviceAlarmStatusList `xml:"AlarmStatus"`
+}
+
+// BasicParam of device config, zero values are left unchanged when config
+type BasicParam struct {
+	Name string `xml:"Name,omitempty"`
+	// Expiration of register in seconds
+	Expiration int `xml:"Expiration,omitempty"`
+	// HeartBeatInterval in seconds
+	HeartBeatInterval int `xml:"HeartBeatInterval,omitempty"`
+	// HeartBeatCount of timeout
+	HeartBeatCount int `xml:"HeartBeatCount,omitempty"`
+}
+
+// DeviceConfig command to device
+type DeviceConfig struct {
+	XMLName    xml.Name    `xml:"Control"`
+	CmdType    string      `xml:"CmdType"`
+	SN         int         `xml:"SN"`
+	DeviceID   string      `xml:"DeviceID"`
+	BasicParam *BasicParam `xml:"BasicParam"`
+}
+
+// ConfigDownloadQuery of device config, only BasicParam is supported
+type ConfigDownloadQuery struct {
+	XMLName    xml.Name `xml:"Query"`
+	CmdType    string   `xml:"CmdType"`
+	SN         int      `xml:"SN"`
+	DeviceID   string   `xml:"DeviceID"`
+	ConfigType string   `xml:"ConfigType"`
+}
+
+// ConfigDownloadResponse of device
+type ConfigDownloadResponse struct {
+	XMLName    xml.Name `xml:"Response"`
+	CmdType    string   `xml:"CmdType"`
+	SN         int      `xml:"SN"`
+	DeviceID   string   `xml:"DeviceID"`
+	Result     string   `xml:"Result"`
+	BasicParam *struct {
+		Name              string `xml:"Name"`
+		Expiration        string `xml:"Expiration"`
+		HeartBeatInterval string `xml:"HeartBeatInterval"`
+		HeartBeatCount    string `xml:"HeartBeatCount"`
+	} `xml:"BasicParam"`
+}
+
+// queryDevice by body made of SN, the only response is decoded into res
+func (server *Server) queryDevice(device *Device, cmdType string, body func(sn int) interface{}, res interface{}) error {
+	if !device.Online() {
+		return ErrorDeviceOffline
+	}
+	sn := server.nextSN()
+	data, err := encodeMANSCDP(body(sn))
+	if err != nil {
+		return err
+	}
+	return server.Query(device, cmdType, sn, data, func(data []byte) (bool, error) {
+		return true, decodeMANSCDP(data, res)
+	})
+}
+
+func checkResult(device *Device, cmdType string, result string) error {
+	if result != "" && !strings.EqualFold(result, "OK") {
+		return fmt.Errorf("%s %s result %s", device, cmdType, result)
+	}
+	return nil
+}
+
+// QueryDeviceInfo of device and store it, name of device is updated too
+func (server *Server) QueryDeviceInfo(device *Device) (*models.DeviceInfo, error) {
+	res := &DeviceInfoResponse{}
+	err := server.queryDevice(device, "DeviceInfo", func(sn int) interface{} {
+		return &Query{CmdType: "DeviceInfo", SN: sn, DeviceID: device.ID()}
+	}, res)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkResult(device, "DeviceInfo", res.Result); err != nil {
+		return nil, err
+	}
+
+	info := &models.DeviceInfo{
+		Name:         res.DeviceName,
+		Manufacturer: res.Manufacturer,
+		Model:        res.Model,
+		Firmware:     res.Firmware,
+		Channel:      atoi(res.Channel),
+		UpdateAt:     time.Now().Unix(),
+	}
+	err = device.update(func(record *models.Device) {
+		record.Info = info
+		if info.Name != "" {
+			record.Name = info.Name
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.WithField("id", device.ID()).Infof("device info %s %s %s, %d channels", info.Manufacturer, info.Model, info.Firmware, info.Channel)
+	return info, nil
+}
+
+// QueryDeviceStatus of device and store it
+func (server *Server) QueryDeviceStatus(device *Device) (*models.DeviceStatus, error) {
+	res := &DeviceStatusResponse{}
+	err := server.queryDevice(device, "DeviceStatus", func(sn int) interface{} {
+		return &Query{CmdType: "DeviceStatus", SN: sn, DeviceID: device.ID()}
+	}, res)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkResult(device, "DeviceStatus", res.Result); err != nil {
+		return nil, err
+	}
+
+	status := &models.DeviceStatus{
+		Online:     strings.EqualFold(res.Online, "ONLINE"),
+		Status:     res.Status,
+		Reason:     res.Reason,
+		Encode:     strings.EqualFold(res.Encode, "ON"),
+		Record:     strings.EqualFold(res.Record, "ON"),
+		DeviceTime: res.DeviceTime,
+		UpdateAt:   time.Now().Unix(),
+	}
+	for _, list := range []DeviceAlarmStatusList{res.Alarmstatus, res.AlarmStatus} {
+		for _, item := range list.Items {
+			status.AlarmStatus = append(status.AlarmStatus, &models.DeviceAlarmStatus{
+				ChannelID:  item.DeviceID,
+				DutyStatus: item.DutyStatus,
+			})
+		}
+	}
+	err = device.update(func(record *models.Device) {
+		record.Status = status
+	})
+	if err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// QueryDeviceConfig of BasicParam by ConfigDownload and store it
+func (server *Server) QueryDeviceConfig(device *Device) (*models.DeviceConfig, error) {
+	res := &ConfigDownloadResponse{}
+	err := server.queryDevice(device, "ConfigDownload", func(sn int) interface{} {
+		return &ConfigDownloadQuery{CmdType: "ConfigDownload", SN: sn, DeviceID: device.ID(), ConfigType: "BasicParam"}
+	}, res)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkResult(device, "ConfigDownload", res.Result); err != nil {
+		return nil, err
+	}
+	if nil == res.BasicParam {
+		return nil, fmt.Errorf("%s ConfigDownload without BasicParam", device)
+	}
+
+	deviceConfig := &models.DeviceConfig{
+		Name:              res.BasicParam.Name,
+		Expiration:        atoi(res.BasicParam.Expiration),
+		HeartBeatInterval: atoi(res.BasicParam.HeartBeatInterval),
+		HeartBeatCount:    atoi(res.BasicParam.HeartBeatCount),
+		UpdateAt:          time.Now().Unix(),
+	}
+	err = device.update(func(record *models.Device) {
+		record.Config = deviceConfig
+	})
+	if err != nil {
+		return nil, err
+	}
+	return deviceConfig, nil
+}
+
+// SetDeviceConfig of BasicParam, the stored config is merged with param after device accepts it
+func (server *Server) SetDeviceConfig(device *Device, param *BasicParam) (*models.DeviceConfig, error) {
+	res := &ControlResponse{}
+	err := server.queryDevice(device, "DeviceConfig", func(sn int) interface{} {
+		return &DeviceConfig{CmdType: "DeviceConfig", SN: sn, DeviceID: device.ID(), BasicParam: param}
+	}, res)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkResult(device, "DeviceConfig", res.Result); err != nil {
+		return nil, err
+	}
+
+	var deviceConfig *models.DeviceConfig
+	err = device.update(func(record *models.Device) {
+		deviceConfig = &models.DeviceConfig{}
+		if nil != record.Config {
+			deviceConfig = proto.Clone(record.Config).(*models.DeviceConfig)
+		}
+		if param.Name != "" {
+			deviceConfig.Name = param.Name
+			record.Name = param.Name
+		}
+		if param.Expiration > 0 {
+			deviceConfig.Expiration = int32(param.Expiration)
+		}
+		if param.HeartBeatInterval > 0 {
+			deviceConfig.HeartBeatInterval = int32(param.HeartBeatInterval)
+		}
+		if param.HeartBeatCount > 0 {
+			deviceConfig.HeartBeatCount = int32(param.HeartBeatCount)
+		}
+		deviceConfig.UpdateAt = time.Now().Unix()
+		record.Config = deviceConfig
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.WithField("id", device.ID()).Infof("device config %+v", *param)
+	return deviceConfig, nil
+}
+
+func (server *Server) refreshDeviceInfo(device *Device) {
+	if _, err := server.QueryDeviceInfo(device); err != nil {
+		log.WithError(err).WithField("id", device.ID()).Error("query device info")
+	}
+}
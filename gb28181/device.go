@@ -0,0 +1,209 @@
+package gb28181
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/EasyDarwin/EasyDarwin/models"
+	proto "github.com/golang/protobuf/proto"
+)
+
+// Device of GB28181 in registry
+type Device struct {
+	info *models.Device
+	lock sync.RWMutex
+	// next time to subscribe, key is CmdType
+	subscriptions map[string]time.Time
+}
+
+func newDevice(info *models.Device) *Device {
+	return &Device{
+		info:          info,
+		subscriptions: make(map[string]time.Time),
+	}
+}
+
+func (device *Device) String() string {
+	return fmt.Sprintf("device[%s]", device.ID())
+}
+
+// ID of device, never changed
+func (device *Device) ID() string {
+	return device.info.ID
+}
+
+// Info copy of device
+func (device *Device) Info() *models.Device {
+	device.lock.RLock()
+	info := proto.Clone(device.info).(*models.Device)
+	device.lock.RUnlock()
+
+	return info
+}
+
+// Online state of device
+func (device *Device) Online() bool {
+	device.lock.RLock()
+	online := device.info.Online
+	device.lock.RUnlock()
+
+	return online
+}
+
+// Addr of device signal, with transport
+func (device *Device) Addr() (transport string, addr string) {
+	device.lock.RLock()
+	transport = device.info.Transport
+	addr = fmt.Sprintf("%s:%d", device.info.Host, device.info.Port)
+	device.lock.RUnlock()
+
+	return
+}
+
+// update device info and store it
+func (device *Device) update(fn func(info *models.Device)) error {
+	device.lock.Lock()
+	fn(device.info)
+	info := proto.Clone(device.info).(*models.Device)
+	device.lock.Unlock()
+
+	return models.AddDevice(info)
+}
+
+// expired if no keepalive or register expired
+func (device *Device) expired(now time.Time) bool {
+	device.lock.RLock()
+	defer device.lock.RUnlock()
+
+	if !device.info.Online {
+		return false
+	}
+	if now.Unix()-device.info.KeepaliveAt > int64(config.SIP.KeepaliveTimeout) {
+		return true
+	}
+	if device.info.Expires > 0 && now.Unix() > device.info.RegisterAt+device.info.Expires {
+		return true
+	}
+	return false
+}
+
+// subscriptionDue of CmdType, it is delayed to avoid subscribing again before the result
+func (device *Device) subscriptionDue(cmdType string, now time.Time) bool {
+	device.lock.Lock()
+	defer device.lock.Unlock()
+
+	if next, ok := device.subscriptions[cmdType]; ok && now.Before(next) {
+		return false
+	}
+	device.subscriptions[cmdType] = now.Add(subscribeRetryInterval)
+	return true
+}
+
+func (device *Device) setSubscriptionDue(cmdType string, next time.Time) {
+	device.lock.Lock()
+	device.subscriptions[cmdType] = next
+	device.lock.Unlock()
+}
+
+// clearSubscriptions when device is offline or registers again, subscriptions are lost
+func (device *Device) clearSubscriptions() {
+	device.lock.Lock()
+	device.subscriptions = make(map[string]time.Time)
+	device.lock.Unlock()
+}
+
+func (server *Server) loadDevices() error {
+	devices, err := models.GetAllDevices()
+	if nil != err {
+		return err
+	}
+
+	server.devicesLock.Lock()
+	server.devices = make(map[string]*Device)
+	for _, info := range devices {
+		// online state is unknown before device register again
+		info.Online = false
+		server.devices[info.ID] = newDevice(info)
+	}
+	server.devicesLock.Unlock()
+
+	log.Infof("%d devices loaded", len(devices))
+	return nil
+}
+
+// GetDevice in registry, nil if not found
+func (server *Server) GetDevice(ID string) *Device {
+	server.devicesLock.RLock()
+	device := server.devices[ID]
+	server.devicesLock.RUnlock()
+
+	return device
+}
+
+// GetDevices all in registry
+func (server *Server) GetDevices() []*Device {
+	server.devicesLock.RLock()
+	devices := make([]*Device, 0, len(server.devices))
+	for _, device := range server.devices {
+		devices = append(devices, device)
+	}
+	server.devicesLock.RUnlock()
+
+	return devices
+}
+
+// RemoveDevice from registry and DB
+func (server *Server) RemoveDevice(ID string) error {
+	server.devicesLock.Lock()
+	_, ok := server.devices[ID]
+	delete(server.devices, ID)
+	server.devicesLock.Unlock()
+
+	if !ok {
+		return ErrorDeviceNotFound
+	}
+	return models.RemoveDevice(ID)
+}
+
+func (server *Server) getOrAddDevice(ID string) *Device {
+	server.devicesLock.Lock()
+	device, ok := server.devices[ID]
+	if !ok {
+		device = newDevice(&models.Device{ID: ID})
+		server.devices[ID] = device
+	}
+	server.devicesLock.Unlock()
+
+	return device
+}
+
+// checkDevicesLoop set device offline when keepalive timeout, and refresh subscriptions of online devices.
+// Nonces of REGISTER never answered are pruned too
+func (server *Server) checkDevicesLoop() {
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-server.stopChannel:
+			return
+		case now := <-ticker.C:
+			server.pruneNonces(now)
+			for _, device := range server.GetDevices() {
+				if !device.expired(now) {
+					server.refreshSubscriptions(device, now)
+					continue
+				}
+				device.clearSubscriptions()
+				log.WithField("id", device.ID()).Info("device offline for keepalive timeout")
+				err := device.update(func(info *models.Device) {
+					info.Online = false
+				})
+				if nil != err {
+					log.WithError(err).WithField("id", device.ID()).Error("update device")
+				}
+			}
+		}
+	}
+}
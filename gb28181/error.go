@@ -0,0 +1,15 @@
+package gb28181
+
+import "errors"
+
+// Common errors
+var (
+	ErrorSIPMalformed      = errors.New("SIP message malformed")
+	ErrorSIPNeedMore       = errors.New("SIP message need more data")
+	ErrorDeviceNotFound    = errors.New("Device not found")
+	ErrorDeviceOffline     = errors.New("Device offline")
+	ErrorChannelNotFound   = errors.New("Channel not found")
+	ErrorTransportNotFound = errors.New("Transport to device not found")
+	ErrorAuthFailed        = errors.New("Authorization failed")
+	ErrorTimeout           = errors.New("Timeout")
+)
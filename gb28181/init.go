@@ -0,0 +1,30 @@
+package gb28181
+
+func init() {
+	var err error
+
+	err = initConfig()
+	if nil != err {
+		panic(err)
+	}
+
+	err = initLog()
+	if nil != err {
+		panic(err)
+	}
+
+	err = initServer()
+	if nil != err {
+		log.Panic(err)
+	}
+
+	err = initPlay()
+	if nil != err {
+		log.Panic(err)
+	}
+
+	err = initPlayback()
+	if nil != err {
+		log.Panic(err)
+	}
+}
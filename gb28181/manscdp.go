@@ -0,0 +1,79 @@
+package gb28181
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/transform"
+)
+
+// ContentTypeMANSCDP of MESSAGE body
+const ContentTypeMANSCDP = "Application/MANSCDP+xml"
+
+// manscdpHeader is the common part of all MANSCDP xml
+type manscdpHeader struct {
+	XMLName  xml.Name
+	CmdType  string `xml:"CmdType"`
+	SN       int    `xml:"SN"`
+	DeviceID string `xml:"DeviceID"`
+}
+
+// Query command to device
+type Query struct {
+	XMLName  xml.Name `xml:"Query"`
+	CmdType  string   `xml:"CmdType"`
+	SN       int      `xml:"SN"`
+	DeviceID string   `xml:"DeviceID"`
+}
+
+// Keepalive notify of device
+type Keepalive struct {
+	XMLName  xml.Name `xml:"Notify"`
+	CmdType  string   `xml:"CmdType"`
+	SN       int      `xml:"SN"`
+	DeviceID string   `xml:"DeviceID"`
+	Status   string   `xml:"Status"`
+}
+
+// MediaStatus notify of device, NotifyType 121 means end of playback or download
+type MediaStatus struct {
+	XMLName    xml.Name `xml:"Notify"`
+	CmdType    string   `xml:"CmdType"`
+	SN         int      `xml:"SN"`
+	DeviceID   string   `xml:"DeviceID"`
+	NotifyType string   `xml:"NotifyType"`
+}
+
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(charset) {
+	case "gb2312", "gbk", "gb18030":
+		return transform.NewReader(input, simplifiedchinese.GB18030.NewDecoder()), nil
+	}
+	return input, nil
+}
+
+// encodeMANSCDP xml body with GB2312 charset
+func encodeMANSCDP(v interface{}) ([]byte, error) {
+	body, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	body, _, err = transform.Bytes(simplifiedchinese.GB18030.NewEncoder(), body)
+	if err != nil {
+		return nil, err
+	}
+	buf := bytes.NewBufferString(`<?xml version="1.0" encoding="GB2312"?>` + "\r\n")
+	buf.Write(body)
+	buf.WriteString("\r\n")
+	return buf.Bytes(), nil
+}
+
+// decodeMANSCDP xml body, GB2312 is the default charset of GB28181
+func decodeMANSCDP(body []byte, v interface{}) error {
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	decoder.CharsetReader = charsetReader
+	return decoder.Decode(v)
+}
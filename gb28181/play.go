@@ -0,0 +1,206 @@
+package gb28181
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/EasyDarwin/EasyDarwin/rtsp"
+	"github.com/teris-io/shortid"
+)
+
+func streamTimeout() time.Duration {
+	return time.Duration(config.Media.StreamTimeout) * time.Second
+}
+
+func mediaHost() string {
+	if config.Media.Host != "" {
+		return config.Media.Host
+	}
+	return Instance.Host()
+}
+
+// PlayPath of channel live stream for RTSP
+func PlayPath(deviceID string, channelID string) string {
+	return fmt.Sprintf("/gb/%s/%s", deviceID, channelID)
+}
+
+// invite channel to send PS to a new PS pusher, it is not added to RTSP server
+func (server *Server) invite(device *Device, channelID string, path string, offer *mediaOffer) (*rtsp.PSPusher, *Dialog, error) {
+	transType := rtsp.TRANS_TYPE_UDP
+	offer.transport = TransportUDP
+	if strings.EqualFold(config.Media.Transport, TransportTCP) {
+		transType = rtsp.TRANS_TYPE_TCP
+		offer.transport = TransportTCP
+	}
+	ssrc, err := server.allocSSRC(offer.sessionName != SessionPlay)
+	if nil != err {
+		return nil, nil, err
+	}
+	pusher, err := server.newPSPusher(device, channelID, path, transType, ssrc)
+	if nil != err {
+		server.freeSSRC(ssrc)
+		return nil, nil, err
+	}
+	pusher.AddOnStopHandle(func() {
+		server.freeSSRC(ssrc)
+	})
+	offer.channelID = channelID
+	offer.host = mediaHost()
+	offer.port = pusher.Port()
+	offer.ssrc = ssrc
+
+	subject := fmt.Sprintf("%s:%s,%s:0", channelID, offer.ssrc, server.ID())
+	dialog, res, err := server.Invite(device, channelID, subject, offer.SDP(), streamTimeout())
+	if nil != err {
+		pusher.Stop()
+		return nil, nil, err
+	}
+	dialog.sessionName = offer.sessionName
+
+	pusher.AddOnStopHandle(func() {
+		go func() {
+			if err := dialog.Bye(); nil != err {
+				log.WithError(err).WithField("id", device.ID()).Warn("bye")
+			}
+		}()
+	})
+	dialog.OnEnd(pusher.Stop)
+
+	// device may use its own SSRC
+	if answered := sdpField(res.Body, "y"); answered != "" && answered != ssrc {
+		value, err := parseSSRC(answered)
+		if nil == err {
+			err = pusher.SetSSRC(value)
+		}
+		if nil != err {
+			pusher.Stop()
+			return nil, nil, fmt.Errorf("%s answered SSRC %s: %v", device, answered, err)
+		}
+		log.WithField("id", device.ID()).Infof("channel[%s] answered SSRC[%s] instead of %s", channelID, answered, ssrc)
+	}
+
+	return pusher, dialog, nil
+}
+
+// newPSPusher receiving ssrc on the mux port, or on a port of its own if mux is disabled
+func (server *Server) newPSPusher(device *Device, channelID string, path string, transType rtsp.TransType, ssrc string) (*rtsp.PSPusher, error) {
+	value, err := parseSSRC(ssrc)
+	if nil != err {
+		return nil, err
+	}
+	rtspServer := rtsp.GetServer()
+	source := fmt.Sprintf("gb28181://%s/%s", device.ID(), channelID)
+	if nil != rtspServer.RTPMux() {
+		return rtsp.NewMuxPSPusher(rtspServer, shortid.MustGenerate(), path, source, transType, value)
+	}
+	pusher, err := rtsp.NewPSPusher(rtspServer, shortid.MustGenerate(), path, source, transType, 0)
+	if nil != err {
+		return nil, err
+	}
+	pusher.SetSSRC(value)
+	return pusher, nil
+}
+
+// Play live of channel, the stream is stopped when the last player leaves
+func (server *Server) Play(deviceID string, channelID string) (rtsp.Pusher, error) {
+	path := PlayPath(deviceID, channelID)
+	rtspServer := rtsp.GetServer()
+
+	// only one INVITE of the same channel at the same time
+	for {
+		server.playingLock.Lock()
+		wait, ok := server.playing[path]
+		if !ok {
+			server.playing[path] = make(chan int)
+			server.playingLock.Unlock()
+			break
+		}
+		server.playingLock.Unlock()
+		<-wait
+	}
+	defer func() {
+		server.playingLock.Lock()
+		close(server.playing[path])
+		delete(server.playing, path)
+		server.playingLock.Unlock()
+	}()
+
+	if _pusher, ok := rtspServer.GetPushers().Get(path); ok {
+		pusher := _pusher.(rtsp.Pusher)
+		if ps, ok := pusher.(*rtsp.PSPusher); ok {
+			if err := ps.WaitReady(streamTimeout()); nil != err {
+				return nil, err
+			}
+		}
+		return pusher, nil
+	}
+
+	device := server.GetDevice(deviceID)
+	if nil == device {
+		return nil, ErrorDeviceNotFound
+	}
+	if !device.Online() {
+		return nil, ErrorDeviceOffline
+	}
+
+	pusher, _, err := server.invite(device, channelID, path, &mediaOffer{sessionName: SessionPlay})
+	if nil != err {
+		return nil, err
+	}
+	if err := serve(pusher); nil != err {
+		return nil, err
+	}
+	log.WithField("id", deviceID).Infof("channel[%s] play on %s", channelID, path)
+
+	return pusher, nil
+}
+
+// serve the on-demand pusher by RTSP server, it is stopped on error
+func serve(pusher *rtsp.PSPusher) error {
+	pusher.SetOnDemand(true)
+	if !rtsp.GetServer().AddPusher(pusher, false) {
+		pusher.Stop()
+		return fmt.Errorf("pusher of path %s exists", pusher.Path())
+	}
+	if err := pusher.WaitReady(streamTimeout()); nil != err {
+		pusher.Stop()
+		return err
+	}
+	return nil
+}
+
+// getPlayPusher starts live when player requests rtsp://server/gb/[deviceID]/[channelID]
+func getPlayPusher(server *rtsp.Server, session *rtsp.Session, path string, pusher rtsp.Pusher) rtsp.Pusher {
+	if nil != pusher {
+		// players should wait for SDP
+		if ps, ok := pusher.(*rtsp.PSPusher); ok {
+			if err := ps.WaitReady(streamTimeout()); nil != err {
+				return nil
+			}
+		}
+		return pusher
+	}
+	if nil == session {
+		// on-demand stream needs a player to stop it
+		return pusher
+	}
+
+	parts := strings.Split(path, "/")
+	if len(parts) != 4 || parts[1] != "gb" {
+		return pusher
+	}
+	deviceID, channelID := parts[2], parts[3]
+	_pusher, err := Instance.Play(deviceID, channelID)
+	if nil != err {
+		log.WithError(err).WithField("id", deviceID).Errorf("channel[%s] play", channelID)
+		return nil
+	}
+
+	return _pusher
+}
+
+func initPlay() error {
+	rtsp.GetServer().AddOnGetPusherHandle(getPlayPusher)
+	return nil
+}
@@ -0,0 +1,249 @@
+package gb28181
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/EasyDarwin/EasyDarwin/rtsp"
+)
+
+// ContentTypeMANSRTSP of INFO body controlling playback
+const ContentTypeMANSRTSP = "Application/MANSRTSP"
+
+// MediaStatus NotifyType
+const (
+	// MediaStatusEnd of record file sending
+	MediaStatusEnd = "121"
+)
+
+// PlaybackPath of channel record for RTSP, ID makes the path unique for each player like VOD
+func PlaybackPath(deviceID string, channelID string, start int64, end int64, ID string) string {
+	return fmt.Sprintf("/gb/playback/%s/%s/%d/%d/%s", deviceID, channelID, start, end, ID)
+}
+
+// DownloadPath of channel record for RTSP, device sends the record with download_speed
+func DownloadPath(deviceID string, channelID string, start int64, end int64, ID string) string {
+	return fmt.Sprintf("/gb/download/%s/%s/%d/%d/%s", deviceID, channelID, start, end, ID)
+}
+
+// playbackControl maps RTSP PLAY/PAUSE of player to MANSRTSP INFO of dialog
+type playbackControl struct {
+	dialog *Dialog
+	// start of record in unix seconds, npt of MANSRTSP is relative to it
+	start int64
+
+	lock    sync.Mutex
+	cseq    int
+	started bool
+	paused  bool
+}
+
+// info sends MANSRTSP command to device
+func (control *playbackControl) info(method string, lines ...string) error {
+	control.lock.Lock()
+	control.cseq++
+	cseq := control.cseq
+	control.lock.Unlock()
+
+	body := fmt.Sprintf("%s MANSRTSP/1.0\r\nCSeq: %d\r\n", method, cseq)
+	for _, line := range lines {
+		body += line + "\r\n"
+	}
+	req := control.dialog.NewRequest(INFO)
+	req.SetBody(ContentTypeMANSRTSP, []byte(body))
+	res, err := control.dialog.server.Request(control.dialog.peer, req, streamTimeout())
+	if nil != err {
+		return err
+	}
+	if res.StatusCode != 200 {
+		return fmt.Errorf("%s %s response %d %s", control.dialog, method, res.StatusCode, res.Reason)
+	}
+	log.WithField("id", control.dialog.peer.ID()).Debugf("%s %s %s", control.dialog, method, strings.Join(lines, ","))
+	return nil
+}
+
+// seek parses RTSP Range to npt seconds of record, false if it is not a seek
+func (control *playbackControl) seek(rangeValue string) (int64, bool) {
+	rangeValue = strings.TrimSpace(rangeValue)
+	var begin string
+	switch {
+	case strings.HasPrefix(rangeValue, "npt="):
+		begin = strings.SplitN(strings.TrimPrefix(rangeValue, "npt="), "-", 2)[0]
+		if begin == "" || begin == "now" {
+			return 0, false
+		}
+		value, err := strconv.ParseFloat(begin, 64)
+		if nil != err {
+			return 0, false
+		}
+		return int64(value), true
+	case strings.HasPrefix(rangeValue, "clock="):
+		begin = strings.SplitN(strings.TrimPrefix(rangeValue, "clock="), "-", 2)[0]
+		// fraction of second is ignored
+		if idx := strings.Index(begin, "."); idx >= 0 {
+			begin = begin[:idx] + "Z"
+		}
+		t, err := time.Parse("20060102T150405Z", begin)
+		if nil != err {
+			return 0, false
+		}
+		npt := t.Unix() - control.start
+		if npt < 0 {
+			npt = 0
+		}
+		return npt, true
+	}
+	return 0, false
+}
+
+// Play seeks, changes scale or resumes, the first PLAY is done by INVITE
+func (control *playbackControl) Play(rangeValue string, scale string) error {
+	control.lock.Lock()
+	first := !control.started
+	paused := control.paused
+	control.started = true
+	control.paused = false
+	control.lock.Unlock()
+
+	lines := []string{}
+	if scale != "" {
+		value, err := strconv.ParseFloat(scale, 64)
+		if nil != err || value == 0 {
+			return fmt.Errorf("invalid scale %s", scale)
+		}
+		if !first || value != 1 {
+			lines = append(lines, fmt.Sprintf("Scale: %s", scale))
+		}
+	}
+	if npt, ok := control.seek(rangeValue); ok && (!first || npt > 0) {
+		lines = append(lines, fmt.Sprintf("Range: npt=%d-", npt))
+	} else if paused {
+		lines = append(lines, "Range: npt=now-")
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	return control.info("PLAY", lines...)
+}
+
+// Pause the device sending
+func (control *playbackControl) Pause() error {
+	control.lock.Lock()
+	control.paused = true
+	control.lock.Unlock()
+
+	return control.info("PAUSE", "PauseTime: now")
+}
+
+// Playback record of channel between start and end, sessionName is Playback or Download
+func (server *Server) Playback(deviceID string, channelID string, sessionName string, start int64, end int64, path string) (rtsp.Pusher, error) {
+	device := server.GetDevice(deviceID)
+	if nil == device {
+		return nil, ErrorDeviceNotFound
+	}
+	if !device.Online() {
+		return nil, ErrorDeviceOffline
+	}
+
+	offer := &mediaOffer{
+		sessionName: sessionName,
+		start:       start,
+		end:         end,
+	}
+	if sessionName == SessionDownload {
+		offer.speed = config.Media.DownloadSpeed
+	}
+	pusher, dialog, err := server.invite(device, channelID, path, offer)
+	if nil != err {
+		return nil, err
+	}
+	if sessionName == SessionPlayback {
+		pusher.SetPlayControl(&playbackControl{dialog: dialog, start: start})
+	}
+	if err := serve(pusher); nil != err {
+		return nil, err
+	}
+	log.WithField("id", deviceID).Infof("channel[%s] %s %d-%d on %s", channelID, sessionName, start, end, path)
+
+	return pusher, nil
+}
+
+// handleMediaStatus stops the playback and download of channel when device finishes sending
+func (server *Server) handleMediaStatus(device *Device, body []byte) {
+	status := &MediaStatus{}
+	if err := decodeMANSCDP(body, status); err != nil {
+		log.WithError(err).WithField("id", device.ID()).Warn("decode MediaStatus")
+		return
+	}
+	if status.NotifyType != MediaStatusEnd {
+		return
+	}
+	dialogs := server.mediaStatusDialogs(device, status.DeviceID)
+	if len(dialogs) == 0 {
+		// some NVRs notify with their own ID, not the channel
+		dialogs = server.mediaStatusDialogs(device, "")
+	}
+	for _, dialog := range dialogs {
+		log.WithField("id", device.ID()).Infof("%s media end", dialog)
+		// pusher stops and then sends BYE
+		go dialog.end()
+	}
+}
+
+// mediaStatusDialogs of playback and download of channel, all channels of device if channelID is empty
+func (server *Server) mediaStatusDialogs(device *Device, channelID string) []*Dialog {
+	dialogs := make([]*Dialog, 0)
+	for _, sessionName := range []string{SessionPlayback, SessionDownload} {
+		dialogs = append(dialogs, server.getDialogs(device, channelID, sessionName)...)
+	}
+	return dialogs
+}
+
+// getPlaybackPusher starts playback when player requests
+// rtsp://server/gb/[playback|download]/[deviceID]/[channelID]/[start]/[end]/[ID]
+func getPlaybackPusher(server *rtsp.Server, session *rtsp.Session, path string, pusher rtsp.Pusher) rtsp.Pusher {
+	if nil != pusher || nil == session {
+		return pusher
+	}
+
+	parts := strings.Split(path, "/")
+	if len(parts) != 8 || parts[1] != "gb" {
+		return pusher
+	}
+	var sessionName string
+	switch parts[2] {
+	case "playback":
+		sessionName = SessionPlayback
+	case "download":
+		sessionName = SessionDownload
+	default:
+		return pusher
+	}
+	deviceID, channelID := parts[3], parts[4]
+	start, err := strconv.ParseInt(parts[5], 10, 64)
+	if nil != err {
+		log.WithError(err).WithField("path", path).Error("playback URL parse start")
+		return nil
+	}
+	end, err := strconv.ParseInt(parts[6], 10, 64)
+	if nil != err || end <= start {
+		log.WithError(err).WithField("path", path).Error("playback URL parse end")
+		return nil
+	}
+
+	_pusher, err := Instance.Playback(deviceID, channelID, sessionName, start, end, path)
+	if nil != err {
+		log.WithError(err).WithField("id", deviceID).Errorf("channel[%s] %s", channelID, sessionName)
+		return nil
+	}
+
+	return _pusher
+}
+
+func initPlayback() error {
+	rtsp.GetServer().AddOnGetPusherHandle(getPlaybackPusher)
+	return nil
+}
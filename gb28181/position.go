@@ -0,0 +1,74 @@
+package gb28181
+
+import (
+	"encoding/xml"
+	"time"
+
+	"github.com/EasyDarwin/EasyDarwin/models"
+)
+
+// MobilePositionNotify of device by MESSAGE or NOTIFY of subscription
+type MobilePositionNotify struct {
+	XMLName   xml.Name `xml:"Notify"`
+	CmdType   string   `xml:"CmdType"`
+	SN        int      `xml:"SN"`
+	DeviceID  string   `xml:"DeviceID"`
+	Time      string   `xml:"Time"`
+	Longitude string   `xml:"Longitude"`
+	Latitude  string   `xml:"Latitude"`
+	Speed     string   `xml:"Speed"`
+	Direction string   `xml:"Direction"`
+	Altitude  string   `xml:"Altitude"`
+}
+
+// MobilePositionQuery of SUBSCRIBE, Interval of reporting in seconds
+type MobilePositionQuery struct {
+	XMLName  xml.Name `xml:"Query"`
+	CmdType  string   `xml:"CmdType"`
+	SN       int      `xml:"SN"`
+	DeviceID string   `xml:"DeviceID"`
+	Interval int      `xml:"Interval"`
+}
+
+func newMobilePositionQuery(deviceID string, sn int) *MobilePositionQuery {
+	return &MobilePositionQuery{
+		CmdType:  "MobilePosition",
+		SN:       sn,
+		DeviceID: deviceID,
+		Interval: config.SIP.PositionInterval,
+	}
+}
+
+// handleMobilePosition of device, store it in history
+func (server *Server) handleMobilePosition(device *Device, body []byte) {
+	notify := &MobilePositionNotify{}
+	if err := decodeMANSCDP(body, notify); err != nil {
+		log.WithError(err).WithField("id", device.ID()).Warn("decode MobilePosition")
+		return
+	}
+
+	now := time.Now()
+	position := &models.Position{
+		DeviceID:  device.ID(),
+		ChannelID: notify.DeviceID,
+		Longitude: atof(notify.Longitude),
+		Latitude:  atof(notify.Latitude),
+		Speed:     atof(notify.Speed),
+		Direction: atof(notify.Direction),
+		Altitude:  atof(notify.Altitude),
+	}
+	if t, err := parseRecordTime(notify.Time); nil == err {
+		position.Time = t
+	} else {
+		position.Time = now.Unix()
+	}
+	keepAfter := int64(0)
+	if config.SIP.PositionKeepDays > 0 {
+		keepAfter = now.AddDate(0, 0, -config.SIP.PositionKeepDays).Unix()
+	}
+	if err := models.AddPosition(position, keepAfter); err != nil {
+		log.WithError(err).WithField("id", device.ID()).Error("add position")
+		return
+	}
+	log.WithField("id", device.ID()).Debugf("channel[%s] position %f,%f", position.ChannelID, position.Longitude, position.Latitude)
+}
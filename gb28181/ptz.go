@@ -0,0 +1,163 @@
+package gb28181
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PTZ command names of API, ONVIF PTZ uses the same names
+const (
+	PTZStop      = "stop"
+	PTZLeft      = "left"
+	PTZRight     = "right"
+	PTZUp        = "up"
+	PTZDown      = "down"
+	PTZUpLeft    = "upleft"
+	PTZUpRight   = "upright"
+	PTZDownLeft  = "downleft"
+	PTZDownRight = "downright"
+	PTZZoomIn    = "zoomin"
+	PTZZoomOut   = "zoomout"
+
+	PTZFocusNear = "focusnear"
+	PTZFocusFar  = "focusfar"
+	PTZIrisOpen  = "irisopen"
+	PTZIrisClose = "irisclose"
+
+	PTZPresetSet    = "presetset"
+	PTZPresetGoto   = "presetgoto"
+	PTZPresetRemove = "presetremove"
+
+	PTZCruiseAdd    = "cruiseadd"
+	PTZCruiseRemove = "cruiseremove"
+	PTZCruiseSpeed  = "cruisespeed"
+	PTZCruiseDwell  = "cruisedwell"
+	PTZCruiseStart  = "cruisestart"
+	PTZCruiseStop   = "cruisestop"
+)
+
+// bits of PTZCmd byte 4, see GB28181 appendix A.3
+const (
+	ptzRight   byte = 0x01
+	ptzLeft    byte = 0x02
+	ptzDown    byte = 0x04
+	ptzUp      byte = 0x08
+	ptzZoomIn  byte = 0x10
+	ptzZoomOut byte = 0x20
+
+	ptzFocusFar  byte = 0x41
+	ptzFocusNear byte = 0x42
+	ptzIrisOpen  byte = 0x44
+	ptzIrisClose byte = 0x48
+
+	ptzPresetSet    byte = 0x81
+	ptzPresetGoto   byte = 0x82
+	ptzPresetRemove byte = 0x83
+	ptzCruiseAdd    byte = 0x84
+	ptzCruiseRemove byte = 0x85
+	ptzCruiseSpeed  byte = 0x86
+	ptzCruiseDwell  byte = 0x87
+	ptzCruiseStart  byte = 0x88
+)
+
+// PTZParam of PTZ command, unused fields are ignored
+type PTZParam struct {
+	// Speed of pan/tilt 0-255, zoom 0-15, focus and iris 0-255
+	Speed int
+	// Preset number 1-255
+	Preset int
+	// Group of cruise 0-255
+	Group int
+	// Value of cruise speed or dwell seconds, 12 bits
+	Value int
+}
+
+// PTZCmd of 8 bytes in DeviceControl
+type PTZCmd [8]byte
+
+// NewPTZCmd with address 1, data3 is the high 4 bits of byte 7
+func NewPTZCmd(command byte, data1 byte, data2 byte, data3 byte) PTZCmd {
+	// byte 2 is version 0 and check (0xA + 0x5 + version) % 16
+	cmd := PTZCmd{0xA5, 0x0F, 0x01, command, data1, data2, data3 << 4}
+	sum := 0
+	for _, b := range cmd[:7] {
+		sum += int(b)
+	}
+	cmd[7] = byte(sum % 256)
+	return cmd
+}
+
+// String of PTZCmd in upper hex as DeviceControl requires
+func (cmd PTZCmd) String() string {
+	return fmt.Sprintf("%X", cmd[:])
+}
+
+// ParsePTZCmd of command name
+func ParsePTZCmd(command string, param PTZParam) (PTZCmd, error) {
+	speed := byte(clamp(param.Speed, 0, 255))
+	zoom := byte(clamp(param.Speed, 0, 255) >> 4)
+	preset := byte(clamp(param.Preset, 0, 255))
+	group := byte(clamp(param.Group, 0, 255))
+	value := clamp(param.Value, 0, 0xFFF)
+
+	switch strings.ToLower(command) {
+	case PTZStop, PTZCruiseStop:
+		return NewPTZCmd(0, 0, 0, 0), nil
+	case PTZLeft:
+		return NewPTZCmd(ptzLeft, speed, 0, 0), nil
+	case PTZRight:
+		return NewPTZCmd(ptzRight, speed, 0, 0), nil
+	case PTZUp:
+		return NewPTZCmd(ptzUp, 0, speed, 0), nil
+	case PTZDown:
+		return NewPTZCmd(ptzDown, 0, speed, 0), nil
+	case PTZUpLeft:
+		return NewPTZCmd(ptzUp|ptzLeft, speed, speed, 0), nil
+	case PTZUpRight:
+		return NewPTZCmd(ptzUp|ptzRight, speed, speed, 0), nil
+	case PTZDownLeft:
+		return NewPTZCmd(ptzDown|ptzLeft, speed, speed, 0), nil
+	case PTZDownRight:
+		return NewPTZCmd(ptzDown|ptzRight, speed, speed, 0), nil
+	case PTZZoomIn:
+		return NewPTZCmd(ptzZoomIn, 0, 0, zoom), nil
+	case PTZZoomOut:
+		return NewPTZCmd(ptzZoomOut, 0, 0, zoom), nil
+	case PTZFocusNear:
+		return NewPTZCmd(ptzFocusNear, speed, 0, 0), nil
+	case PTZFocusFar:
+		return NewPTZCmd(ptzFocusFar, speed, 0, 0), nil
+	case PTZIrisOpen:
+		return NewPTZCmd(ptzIrisOpen, 0, speed, 0), nil
+	case PTZIrisClose:
+		return NewPTZCmd(ptzIrisClose, 0, speed, 0), nil
+	case PTZPresetSet, PTZPresetGoto, PTZPresetRemove:
+		if preset == 0 {
+			return PTZCmd{}, fmt.Errorf("preset of %s should be 1-255", command)
+		}
+		codes := map[string]byte{PTZPresetSet: ptzPresetSet, PTZPresetGoto: ptzPresetGoto, PTZPresetRemove: ptzPresetRemove}
+		return NewPTZCmd(codes[strings.ToLower(command)], 0, preset, 0), nil
+	case PTZCruiseAdd:
+		return NewPTZCmd(ptzCruiseAdd, group, preset, 0), nil
+	case PTZCruiseRemove:
+		// preset 0 removes the whole cruise
+		return NewPTZCmd(ptzCruiseRemove, group, preset, 0), nil
+	case PTZCruiseSpeed:
+		return NewPTZCmd(ptzCruiseSpeed, group, byte(value), byte(value>>8)), nil
+	case PTZCruiseDwell:
+		return NewPTZCmd(ptzCruiseDwell, group, byte(value), byte(value>>8)), nil
+	case PTZCruiseStart:
+		return NewPTZCmd(ptzCruiseStart, group, 0, 0), nil
+	}
+	return PTZCmd{}, fmt.Errorf("unknown PTZ command %s", command)
+}
+
+func clamp(value int, min int, max int) int {
+	if value < min {
+		return min
+	}
+	if value > max {
+		return max
+	}
+	return value
+}
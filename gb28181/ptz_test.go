@@ -0,0 +1,38 @@
+package gb28181
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePTZCmd(t *testing.T) {
+	tests := []struct {
+		command string
+		param   PTZParam
+		cmd     string
+		err     bool
+	}{
+		{command: "stop", cmd: "A50F0100000000B5"},
+		{command: "cruisestop", cmd: "A50F0100000000B5"},
+		{command: "left", param: PTZParam{Speed: 128}, cmd: "A50F010280000037"},
+		{command: "UpRight", param: PTZParam{Speed: 300}, cmd: "A50F0109FFFF00BC"},
+		{command: "zoomin", param: PTZParam{Speed: 255}, cmd: "A50F01100000F0B5"},
+		{command: "irisclose", param: PTZParam{Speed: 16}, cmd: "A50F01480010000D"},
+		{command: "presetgoto", param: PTZParam{Preset: 3}, cmd: "A50F01820003003A"},
+		{command: "presetset", param: PTZParam{Preset: 0}, err: true},
+		{command: "cruisespeed", param: PTZParam{Group: 1, Value: 0x123}, cmd: "A50F01860123106F"},
+		{command: "jump", err: true},
+	}
+	for _, test := range tests {
+		t.Run(test.command, func(t *testing.T) {
+			cmd, err := ParsePTZCmd(test.command, test.param)
+			if test.err {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, test.cmd, cmd.String())
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package gb28181
+
+import (
+	"fmt"
+	"time"
+)
+
+// query of MANSCDP sent to device, responses come back as MESSAGE with the same CmdType and SN
+type query struct {
+	key    string
+	bodies chan []byte
+}
+
+func queryKey(deviceID string, cmdType string, sn int) string {
+	return fmt.Sprintf("%s:%s:%d", deviceID, cmdType, sn)
+}
+
+func queryTimeout() time.Duration {
+	return time.Duration(config.SIP.QueryTimeout) * time.Second
+}
+
+// Query device by MANSCDP body, handle each response until it returns done or timeout
+func (server *Server) Query(device *Device, cmdType string, sn int, body []byte, handle func(body []byte) (done bool, err error)) error {
+	q := &query{
+		key:    queryKey(device.ID(), cmdType, sn),
+		bodies: make(chan []byte, 256),
+	}
+	server.queriesLock.Lock()
+	server.queries[q.key] = q
+	server.queriesLock.Unlock()
+	defer func() {
+		server.queriesLock.Lock()
+		delete(server.queries, q.key)
+		server.queriesLock.Unlock()
+	}()
+
+	deadline := time.NewTimer(queryTimeout())
+	defer deadline.Stop()
+
+	req := server.NewDeviceRequest(device, MESSAGE)
+	req.SetBody(ContentTypeMANSCDP, body)
+	res, err := server.Request(device, req, queryTimeout())
+	if err != nil {
+		return err
+	}
+	if res.StatusCode != 200 {
+		return fmt.Errorf("%s query %s response %d %s", device, cmdType, res.StatusCode, res.Reason)
+	}
+
+	for {
+		select {
+		case body := <-q.bodies:
+			done, err := handle(body)
+			if err != nil {
+				return err
+			}
+			if done {
+				return nil
+			}
+		case <-deadline.C:
+			return ErrorTimeout
+		}
+	}
+}
+
+// handleQueryResponse deliver response body to the waiting query
+func (server *Server) handleQueryResponse(device *Device, header *manscdpHeader, body []byte) {
+	key := queryKey(device.ID(), header.CmdType, header.SN)
+	server.queriesLock.RLock()
+	q, ok := server.queries[key]
+	server.queriesLock.RUnlock()
+	if !ok {
+		log.WithField("id", device.ID()).Infof("MANSCDP response %s[%d] without query", header.CmdType, header.SN)
+		return
+	}
+
+	select {
+	case q.bodies <- body:
+	default:
+		log.WithField("id", device.ID()).Warnf("MANSCDP response %s[%d] dropped", header.CmdType, header.SN)
+	}
+}
@@ -0,0 +1,176 @@
+package gb28181
+
+import (
+	"encoding/xml"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RecordInfo types of query
+const (
+	RecordTypeAll    = "all"
+	RecordTypeTime   = "time"
+	RecordTypeAlarm  = "alarm"
+	RecordTypeManual = "manual"
+)
+
+// recordTimeLayout of RecordInfo, local time without zone
+const recordTimeLayout = "2006-01-02T15:04:05"
+
+// RecordInfoQuery to device
+type RecordInfoQuery struct {
+	XMLName   xml.Name `xml:"Query"`
+	CmdType   string   `xml:"CmdType"`
+	SN        int      `xml:"SN"`
+	DeviceID  string   `xml:"DeviceID"`
+	StartTime string   `xml:"StartTime"`
+	EndTime   string   `xml:"EndTime"`
+	Secrecy   int      `xml:"Secrecy"`
+	Type      string   `xml:"Type"`
+}
+
+// RecordItem of device RecordInfo response
+type RecordItem struct {
+	DeviceID   string `xml:"DeviceID"`
+	Name       string `xml:"Name"`
+	FilePath   string `xml:"FilePath"`
+	Address    string `xml:"Address"`
+	StartTime  string `xml:"StartTime"`
+	EndTime    string `xml:"EndTime"`
+	Secrecy    string `xml:"Secrecy"`
+	Type       string `xml:"Type"`
+	RecorderID string `xml:"RecorderID"`
+	FileSize   string `xml:"FileSize"`
+}
+
+// RecordInfoResponse of device, may be split into many MESSAGE
+type RecordInfoResponse struct {
+	XMLName    xml.Name `xml:"Response"`
+	CmdType    string   `xml:"CmdType"`
+	SN         int      `xml:"SN"`
+	DeviceID   string   `xml:"DeviceID"`
+	Name       string   `xml:"Name"`
+	SumNum     int      `xml:"SumNum"`
+	RecordList struct {
+		Num   int          `xml:"Num,attr"`
+		Items []RecordItem `xml:"Item"`
+	} `xml:"RecordList"`
+}
+
+// RecordSegment of merged timeline in unix seconds
+type RecordSegment struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+}
+
+// RecordTimeline of channel
+type RecordTimeline struct {
+	ChannelID string           `json:"channelID"`
+	Segments  []*RecordSegment `json:"segments"`
+}
+
+func formatRecordTime(t int64) string {
+	return time.Unix(t, 0).Format(recordTimeLayout)
+}
+
+// parseRecordTime of device, some devices append Z or use space as separator
+func parseRecordTime(value string) (int64, error) {
+	value = strings.Replace(strings.TrimSpace(value), " ", "T", 1)
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse(recordTimeLayout, strings.TrimSuffix(value, "Z"))
+		return t.Unix(), err
+	}
+	t, err := time.ParseInLocation(recordTimeLayout, value, time.Local)
+	return t.Unix(), err
+}
+
+// QueryRecordInfo of channel between start and end, partial records are returned on timeout
+func (server *Server) QueryRecordInfo(device *Device, channelID string, start int64, end int64, recordType string) ([]*RecordItem, error) {
+	if !device.Online() {
+		return nil, ErrorDeviceOffline
+	}
+	if recordType == "" {
+		recordType = RecordTypeAll
+	}
+	sn := server.nextSN()
+	body, err := encodeMANSCDP(&RecordInfoQuery{
+		CmdType:   "RecordInfo",
+		SN:        sn,
+		DeviceID:  channelID,
+		StartTime: formatRecordTime(start),
+		EndTime:   formatRecordTime(end),
+		Type:      recordType,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]*RecordItem, 0)
+	err = server.Query(device, "RecordInfo", sn, body, func(body []byte) (bool, error) {
+		res := &RecordInfoResponse{}
+		if err := decodeMANSCDP(body, res); err != nil {
+			return false, err
+		}
+		for i := range res.RecordList.Items {
+			record := &res.RecordList.Items[i]
+			if record.DeviceID == "" {
+				record.DeviceID = channelID
+			}
+			records = append(records, record)
+		}
+		return len(records) >= res.SumNum, nil
+	})
+	if err == ErrorTimeout && len(records) > 0 {
+		log.WithField("id", device.ID()).Warnf("record info of %s timeout, %d records received", channelID, len(records))
+	} else if err != nil {
+		return nil, err
+	}
+	log.WithField("id", device.ID()).Infof("record info of %s, %d records", channelID, len(records))
+	return records, nil
+}
+
+// MergeRecords into timeline of each channel, overlapping and adjacent records are merged
+func MergeRecords(records []*RecordItem) []*RecordTimeline {
+	segments := make(map[string][]*RecordSegment)
+	channelIDs := make([]string, 0)
+	for _, record := range records {
+		start, err := parseRecordTime(record.StartTime)
+		if err != nil {
+			continue
+		}
+		end, err := parseRecordTime(record.EndTime)
+		if err != nil || end < start {
+			continue
+		}
+		if _, ok := segments[record.DeviceID]; !ok {
+			channelIDs = append(channelIDs, record.DeviceID)
+		}
+		segments[record.DeviceID] = append(segments[record.DeviceID], &RecordSegment{Start: start, End: end})
+	}
+
+	timelines := make([]*RecordTimeline, 0, len(channelIDs))
+	for _, channelID := range channelIDs {
+		all := segments[channelID]
+		sort.Slice(all, func(i, j int) bool {
+			return all[i].Start < all[j].Start
+		})
+		merged := []*RecordSegment{all[0]}
+		for _, segment := range all[1:] {
+			last := merged[len(merged)-1]
+			// devices split files at second boundary
+			if segment.Start <= last.End+1 {
+				if segment.End > last.End {
+					last.End = segment.End
+				}
+				continue
+			}
+			merged = append(merged, segment)
+		}
+		timelines = append(timelines, &RecordTimeline{
+			ChannelID: channelID,
+			Segments:  merged,
+		})
+	}
+	return timelines
+}
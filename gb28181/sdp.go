@@ -0,0 +1,169 @@
+package gb28181
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/EasyDarwin/EasyDarwin/rtsp"
+)
+
+// ContentTypeSDP of INVITE body
+const ContentTypeSDP = "application/sdp"
+
+// Session names of INVITE SDP
+const (
+	SessionPlay     = "Play"
+	SessionPlayback = "Playback"
+	SessionDownload = "Download"
+)
+
+// mediaOffer of INVITE SDP, server receives PS from device
+type mediaOffer struct {
+	sessionName string
+	channelID   string
+	host        string
+	port        int
+	transport   string
+	ssrc        string
+	// time range in unix seconds for playback and download
+	start int64
+	end   int64
+	// download speed
+	speed int
+}
+
+func (offer *mediaOffer) SDP() []byte {
+	proto := "RTP/AVP"
+	if offer.transport == TransportTCP {
+		proto = "TCP/RTP/AVP"
+	}
+	lines := []string{
+		"v=0",
+		fmt.Sprintf("o=%s 0 0 IN IP4 %s", config.SIP.ID, offer.host),
+		fmt.Sprintf("s=%s", offer.sessionName),
+	}
+	if offer.sessionName != SessionPlay {
+		lines = append(lines, fmt.Sprintf("u=%s:0", offer.channelID))
+	}
+	lines = append(lines,
+		fmt.Sprintf("c=IN IP4 %s", offer.host),
+		fmt.Sprintf("t=%d %d", offer.start, offer.end),
+		fmt.Sprintf("m=video %d %s 96 98 97", offer.port, proto),
+		"a=recvonly",
+		"a=rtpmap:96 PS/90000",
+		"a=rtpmap:98 H264/90000",
+		"a=rtpmap:97 MPEG4/90000",
+	)
+	if offer.transport == TransportTCP {
+		lines = append(lines, "a=setup:passive", "a=connection:new")
+	}
+	if offer.sessionName == SessionDownload && offer.speed > 0 {
+		lines = append(lines, fmt.Sprintf("a=downloadspeed:%d", offer.speed))
+	}
+	lines = append(lines, fmt.Sprintf("y=%s", offer.ssrc), "f=")
+	return []byte(strings.Join(lines, "\r\n") + "\r\n")
+}
+
+// sdpField of type like `y` in SDP, the first one
+func sdpField(sdp []byte, field string) string {
+	prefix := field + "="
+	for _, line := range strings.Split(string(sdp), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, prefix) {
+			return strings.TrimPrefix(line, prefix)
+		}
+	}
+	return ""
+}
+
+// sdpAttribute of name like `setup` in SDP, the first one
+func sdpAttribute(sdp []byte, name string) string {
+	prefix := "a=" + name + ":"
+	for _, line := range strings.Split(string(sdp), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, prefix) {
+			return strings.TrimPrefix(line, prefix)
+		}
+	}
+	return ""
+}
+
+// mediaRequest of INVITE SDP from peer receiving stream from server, like upstream platform or device of broadcast
+type mediaRequest struct {
+	sessionName string
+	// remote address to send, empty if peer connects server by TCP
+	remote       string
+	transType    rtsp.TransType
+	setup        string
+	payloadTypes []string
+	ssrc         string
+}
+
+// parseMediaRequest of the first media in SDP
+func parseMediaRequest(sdp []byte) (*mediaRequest, error) {
+	// c=IN IP4 host, m=video port RTP/AVP 96 98
+	connection := strings.Fields(sdpField(sdp, "c"))
+	media := strings.Fields(sdpField(sdp, "m"))
+	if len(connection) < 3 || len(media) < 4 {
+		return nil, fmt.Errorf("SDP without connection or media")
+	}
+	request := &mediaRequest{
+		sessionName:  sdpField(sdp, "s"),
+		remote:       net.JoinHostPort(connection[2], media[1]),
+		transType:    rtsp.TRANS_TYPE_UDP,
+		payloadTypes: media[3:],
+		ssrc:         sdpField(sdp, "y"),
+	}
+	if strings.Contains(strings.ToUpper(media[2]), "TCP") {
+		request.transType = rtsp.TRANS_TYPE_TCP
+		request.setup = "active"
+		if sdpAttribute(sdp, "setup") == "active" {
+			// peer connects to server
+			request.remote = ""
+			request.setup = "passive"
+		}
+	}
+	return request, nil
+}
+
+// hasPayloadType in media of request
+func (request *mediaRequest) hasPayloadType(payloadType int) bool {
+	for _, value := range request.payloadTypes {
+		if value == strconv.Itoa(payloadType) {
+			return true
+		}
+	}
+	return false
+}
+
+// SSRC in y field, 0 if absent
+func (request *mediaRequest) SSRC() uint32 {
+	value, _ := strconv.ParseUint(request.ssrc, 10, 32)
+	return uint32(value)
+}
+
+// answer SDP of server sending media from port with payload type like `96 PS/90000`
+func (request *mediaRequest) answer(ID string, media string, port int, rtpmap string) []byte {
+	proto := "RTP/AVP"
+	if request.transType == rtsp.TRANS_TYPE_TCP {
+		proto = "TCP/RTP/AVP"
+	}
+	payloadType := strings.SplitN(rtpmap, " ", 2)[0]
+	lines := []string{
+		"v=0",
+		fmt.Sprintf("o=%s 0 0 IN IP4 %s", ID, mediaHost()),
+		fmt.Sprintf("s=%s", request.sessionName),
+		fmt.Sprintf("c=IN IP4 %s", mediaHost()),
+		"t=0 0",
+		fmt.Sprintf("m=%s %d %s %s", media, port, proto, payloadType),
+		"a=sendonly",
+		fmt.Sprintf("a=rtpmap:%s", rtpmap),
+	}
+	if request.setup != "" {
+		lines = append(lines, fmt.Sprintf("a=setup:%s", request.setup), "a=connection:new")
+	}
+	lines = append(lines, fmt.Sprintf("y=%s", request.ssrc), "f=")
+	return []byte(strings.Join(lines, "\r\n") + "\r\n")
+}
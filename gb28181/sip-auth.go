@@ -0,0 +1,112 @@
+package gb28181
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/EasyDarwin/EasyDarwin/utils"
+)
+
+// parseAuthParams of `Digest username="x", realm="y", nc=00000001`
+func parseAuthParams(authLine string) map[string]string {
+	params := make(map[string]string)
+	authLine = strings.TrimSpace(authLine)
+	if idx := strings.Index(authLine, " "); idx >= 0 {
+		authLine = authLine[idx+1:]
+	}
+
+	for len(authLine) > 0 {
+		eq := strings.Index(authLine, "=")
+		if eq < 0 {
+			break
+		}
+		key := strings.ToLower(strings.Trim(authLine[:eq], " ,"))
+		authLine = strings.TrimSpace(authLine[eq+1:])
+		value := ""
+		if strings.HasPrefix(authLine, `"`) {
+			end := strings.Index(authLine[1:], `"`)
+			if end < 0 {
+				value = authLine[1:]
+				authLine = ""
+			} else {
+				value = authLine[1 : end+1]
+				authLine = authLine[end+2:]
+			}
+		} else {
+			end := strings.Index(authLine, ",")
+			if end < 0 {
+				value = authLine
+				authLine = ""
+			} else {
+				value = authLine[:end]
+				authLine = authLine[end+1:]
+			}
+		}
+		params[key] = strings.TrimSpace(value)
+	}
+
+	return params
+}
+
+// checkDigestAuth of SIP request, RFC2617 with or without qop.
+// realm and username are the ones expected by server, never trust the client
+func checkDigestAuth(authLine string, method string, nonce string, realm string, username string, password string) error {
+	if !strings.HasPrefix(strings.ToLower(strings.TrimSpace(authLine)), "digest") {
+		return ErrorAuthFailed
+	}
+	params := parseAuthParams(authLine)
+	if params["nonce"] != nonce {
+		return fmt.Errorf("sip digest auth error : nonce not match")
+	}
+	if params["response"] == "" {
+		return fmt.Errorf("sip digest auth error : response not found")
+	}
+	if params["realm"] != realm {
+		return fmt.Errorf("sip digest auth error : realm[%s] not match", params["realm"])
+	}
+	if params["username"] != username {
+		return fmt.Errorf("sip digest auth error : username[%s] not match", params["username"])
+	}
+
+	ha1 := utils.MD5(fmt.Sprintf("%s:%s:%s", username, realm, password))
+	ha2 := utils.MD5(fmt.Sprintf("%s:%s", method, params["uri"]))
+	var response string
+	if qop := params["qop"]; qop == "auth" || qop == "auth-int" {
+		response = utils.MD5(fmt.Sprintf("%s:%s:%s:%s:%s:%s",
+			ha1, nonce, params["nc"], params["cnonce"], qop, ha2))
+	} else {
+		response = utils.MD5(fmt.Sprintf("%s:%s:%s", ha1, nonce, ha2))
+	}
+
+	if !strings.EqualFold(response, params["response"]) {
+		return ErrorAuthFailed
+	}
+	return nil
+}
+
+// digestAuthorization answers challenge of WWW-Authenticate or Proxy-Authenticate, qop auth is preferred
+func digestAuthorization(challenge string, method string, uri string, username string, password string) string {
+	params := parseAuthParams(challenge)
+	ha1 := utils.MD5(fmt.Sprintf("%s:%s:%s", username, params["realm"], password))
+	ha2 := utils.MD5(fmt.Sprintf("%s:%s", method, uri))
+	authLine := fmt.Sprintf(`Digest username="%s", realm="%s", nonce="%s", uri="%s", algorithm=MD5`,
+		username, params["realm"], params["nonce"], uri)
+
+	qop := ""
+	for _, value := range strings.Split(params["qop"], ",") {
+		if strings.TrimSpace(value) == "auth" {
+			qop = "auth"
+		}
+	}
+	if qop != "" {
+		nc, cnonce := "00000001", randomHex(8)
+		response := utils.MD5(fmt.Sprintf("%s:%s:%s:%s:%s:%s", ha1, params["nonce"], nc, cnonce, qop, ha2))
+		authLine += fmt.Sprintf(`, response="%s", qop=%s, nc=%s, cnonce="%s"`, response, qop, nc, cnonce)
+	} else {
+		authLine += fmt.Sprintf(`, response="%s"`, utils.MD5(fmt.Sprintf("%s:%s:%s", ha1, params["nonce"], ha2)))
+	}
+	if opaque := params["opaque"]; opaque != "" {
+		authLine += fmt.Sprintf(`, opaque="%s"`, opaque)
+	}
+	return authLine
+}
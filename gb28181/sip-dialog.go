@@ -0,0 +1,202 @@
+package gb28181
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const byeTimeout = 5 * time.Second
+
+// Dialog of INVITE session between server and channel of device, or upstream platform inviting server
+type Dialog struct {
+	server      *Server
+	peer        Peer
+	channelID   string
+	sessionName string
+	callID      string
+	from        string
+	to          string
+	target      string
+	cseq        int
+	lock        sync.Mutex
+	onEnds      []func()
+}
+
+func (dialog *Dialog) String() string {
+	return fmt.Sprintf("dialog[%s][%s][%s]", dialog.peer.ID(), dialog.channelID, dialog.callID)
+}
+
+// Device of dialog, nil if the peer is upstream platform
+func (dialog *Dialog) Device() *Device {
+	device, _ := dialog.peer.(*Device)
+	return device
+}
+
+// ChannelID of dialog
+func (dialog *Dialog) ChannelID() string {
+	return dialog.channelID
+}
+
+// NewRequest in dialog, ACK uses the CSeq of INVITE
+func (dialog *Dialog) NewRequest(method string) *Message {
+	dialog.lock.Lock()
+	if method != ACK {
+		dialog.cseq++
+	}
+	cseq := dialog.cseq
+	dialog.lock.Unlock()
+
+	transport, _ := dialog.peer.Addr()
+	req := NewRequest(method, dialog.target)
+	req.Header.Add("Via", dialog.server.newVia(transport))
+	req.Header.Add("From", dialog.from)
+	req.Header.Add("To", dialog.to)
+	req.Header.Add("Call-ID", dialog.callID)
+	req.Header.Add("CSeq", fmt.Sprintf("%d %s", cseq, method))
+	req.Header.Add("Max-Forwards", "70")
+	req.Header.Add("User-Agent", userAgent)
+	return req
+}
+
+// OnEnd add handle called when peer ends the session, by BYE or end of media
+func (dialog *Dialog) OnEnd(handle func()) {
+	dialog.lock.Lock()
+	dialog.onEnds = append(dialog.onEnds, handle)
+	dialog.lock.Unlock()
+}
+
+func (dialog *Dialog) end() {
+	dialog.lock.Lock()
+	handles := dialog.onEnds
+	dialog.lock.Unlock()
+	for _, handle := range handles {
+		handle()
+	}
+}
+
+// Bye to peer, nothing happens if the dialog has been terminated
+func (dialog *Dialog) Bye() error {
+	if nil == dialog.server.removeDialog(dialog.callID) {
+		return nil
+	}
+	log.WithField("id", dialog.peer.ID()).Infof("%s bye", dialog)
+	res, err := dialog.server.Request(dialog.peer, dialog.NewRequest(BYE), byeTimeout)
+	if nil != err {
+		return err
+	}
+	if res.StatusCode != 200 {
+		return fmt.Errorf("%s bye response %d %s", dialog, res.StatusCode, res.Reason)
+	}
+	return nil
+}
+
+// Invite channel of device with SDP, ACK is sent on success
+func (server *Server) Invite(device *Device, channelID string, subject string, sdp []byte, timeout time.Duration) (*Dialog, *Message, error) {
+	req := server.NewChannelRequest(device, channelID, INVITE)
+	req.Header.Add("Contact", fmt.Sprintf("<sip:%s@%s:%d>", server.ID(), server.Host(), server.Port))
+	req.Header.Add("Subject", subject)
+	req.SetBody(ContentTypeSDP, sdp)
+
+	res, err := server.Request(device, req, timeout)
+	if nil != err {
+		return nil, nil, err
+	}
+	if res.StatusCode != 200 {
+		return nil, res, fmt.Errorf("%s invite %s response %d %s", device, channelID, res.StatusCode, res.Reason)
+	}
+
+	cseq, _ := req.CSeq()
+	dialog := &Dialog{
+		server:    server,
+		peer:      device,
+		channelID: channelID,
+		callID:    req.CallID(),
+		from:      req.Header.Get("From"),
+		to:        res.Header.Get("To"),
+		target:    req.RequestURI,
+		cseq:      cseq,
+	}
+	if contact := getURI(res.Header.Get("Contact")); contact != "" {
+		dialog.target = contact
+	}
+	if err := server.SendRequest(device, dialog.NewRequest(ACK)); nil != err {
+		return nil, res, err
+	}
+	server.addDialog(dialog)
+
+	return dialog, res, nil
+}
+
+// acceptInvite of peer with SDP answer, onEnd is called when the dialog ends
+func (server *Server) acceptInvite(peer Peer, packet *Packet, channelID string, sessionName string, sdp []byte, onEnd func()) *Dialog {
+	res := NewResponse(packet.Message, 200, "OK")
+	res.Header.Set("To", fmt.Sprintf("%s;tag=%s", packet.Header.Get("To"), newTag()))
+	res.Header.Add("Contact", fmt.Sprintf("<sip:%s@%s:%d>", channelID, server.Host(), server.Port))
+	res.SetBody(ContentTypeSDP, sdp)
+
+	cseq, _ := packet.CSeq()
+	dialog := &Dialog{
+		server:      server,
+		peer:        peer,
+		channelID:   channelID,
+		sessionName: sessionName,
+		callID:      packet.CallID(),
+		from:        res.Header.Get("To"),
+		to:          packet.Header.Get("From"),
+		target:      getURI(packet.Header.Get("Contact")),
+		cseq:        cseq,
+	}
+	if dialog.target == "" {
+		dialog.target = fmt.Sprintf("sip:%s@%s", peer.ID(), packet.Addr)
+	}
+	dialog.OnEnd(onEnd)
+	server.addDialog(dialog)
+	server.Reply(packet, res)
+
+	return dialog
+}
+
+func (server *Server) addDialog(dialog *Dialog) {
+	server.dialogsLock.Lock()
+	server.dialogs[dialog.callID] = dialog
+	server.dialogsLock.Unlock()
+}
+
+func (server *Server) removeDialog(callID string) *Dialog {
+	server.dialogsLock.Lock()
+	dialog := server.dialogs[callID]
+	delete(server.dialogs, callID)
+	server.dialogsLock.Unlock()
+
+	return dialog
+}
+
+func (server *Server) handleBye(packet *Packet) {
+	dialog := server.removeDialog(packet.CallID())
+	if nil == dialog {
+		server.Reply(packet, NewResponse(packet.Message, 481, "Call/Transaction Does Not Exist"))
+		return
+	}
+	server.Reply(packet, NewResponse(packet.Message, 200, "OK"))
+	log.WithField("id", dialog.peer.ID()).Infof("%s bye by peer", dialog)
+	dialog.end()
+}
+
+// getDialogs of channel of device, channelID and sessionName are optional
+func (server *Server) getDialogs(device *Device, channelID string, sessionName string) []*Dialog {
+	dialogs := make([]*Dialog, 0)
+	server.dialogsLock.RLock()
+	for _, dialog := range server.dialogs {
+		if dialog.peer != Peer(device) || (channelID != "" && dialog.channelID != channelID) {
+			continue
+		}
+		if sessionName != "" && dialog.sessionName != sessionName {
+			continue
+		}
+		dialogs = append(dialogs, dialog)
+	}
+	server.dialogsLock.RUnlock()
+
+	return dialogs
+}
@@ -0,0 +1,347 @@
+package gb28181
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	// SIPVersion of GB28181
+	SIPVersion = "SIP/2.0"
+	// SIPMaxContentLength of message, larger ones are malformed
+	SIPMaxContentLength = 65535
+)
+
+// SIP methods used by GB28181
+const (
+	REGISTER  = "REGISTER"
+	MESSAGE   = "MESSAGE"
+	INVITE    = "INVITE"
+	ACK       = "ACK"
+	BYE       = "BYE"
+	CANCEL    = "CANCEL"
+	INFO      = "INFO"
+	SUBSCRIBE = "SUBSCRIBE"
+	NOTIFY    = "NOTIFY"
+	OPTIONS   = "OPTIONS"
+)
+
+// compact header names of RFC3261 7.3.3
+var compactHeaders = map[string]string{
+	"i": "Call-ID",
+	"m": "Contact",
+	"e": "Content-Encoding",
+	"l": "Content-Length",
+	"c": "Content-Type",
+	"f": "From",
+	"s": "Subject",
+	"k": "Supported",
+	"t": "To",
+	"v": "Via",
+}
+
+type headerField struct {
+	Name  string
+	Value string
+}
+
+// Header of SIP message, keep the order for multi Via
+type Header []headerField
+
+// Get first value of header name, case insensitive
+func (h Header) Get(name string) string {
+	for _, field := range h {
+		if strings.EqualFold(field.Name, name) {
+			return field.Value
+		}
+	}
+	return ""
+}
+
+// Values of header name, case insensitive
+func (h Header) Values(name string) []string {
+	values := []string{}
+	for _, field := range h {
+		if strings.EqualFold(field.Name, name) {
+			values = append(values, field.Value)
+		}
+	}
+	return values
+}
+
+// Add header field to the tail
+func (h *Header) Add(name, value string) {
+	*h = append(*h, headerField{Name: name, Value: value})
+}
+
+// Set header field, replace the first one if exists
+func (h *Header) Set(name, value string) {
+	for i, field := range *h {
+		if strings.EqualFold(field.Name, name) {
+			(*h)[i].Value = value
+			h.delete(name, i+1)
+			return
+		}
+	}
+	h.Add(name, value)
+}
+
+// Del all the header fields named name
+func (h *Header) Del(name string) {
+	h.delete(name, 0)
+}
+
+func (h *Header) delete(name string, from int) {
+	fields := (*h)[:from]
+	for _, field := range (*h)[from:] {
+		if !strings.EqualFold(field.Name, name) {
+			fields = append(fields, field)
+		}
+	}
+	*h = fields
+}
+
+// Message of SIP, request or response
+type Message struct {
+	// Request line
+	Method     string
+	RequestURI string
+	// Status line
+	StatusCode int
+	Reason     string
+
+	Header Header
+	Body   []byte
+}
+
+// NewRequest of SIP
+func NewRequest(method string, requestURI string) *Message {
+	return &Message{
+		Method:     method,
+		RequestURI: requestURI,
+		Header:     Header{},
+	}
+}
+
+// NewResponse to request, copy the dialog headers of request
+func NewResponse(req *Message, statusCode int, reason string) *Message {
+	res := &Message{
+		StatusCode: statusCode,
+		Reason:     reason,
+		Header:     Header{},
+	}
+	for _, via := range req.Header.Values("Via") {
+		res.Header.Add("Via", via)
+	}
+	res.Header.Add("From", req.Header.Get("From"))
+	to := req.Header.Get("To")
+	if "" == getParam(to, "tag") && statusCode > 100 {
+		to = fmt.Sprintf("%s;tag=%s", to, newTag())
+	}
+	res.Header.Add("To", to)
+	res.Header.Add("Call-ID", req.Header.Get("Call-ID"))
+	res.Header.Add("CSeq", req.Header.Get("CSeq"))
+	res.Header.Add("User-Agent", userAgent)
+	return res
+}
+
+// IsRequest or response
+func (m *Message) IsRequest() bool {
+	return m.Method != ""
+}
+
+// CallID of message
+func (m *Message) CallID() string {
+	return m.Header.Get("Call-ID")
+}
+
+// CSeq number and method of message
+func (m *Message) CSeq() (int, string) {
+	parts := strings.Fields(m.Header.Get("CSeq"))
+	if len(parts) != 2 {
+		return 0, ""
+	}
+	seq, _ := strconv.Atoi(parts[0])
+	return seq, parts[1]
+}
+
+// FromUser returns the user part of From URI, it is the device ID usually
+func (m *Message) FromUser() string {
+	return getURIUser(m.Header.Get("From"))
+}
+
+// ToUser returns the user part of To URI
+func (m *Message) ToUser() string {
+	return getURIUser(m.Header.Get("To"))
+}
+
+// ContentType of body
+func (m *Message) ContentType() string {
+	return strings.ToLower(strings.TrimSpace(m.Header.Get("Content-Type")))
+}
+
+// SetBody with content type
+func (m *Message) SetBody(contentType string, body []byte) {
+	m.Body = body
+	if len(body) > 0 {
+		m.Header.Set("Content-Type", contentType)
+	} else {
+		m.Header.Del("Content-Type")
+	}
+}
+
+func (m *Message) String() string {
+	buf := &bytes.Buffer{}
+	if m.IsRequest() {
+		fmt.Fprintf(buf, "%s %s %s\r\n", m.Method, m.RequestURI, SIPVersion)
+	} else {
+		fmt.Fprintf(buf, "%s %d %s\r\n", SIPVersion, m.StatusCode, m.Reason)
+	}
+	for _, field := range m.Header {
+		if strings.EqualFold(field.Name, "Content-Length") {
+			continue
+		}
+		fmt.Fprintf(buf, "%s: %s\r\n", field.Name, field.Value)
+	}
+	fmt.Fprintf(buf, "Content-Length: %d\r\n\r\n", len(m.Body))
+	buf.Write(m.Body)
+	return buf.String()
+}
+
+// ReadMessage from stream, leading CRLF keepalive will be skipped
+func ReadMessage(reader *bufio.Reader) (*Message, error) {
+	var line string
+	var err error
+	for line == "" {
+		if line, err = readLine(reader); nil != err {
+			return nil, err
+		}
+	}
+
+	m := &Message{Header: Header{}}
+	parts := strings.SplitN(line, " ", 3)
+	if len(parts) != 3 {
+		return nil, ErrorSIPMalformed
+	}
+	if parts[0] == SIPVersion {
+		m.StatusCode, err = strconv.Atoi(parts[1])
+		if nil != err {
+			return nil, ErrorSIPMalformed
+		}
+		m.Reason = parts[2]
+	} else if parts[2] == SIPVersion {
+		m.Method = strings.ToUpper(parts[0])
+		m.RequestURI = parts[1]
+	} else {
+		return nil, ErrorSIPMalformed
+	}
+
+	for {
+		if line, err = readLine(reader); nil != err {
+			return nil, err
+		}
+		if line == "" {
+			break
+		}
+		// header folding
+		if (line[0] == ' ' || line[0] == '\t') && len(m.Header) > 0 {
+			m.Header[len(m.Header)-1].Value += " " + strings.TrimSpace(line)
+			continue
+		}
+		kv := strings.SplitN(line, ":", 2)
+		if len(kv) != 2 {
+			return nil, ErrorSIPMalformed
+		}
+		name := strings.TrimSpace(kv[0])
+		if full, ok := compactHeaders[strings.ToLower(name)]; ok {
+			name = full
+		}
+		m.Header.Add(name, strings.TrimSpace(kv[1]))
+	}
+
+	if contentLength := m.Header.Get("Content-Length"); contentLength != "" {
+		length, err := strconv.Atoi(contentLength)
+		if nil != err || length < 0 || length > SIPMaxContentLength {
+			return nil, ErrorSIPMalformed
+		}
+		m.Body = make([]byte, length)
+		if _, err = io.ReadFull(reader, m.Body); nil != err {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+// ParseMessage from a datagram
+func ParseMessage(data []byte) (*Message, error) {
+	m, err := ReadMessage(bufio.NewReader(bytes.NewReader(data)))
+	if nil != err {
+		if io.EOF == err || io.ErrUnexpectedEOF == err {
+			return nil, ErrorSIPNeedMore
+		}
+		return nil, err
+	}
+	return m, nil
+}
+
+func readLine(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadString('\n')
+	if nil != err {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// getParam of header value like `<sip:xxx@yyy>;tag=zzz`
+func getParam(value string, name string) string {
+	if idx := strings.LastIndex(value, ">"); idx >= 0 {
+		value = value[idx+1:]
+	}
+	for _, param := range strings.Split(value, ";") {
+		kv := strings.SplitN(strings.TrimSpace(param), "=", 2)
+		if strings.EqualFold(kv[0], name) {
+			if len(kv) == 2 {
+				return strings.Trim(kv[1], `"`)
+			}
+			return ""
+		}
+	}
+	return ""
+}
+
+// getURIUser of header value like `<sip:xxx@yyy>;tag=zzz` or `sip:xxx@yyy`
+func getURIUser(value string) string {
+	if idx := strings.Index(value, "<"); idx >= 0 {
+		value = value[idx+1:]
+		if end := strings.Index(value, ">"); end >= 0 {
+			value = value[:end]
+		}
+	}
+	value = strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(value), "sips:"), "sip:")
+	if idx := strings.Index(value, "@"); idx >= 0 {
+		return value[:idx]
+	}
+	if idx := strings.IndexAny(value, ":;"); idx >= 0 {
+		return value[:idx]
+	}
+	return value
+}
+
+// getURI of header value like `<sip:xxx@yyy>;tag=zzz` or `sip:xxx@yyy;transport=udp`
+func getURI(value string) string {
+	if idx := strings.Index(value, "<"); idx >= 0 {
+		value = value[idx+1:]
+		if end := strings.Index(value, ">"); end >= 0 {
+			return value[:end]
+		}
+	}
+	if idx := strings.Index(value, ";"); idx >= 0 {
+		value = value[:idx]
+	}
+	return strings.TrimSpace(value)
+}
@@ -0,0 +1,143 @@
+package gb28181
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMessage(t *testing.T) {
+	register := "REGISTER sip:34020000002000000001@3402000000 SIP/2.0\r\n" +
+		"Via: SIP/2.0/UDP 192.168.1.64:5060;rport;branch=z9hG4bK1\r\n" +
+		"f: <sip:34020000001320000001@3402000000>;tag=1\r\n" +
+		"To: <sip:34020000001320000001@3402000000>\r\n" +
+		"Call-ID: 1@192.168.1.64\r\n" +
+		"CSeq: 1 REGISTER\r\n" +
+		"Expires: 3600\r\n" +
+		"Content-Length: 0\r\n\r\n"
+	message := "MESSAGE sip:34020000002000000001@3402000000 SIP/2.0\r\n" +
+		"From: <sip:34020000001320000001@3402000000>;tag=2\r\n" +
+		"Subject: a,\r\n b\r\n" +
+		"Content-Type: Application/MANSCDP+xml\r\n" +
+		"Content-Length: 5\r\n\r\n" +
+		"<xml>"
+	response := "SIP/2.0 401 Unauthorized\r\n" +
+		"CSeq: 1 REGISTER\r\n" +
+		"l: 0\r\n\r\n"
+
+	tests := []struct {
+		name        string
+		data        string
+		err         error
+		method      string
+		statusCode  int
+		fromUser    string
+		body        string
+		contentType string
+		subject     string
+	}{
+		{name: "request", data: register, method: REGISTER, fromUser: "34020000001320000001"},
+		{name: "keepalive CRLF skipped", data: "\r\n\r\n" + register, method: REGISTER, fromUser: "34020000001320000001"},
+		{name: "body and folded header", data: message, method: MESSAGE, fromUser: "34020000001320000001",
+			body: "<xml>", contentType: "application/manscdp+xml", subject: "a, b"},
+		{name: "response", data: response, statusCode: 401},
+		{name: "body not complete", data: message[:len(message)-1], err: ErrorSIPNeedMore},
+		{name: "header not complete", data: register[:40], err: ErrorSIPNeedMore},
+		{name: "negative Content-Length", data: strings.Replace(register, "Content-Length: 0", "Content-Length: -1", 1), err: ErrorSIPMalformed},
+		{name: "huge Content-Length", data: strings.Replace(register, "Content-Length: 0", "Content-Length: 9223372036854775807", 1), err: ErrorSIPMalformed},
+		{name: "Content-Length over max", data: strings.Replace(register, "Content-Length: 0", "Content-Length: 65536", 1), err: ErrorSIPMalformed},
+		{name: "Content-Length not number", data: strings.Replace(register, "Content-Length: 0", "Content-Length: x", 1), err: ErrorSIPMalformed},
+		{name: "bad status line", data: "HELLO\r\n\r\n", err: ErrorSIPMalformed},
+		{name: "bad header", data: strings.Replace(register, "Expires: 3600", "Expires 3600", 1), err: ErrorSIPMalformed},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m, err := ParseMessage([]byte(test.data))
+			if test.err != nil {
+				assert.Equal(t, test.err, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, test.method, m.Method)
+			assert.Equal(t, test.statusCode, m.StatusCode)
+			assert.Equal(t, test.fromUser, m.FromUser())
+			assert.Equal(t, test.body, string(m.Body))
+			assert.Equal(t, test.contentType, m.ContentType())
+			assert.Equal(t, test.subject, m.Header.Get("Subject"))
+		})
+	}
+}
+
+func TestMessageString(t *testing.T) {
+	req := NewRequest(MESSAGE, "sip:34020000001320000001@192.168.1.64:5060")
+	req.Header.Add("From", "<sip:34020000002000000001@3402000000>;tag=1")
+	req.Header.Add("Content-Length", "100")
+	req.SetBody("Application/MANSCDP+xml", []byte("<xml>"))
+
+	m, err := ParseMessage([]byte(req.String()))
+	assert.Nil(t, err)
+	assert.Equal(t, req.RequestURI, m.RequestURI)
+	assert.Equal(t, "5", m.Header.Get("Content-Length"))
+	assert.Equal(t, []byte("<xml>"), m.Body)
+}
+
+func TestGetURIUser(t *testing.T) {
+	tests := []struct {
+		value string
+		user  string
+	}{
+		{value: "<sip:34020000001320000001@3402000000>;tag=1", user: "34020000001320000001"},
+		{value: `"camera" <sips:34020000001320000001@192.168.1.64:5060>`, user: "34020000001320000001"},
+		{value: "sip:34020000001320000001@3402000000", user: "34020000001320000001"},
+		{value: "sip:192.168.1.64:5060;transport=udp", user: "192.168.1.64"},
+		{value: "", user: ""},
+	}
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			assert.Equal(t, test.user, getURIUser(test.value))
+		})
+	}
+}
+
+func TestCheckDigestAuth(t *testing.T) {
+	// RFC2617 3.5 example
+	rfc := `Digest username="Mufasa", realm="testrealm@host.com", nonce="dcd98b7102dd2f0e8b11d0f600bfb0c093", ` +
+		`uri="/dir/index.html", qop=auth, nc=00000001, cnonce="0a4f113b", ` +
+		`response="6629fae49393a05397450978507c4ef1", opaque="5ccc069c403ebaf9f0171e9517f40e41"`
+	nonce := "dcd98b7102dd2f0e8b11d0f600bfb0c093"
+
+	tests := []struct {
+		name     string
+		authLine string
+		method   string
+		nonce    string
+		realm    string
+		username string
+		password string
+		ok       bool
+	}{
+		{name: "qop auth", authLine: rfc, method: "GET", nonce: nonce, realm: "testrealm@host.com", username: "Mufasa", password: "Circle Of Life", ok: true},
+		{name: "wrong password", authLine: rfc, method: "GET", nonce: nonce, realm: "testrealm@host.com", username: "Mufasa", password: "12345678"},
+		{name: "wrong nonce", authLine: rfc, method: "GET", nonce: "0000", realm: "testrealm@host.com", username: "Mufasa", password: "Circle Of Life"},
+		{name: "realm of client", authLine: rfc, method: "GET", nonce: nonce, realm: "3402000000", username: "Mufasa", password: "Circle Of Life"},
+		{name: "username of client", authLine: rfc, method: "GET", nonce: nonce, realm: "testrealm@host.com", username: "34020000001320000001", password: "Circle Of Life"},
+		{name: "basic", authLine: "Basic TXVmYXNhOkNpcmNsZSBPZiBMaWZl", method: "GET", nonce: nonce, realm: "testrealm@host.com", username: "Mufasa", password: "Circle Of Life"},
+		{
+			name:     "answer of digestAuthorization without qop",
+			authLine: digestAuthorization(`Digest realm="3402000000", nonce="abc"`, REGISTER, "sip:34020000002000000001@3402000000", "34020000001320000001", "12345678"),
+			method:   REGISTER, nonce: "abc", realm: "3402000000", username: "34020000001320000001", password: "12345678", ok: true,
+		},
+		{
+			name:     "answer of digestAuthorization with qop",
+			authLine: digestAuthorization(`Digest realm="3402000000", nonce="abc", qop="auth,auth-int"`, REGISTER, "sip:34020000002000000001@3402000000", "34020000001320000001", "12345678"),
+			method:   REGISTER, nonce: "abc", realm: "3402000000", username: "34020000001320000001", password: "12345678", ok: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := checkDigestAuth(test.authLine, test.method, test.nonce, test.realm, test.username, test.password)
+			assert.Equal(t, test.ok, err == nil, "%v", err)
+		})
+	}
+}
@@ -0,0 +1,554 @@
+package gb28181
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/EasyDarwin/EasyDarwin/models"
+	"github.com/EasyDarwin/EasyDarwin/utils"
+)
+
+const userAgent = "EasyDarwinGo"
+
+// Transport of SIP
+const (
+	TransportUDP = "UDP"
+	TransportTCP = "TCP"
+)
+
+// Packet is a SIP message with the address it comes from
+type Packet struct {
+	*Message
+	Transport string
+	Addr      string
+}
+
+// Server of GB28181 SIP
+type Server struct {
+	UDPConn     *net.UDPConn
+	TCPListener *net.TCPListener
+	Port        int
+	// Stoped of Start and Stop, goroutines of connections check stop channel by stoped()
+	Stoped      bool
+	stopChannel chan int
+	// TCP connections from devices, key is remote address
+	tcpConns     map[string]*tcpConn
+	tcpConnsLock sync.RWMutex
+	// Registry
+	devices     map[string]*Device
+	devicesLock sync.RWMutex
+	// nonces of REGISTER challenged, key is device ID, pruned after nonceTimeout
+	nonces     map[string]*registerNonce
+	noncesLock sync.Mutex
+	// Requests to devices
+	cseq             uint32
+	sn               uint32
+	transactions     map[string]*transaction
+	transactionsLock sync.RWMutex
+	queries          map[string]*query
+	queriesLock      sync.RWMutex
+	// INVITE sessions, key is Call-ID
+	dialogs     map[string]*Dialog
+	dialogsLock sync.RWMutex
+	// channels being invited, key is path
+	playing     map[string]chan int
+	playingLock sync.Mutex
+	// SSRCs of streams being received
+	ssrcs    map[string]bool
+	ssrcLock sync.Mutex
+	ssrcSeq  uint32
+	// superior platforms, key is ID
+	upstreams     map[string]*Upstream
+	upstreamsLock sync.RWMutex
+	// handles of alarm from devices
+	onAlarmHandles []OnAlarmHandle
+	// broadcasts waiting for or being INVITEd by devices, key is source ID
+	broadcasts     map[string]*Broadcast
+	broadcastsLock sync.RWMutex
+	broadcastSeq   uint32
+}
+
+type tcpConn struct {
+	net.Conn
+	writeLock sync.Mutex
+}
+
+// Instance of GB28181 server
+var Instance *Server
+
+func initServer() error {
+	Instance = &Server{
+		Port:     config.SIP.Port,
+		Stoped:   true,
+		tcpConns: make(map[string]*tcpConn),
+		devices:  make(map[string]*Device),
+		nonces:   make(map[string]*registerNonce),
+
+		transactions: make(map[string]*transaction),
+		queries:      make(map[string]*query),
+		dialogs:      make(map[string]*Dialog),
+		playing:      make(map[string]chan int),
+		ssrcs:        make(map[string]bool),
+		upstreams:    make(map[string]*Upstream),
+		broadcasts:   make(map[string]*Broadcast),
+	}
+
+	return nil
+}
+
+// GetServer of GB28181
+func GetServer() *Server {
+	return Instance
+}
+
+// Host of this server announced to devices
+func (server *Server) Host() string {
+	if config.SIP.Host != "" {
+		return config.SIP.Host
+	}
+	return utils.LocalIP()
+}
+
+// ID of this server
+func (server *Server) ID() string {
+	return config.SIP.ID
+}
+
+// Start SIP server on UDP and TCP with the same port
+func (server *Server) Start() (err error) {
+	udpAddr, err := net.ResolveUDPAddr("udp", fmt.Sprintf(":%d", server.Port))
+	if err != nil {
+		return
+	}
+	tcpAddr, err := net.ResolveTCPAddr("tcp", fmt.Sprintf(":%d", server.Port))
+	if err != nil {
+		return
+	}
+	if err = server.loadDevices(); err != nil {
+		return
+	}
+	server.UDPConn, err = net.ListenUDP("udp", udpAddr)
+	if err != nil {
+		return
+	}
+	server.TCPListener, err = net.ListenTCP("tcp", tcpAddr)
+	if err != nil {
+		server.UDPConn.Close()
+		return
+	}
+
+	server.Stoped = false
+	server.stopChannel = make(chan int)
+	log.Infof("SIP server start on[%d]", server.Port)
+
+	go server.checkDevicesLoop()
+	go server.acceptTCP()
+	server.startUpstreams()
+	server.readUDP()
+	return
+}
+
+// Stop SIP server
+func (server *Server) Stop() {
+	if server.Stoped {
+		return
+	}
+	log.Infof("SIP server stop on %d", server.Port)
+	// unregister before connections closed
+	server.stopUpstreams()
+	server.Stoped = true
+	close(server.stopChannel)
+	if server.UDPConn != nil {
+		server.UDPConn.Close()
+		server.UDPConn = nil
+	}
+	if server.TCPListener != nil {
+		server.TCPListener.Close()
+		server.TCPListener = nil
+	}
+	server.tcpConnsLock.Lock()
+	for _, conn := range server.tcpConns {
+		conn.Close()
+	}
+	server.tcpConns = make(map[string]*tcpConn)
+	server.tcpConnsLock.Unlock()
+}
+
+// stoped if stop channel is closed, safe in goroutines of connections
+func (server *Server) stoped() bool {
+	select {
+	case <-server.stopChannel:
+		return true
+	default:
+		return false
+	}
+}
+
+// retryDelay of errors, doubled from 5ms to 1s like net/http
+func retryDelay(delay time.Duration) time.Duration {
+	if delay == 0 {
+		return 5 * time.Millisecond
+	}
+	if delay *= 2; delay > time.Second {
+		return time.Second
+	}
+	return delay
+}
+
+// isClosedError of connection or listener closed
+func isClosedError(err error) bool {
+	return strings.Contains(err.Error(), "use of closed network connection")
+}
+
+func (server *Server) readUDP() {
+	conn := server.UDPConn
+	buf := make([]byte, 65536)
+	var tempDelay time.Duration
+	for !server.stoped() {
+		n, addr, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			if server.stoped() || isClosedError(err) {
+				return
+			}
+			// ICMP unreachable of peers and so on, never a hot loop
+			tempDelay = retryDelay(tempDelay)
+			log.Errorf("SIP server read udp error:%v, retrying in %v", err, tempDelay)
+			time.Sleep(tempDelay)
+			continue
+		}
+		tempDelay = 0
+		if n <= 4 {
+			// CRLF keepalive
+			continue
+		}
+		msg, err := ParseMessage(buf[:n])
+		if err != nil {
+			log.WithError(err).WithField("addr", addr.String()).Warn("parse SIP message")
+			continue
+		}
+		server.handle(&Packet{
+			Message:   msg,
+			Transport: TransportUDP,
+			Addr:      addr.String(),
+		})
+	}
+}
+
+func (server *Server) acceptTCP() {
+	listener := server.TCPListener
+	var tempDelay time.Duration
+	for !server.stoped() {
+		conn, err := listener.Accept()
+		if err != nil {
+			if server.stoped() {
+				return
+			}
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				// back off like net/http, e.g. too many open files
+				tempDelay = retryDelay(tempDelay)
+				log.Errorf("SIP server accept tcp error:%v, retrying in %v", err, tempDelay)
+				time.Sleep(tempDelay)
+				continue
+			}
+			log.Errorf("SIP server accept tcp error:%v, stop accepting", err)
+			return
+		}
+		tempDelay = 0
+		go server.readTCP(&tcpConn{Conn: conn})
+	}
+}
+
+func (server *Server) readTCP(conn *tcpConn) {
+	addr := conn.RemoteAddr().String()
+	server.tcpConnsLock.Lock()
+	server.tcpConns[addr] = conn
+	server.tcpConnsLock.Unlock()
+	defer func() {
+		server.tcpConnsLock.Lock()
+		if server.tcpConns[addr] == conn {
+			delete(server.tcpConns, addr)
+		}
+		server.tcpConnsLock.Unlock()
+		conn.Close()
+	}()
+
+	reader := bufio.NewReader(conn)
+	for !server.stoped() {
+		msg, err := ReadMessage(reader)
+		if err != nil {
+			if !server.stoped() {
+				log.WithError(err).WithField("addr", addr).Info("SIP tcp connection closed")
+			}
+			return
+		}
+		server.handle(&Packet{
+			Message:   msg,
+			Transport: TransportTCP,
+			Addr:      addr,
+		})
+	}
+}
+
+// Send SIP message to address
+func (server *Server) Send(transport string, addr string, msg *Message) error {
+	data := []byte(msg.String())
+	log.Debugf(">>>[%s %s]\n%s", transport, addr, data)
+
+	if strings.EqualFold(transport, TransportTCP) {
+		server.tcpConnsLock.RLock()
+		conn, ok := server.tcpConns[addr]
+		server.tcpConnsLock.RUnlock()
+		if !ok {
+			return ErrorTransportNotFound
+		}
+		conn.writeLock.Lock()
+		defer conn.writeLock.Unlock()
+		_, err := conn.Write(data)
+		return err
+	}
+
+	conn := server.UDPConn
+	if conn == nil {
+		return ErrorTransportNotFound
+	}
+	udpAddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return err
+	}
+	_, err = conn.WriteToUDP(data, udpAddr)
+	return err
+}
+
+// Reply response to request packet
+func (server *Server) Reply(packet *Packet, res *Message) {
+	if err := server.Send(packet.Transport, packet.Addr, res); err != nil {
+		log.WithError(err).WithField("addr", packet.Addr).Error("SIP reply")
+	}
+}
+
+func (server *Server) handle(packet *Packet) {
+	defer func() {
+		if p := recover(); p != nil {
+			log.Errorf("[%s] SIP handle err ocurs:%v", packet.Addr, p)
+			debug.PrintStack()
+		}
+	}()
+	log.Debugf("<<<[%s %s]\n%s", packet.Transport, packet.Addr, packet.Message)
+
+	if !packet.IsRequest() {
+		server.handleResponse(packet)
+		return
+	}
+
+	if upstream := server.getUpstream(packet.FromUser()); nil != upstream && upstream.from(packet) {
+		server.handleUpstream(upstream, packet)
+		return
+	}
+
+	switch packet.Method {
+	case REGISTER:
+		server.handleRegister(packet)
+	case MESSAGE, NOTIFY:
+		server.handleMessage(packet)
+	case INVITE:
+		server.handleInvite(packet)
+	case BYE:
+		server.handleBye(packet)
+	case ACK:
+		// no response for ACK
+	default:
+		server.Reply(packet, NewResponse(packet.Message, 405, "Method Not Allowed"))
+	}
+}
+
+// registerNonce of 401 to REGISTER
+type registerNonce struct {
+	value string
+	at    time.Time
+}
+
+// nonceTimeout of REGISTER challenged, device gets a new one after it
+const nonceTimeout = time.Minute
+
+// pruneNonces of devices never answering the challenge
+func (server *Server) pruneNonces(now time.Time) {
+	server.noncesLock.Lock()
+	for deviceID, nonce := range server.nonces {
+		if now.Sub(nonce.at) > nonceTimeout {
+			delete(server.nonces, deviceID)
+		}
+	}
+	server.noncesLock.Unlock()
+}
+
+func (server *Server) handleRegister(packet *Packet) {
+	deviceID := packet.FromUser()
+	if deviceID == "" {
+		server.Reply(packet, NewResponse(packet.Message, 400, "Bad Request"))
+		return
+	}
+
+	if config.SIP.Password != "" {
+		authLine := packet.Header.Get("Authorization")
+		nonce := ""
+		server.noncesLock.Lock()
+		if challenge, ok := server.nonces[deviceID]; ok {
+			nonce = challenge.value
+		}
+		server.noncesLock.Unlock()
+		if authLine != "" && nonce != "" {
+			if err := checkDigestAuth(authLine, REGISTER, nonce, config.SIP.Domain, deviceID, config.SIP.Password); err != nil {
+				log.WithError(err).WithField("id", deviceID).Warn("device register auth")
+				authLine = ""
+			}
+		} else {
+			authLine = ""
+		}
+		if authLine == "" {
+			nonce = newNonce()
+			server.noncesLock.Lock()
+			server.nonces[deviceID] = &registerNonce{value: nonce, at: time.Now()}
+			server.noncesLock.Unlock()
+			res := NewResponse(packet.Message, 401, "Unauthorized")
+			res.Header.Add("WWW-Authenticate",
+				fmt.Sprintf(`Digest realm="%s",nonce="%s",algorithm=MD5`, config.SIP.Domain, nonce))
+			server.Reply(packet, res)
+			return
+		}
+		server.noncesLock.Lock()
+		delete(server.nonces, deviceID)
+		server.noncesLock.Unlock()
+	}
+
+	expires := config.SIP.RegisterExpires
+	value := packet.Header.Get("Expires")
+	if value == "" {
+		value = getParam(packet.Header.Get("Contact"), "expires")
+	}
+	if value != "" {
+		// malformed one is not an unregister
+		var err error
+		if expires, err = strconv.Atoi(strings.TrimSpace(value)); err != nil || expires < 0 {
+			log.WithField("id", deviceID).Warnf("device register, malformed expires[%s]", value)
+			server.Reply(packet, NewResponse(packet.Message, 400, "Bad Request"))
+			return
+		}
+	}
+	if expires > config.SIP.RegisterExpires {
+		expires = config.SIP.RegisterExpires
+	}
+
+	host, port, _ := net.SplitHostPort(packet.Addr)
+	portNum, _ := strconv.Atoi(port)
+	now := time.Now().Unix()
+	device := server.getOrAddDevice(deviceID)
+	wasOnline := device.Online()
+	err := device.update(func(info *models.Device) {
+		info.Transport = packet.Transport
+		info.Host = host
+		info.Port = int32(portNum)
+		info.Expires = int64(expires)
+		if expires > 0 {
+			info.Online = true
+			info.RegisterAt = now
+			info.KeepaliveAt = now
+		} else {
+			info.Online = false
+		}
+	})
+	if err != nil {
+		log.WithError(err).WithField("id", deviceID).Error("update device")
+		server.Reply(packet, NewResponse(packet.Message, 500, "Server Internal Error"))
+		return
+	}
+	log.WithField("id", deviceID).Infof("device register, expires[%d]", expires)
+
+	res := NewResponse(packet.Message, 200, "OK")
+	if contact := packet.Header.Get("Contact"); contact != "" {
+		res.Header.Add("Contact", contact)
+	}
+	res.Header.Add("Expires", strconv.Itoa(expires))
+	res.Header.Add("Date", time.Now().Format("2006-01-02T15:04:05.000"))
+	server.Reply(packet, res)
+
+	if !wasOnline {
+		device.clearSubscriptions()
+	}
+	if expires > 0 && !wasOnline {
+		go server.refreshDeviceInfo(device)
+		go server.refreshCatalog(device)
+	}
+}
+
+func (server *Server) handleMessage(packet *Packet) {
+	header := &manscdpHeader{}
+	if err := decodeMANSCDP(packet.Body, header); err != nil {
+		log.WithError(err).WithField("addr", packet.Addr).Warn("decode MANSCDP")
+		server.Reply(packet, NewResponse(packet.Message, 400, "Bad Request"))
+		return
+	}
+
+	device := server.GetDevice(packet.FromUser())
+	if device == nil || !device.Online() {
+		// let device register again
+		server.Reply(packet, NewResponse(packet.Message, 403, "Forbidden"))
+		return
+	}
+
+	if header.XMLName.Local == "Response" {
+		server.handleQueryResponse(device, header, packet.Body)
+		server.Reply(packet, NewResponse(packet.Message, 200, "OK"))
+		return
+	}
+
+	switch header.XMLName.Local + ":" + header.CmdType {
+	case "Notify:Keepalive":
+		server.handleKeepalive(device, packet)
+	case "Notify:MediaStatus":
+		server.handleMediaStatus(device, packet.Body)
+	case "Notify:Alarm":
+		server.handleAlarm(device, packet.Body)
+	case "Notify:MobilePosition":
+		server.handleMobilePosition(device, packet.Body)
+	default:
+		log.WithField("id", device.ID()).Infof("unhandled MANSCDP %s:%s", header.XMLName.Local, header.CmdType)
+	}
+	server.Reply(packet, NewResponse(packet.Message, 200, "OK"))
+}
+
+func (server *Server) handleKeepalive(device *Device, packet *Packet) {
+	host, port, _ := net.SplitHostPort(packet.Addr)
+	portNum, _ := strconv.Atoi(port)
+	err := device.update(func(info *models.Device) {
+		info.KeepaliveAt = time.Now().Unix()
+		// NAT may change the address
+		info.Transport = packet.Transport
+		info.Host = host
+		info.Port = int32(portNum)
+	})
+	if err != nil {
+		log.WithError(err).WithField("id", device.ID()).Error("update device")
+	}
+}
+
+func randomHex(n int) string {
+	buf := make([]byte, n)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+func newNonce() string {
+	return randomHex(16)
+}
+
+func newTag() string {
+	return randomHex(4)
+}
@@ -0,0 +1,140 @@
+package gb28181
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Peer of SIP requests, device or upstream platform
+type Peer interface {
+	ID() string
+	Addr() (transport string, addr string)
+}
+
+// transaction of request sent by server, waiting for final response
+type transaction struct {
+	key       string
+	responses chan *Message
+}
+
+func transactionKey(msg *Message) string {
+	seq, method := msg.CSeq()
+	return fmt.Sprintf("%s %d %s", msg.CallID(), seq, method)
+}
+
+func (server *Server) nextCSeq() int {
+	return int(atomic.AddUint32(&server.cseq, 1))
+}
+
+func (server *Server) nextSN() int {
+	return int(atomic.AddUint32(&server.sn, 1))
+}
+
+// NewDeviceRequest of method from server to device, out of dialog
+func (server *Server) NewDeviceRequest(device *Device, method string) *Message {
+	return server.NewChannelRequest(device, device.ID(), method)
+}
+
+// NewChannelRequest of method from server to channel of device, out of dialog
+func (server *Server) NewChannelRequest(device *Device, channelID string, method string) *Message {
+	transport, addr := device.Addr()
+	req := NewRequest(method, fmt.Sprintf("sip:%s@%s", channelID, addr))
+	req.Header.Add("Via", server.newVia(transport))
+	req.Header.Add("From", fmt.Sprintf("<sip:%s@%s>;tag=%s", server.ID(), config.SIP.Domain, newTag()))
+	req.Header.Add("To", fmt.Sprintf("<sip:%s@%s>", channelID, deviceDomain(channelID)))
+	req.Header.Add("Call-ID", randomHex(16))
+	req.Header.Add("CSeq", fmt.Sprintf("%d %s", server.nextCSeq(), method))
+	req.Header.Add("Max-Forwards", "70")
+	req.Header.Add("User-Agent", userAgent)
+	return req
+}
+
+func (server *Server) newVia(transport string) string {
+	return fmt.Sprintf("SIP/2.0/%s %s:%d;rport;branch=z9hG4bK%s",
+		strings.ToUpper(transport), server.Host(), server.Port, randomHex(8))
+}
+
+// SendRequest to peer without waiting response
+func (server *Server) SendRequest(peer Peer, req *Message) error {
+	transport, addr := peer.Addr()
+	return server.Send(transport, addr, req)
+}
+
+// Request to peer and wait for the final response
+func (server *Server) Request(peer Peer, req *Message, timeout time.Duration) (*Message, error) {
+	transport, addr := peer.Addr()
+	t := &transaction{
+		key:       transactionKey(req),
+		responses: make(chan *Message, 4),
+	}
+	server.transactionsLock.Lock()
+	server.transactions[t.key] = t
+	server.transactionsLock.Unlock()
+	defer func() {
+		server.transactionsLock.Lock()
+		delete(server.transactions, t.key)
+		server.transactionsLock.Unlock()
+	}()
+
+	if err := server.Send(transport, addr, req); err != nil {
+		return nil, err
+	}
+
+	// retransmit on UDP, RFC3261 17.1.2.2 timer E
+	interval := 500 * time.Millisecond
+	retransmit := time.NewTimer(interval)
+	defer retransmit.Stop()
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	for {
+		select {
+		case res := <-t.responses:
+			if res.StatusCode < 200 {
+				// provisional response, no more retransmit
+				retransmit.Stop()
+				continue
+			}
+			return res, nil
+		case <-retransmit.C:
+			if transport != TransportUDP {
+				continue
+			}
+			if err := server.Send(transport, addr, req); err != nil {
+				return nil, err
+			}
+			if interval < 4*time.Second {
+				interval *= 2
+			}
+			retransmit.Reset(interval)
+		case <-deadline.C:
+			return nil, ErrorTimeout
+		}
+	}
+}
+
+func (server *Server) handleResponse(packet *Packet) {
+	key := transactionKey(packet.Message)
+	server.transactionsLock.RLock()
+	t, ok := server.transactions[key]
+	server.transactionsLock.RUnlock()
+	if !ok {
+		log.WithField("callID", packet.CallID()).Debug("SIP response without transaction")
+		return
+	}
+
+	select {
+	case t.responses <- packet.Message:
+	default:
+		log.WithField("callID", packet.CallID()).Warn("SIP transaction response dropped")
+	}
+}
+
+// deviceDomain is the first 10 digits of GB28181 ID
+func deviceDomain(ID string) string {
+	if len(ID) >= 10 {
+		return ID[:10]
+	}
+	return config.SIP.Domain
+}
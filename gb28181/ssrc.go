@@ -0,0 +1,54 @@
+package gb28181
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// ssrcPrefix of GB28181 SSRC, the first digit is 0 for realtime or 1 for playback,
+// then 5 digits of domain
+func ssrcPrefix(playback bool) string {
+	prefix := "0"
+	if playback {
+		prefix = "1"
+	}
+	domain := config.SIP.Domain
+	if len(domain) >= 8 {
+		domain = domain[3:8]
+	} else {
+		domain = "00000" + domain
+		domain = domain[len(domain)-5:]
+	}
+	return prefix + domain
+}
+
+// allocSSRC of GB28181 in 10 digits, the prefix and 4 digits sequence at last,
+// it is not used by other streams until freed
+func (server *Server) allocSSRC(playback bool) (string, error) {
+	prefix := ssrcPrefix(playback)
+
+	server.ssrcLock.Lock()
+	defer server.ssrcLock.Unlock()
+	for i := 0; i < 10000; i++ {
+		server.ssrcSeq = (server.ssrcSeq + 1) % 10000
+		ssrc := fmt.Sprintf("%s%04d", prefix, server.ssrcSeq)
+		if !server.ssrcs[ssrc] {
+			server.ssrcs[ssrc] = true
+			return ssrc, nil
+		}
+	}
+	return "", fmt.Errorf("SSRC of prefix %s exhausted", prefix)
+}
+
+// freeSSRC to be allocated again
+func (server *Server) freeSSRC(ssrc string) {
+	server.ssrcLock.Lock()
+	delete(server.ssrcs, ssrc)
+	server.ssrcLock.Unlock()
+}
+
+// parseSSRC of decimal digits
+func parseSSRC(ssrc string) (uint32, error) {
+	value, err := strconv.ParseUint(ssrc, 10, 32)
+	return uint32(value), err
+}
@@ -0,0 +1,74 @@
+package gb28181
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSSRCPrefix(t *testing.T) {
+	domain := config.SIP.Domain
+	defer func() {
+		config.SIP.Domain = domain
+	}()
+
+	tests := []struct {
+		domain   string
+		playback bool
+		prefix   string
+	}{
+		{domain: "3402000000", playback: false, prefix: "020000"},
+		{domain: "3402000000", playback: true, prefix: "120000"},
+		{domain: "34020000", playback: false, prefix: "020000"},
+		{domain: "123", playback: false, prefix: "000123"},
+		{domain: "", playback: true, prefix: "100000"},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%s/%v", test.domain, test.playback), func(t *testing.T) {
+			config.SIP.Domain = test.domain
+			assert.Equal(t, test.prefix, ssrcPrefix(test.playback))
+		})
+	}
+}
+
+func TestAllocSSRC(t *testing.T) {
+	domain := config.SIP.Domain
+	defer func() {
+		config.SIP.Domain = domain
+	}()
+	config.SIP.Domain = "3402000000"
+
+	server := &Server{ssrcs: make(map[string]bool)}
+	ssrc, err := server.allocSSRC(false)
+	assert.Nil(t, err)
+	assert.Equal(t, "0200000001", ssrc)
+	ssrc, err = server.allocSSRC(true)
+	assert.Nil(t, err)
+	assert.Equal(t, "1200000002", ssrc)
+
+	// sequence wraps and skips SSRC in use
+	server.ssrcSeq = 9998
+	server.ssrcs["0200000000"] = true
+	ssrc, _ = server.allocSSRC(false)
+	assert.Equal(t, "0200009999", ssrc)
+	ssrc, _ = server.allocSSRC(false)
+	assert.Equal(t, "0200000002", ssrc)
+
+	// exhausted until freed
+	for i := 0; i < 10000; i++ {
+		server.ssrcs[fmt.Sprintf("020000%04d", i)] = true
+	}
+	_, err = server.allocSSRC(false)
+	assert.NotNil(t, err)
+	server.freeSSRC("0200001234")
+	ssrc, err = server.allocSSRC(false)
+	assert.Nil(t, err)
+	assert.Equal(t, "0200001234", ssrc)
+
+	value, err := parseSSRC(ssrc)
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(200001234), value)
+	_, err = parseSSRC("9999999999")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,67 @@
+package gb28181
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// EventPresence of SUBSCRIBE for alarm and mobile position
+const EventPresence = "presence"
+
+// subscribeRetryInterval after subscribing failed, devices may not support it
+const subscribeRetryInterval = 5 * time.Minute
+
+// Subscribe events of device by SUBSCRIBE with MANSCDP query, expires 0 to unsubscribe.
+// Events come back as NOTIFY or MESSAGE of the same CmdType
+func (server *Server) Subscribe(device *Device, query interface{}, expires int) error {
+	if !device.Online() {
+		return ErrorDeviceOffline
+	}
+	body, err := encodeMANSCDP(query)
+	if err != nil {
+		return err
+	}
+	req := server.NewDeviceRequest(device, SUBSCRIBE)
+	req.Header.Add("Contact", fmt.Sprintf("<sip:%s@%s:%d>", server.ID(), server.Host(), server.Port))
+	req.Header.Add("Event", EventPresence)
+	req.Header.Add("Expires", strconv.Itoa(expires))
+	req.SetBody(ContentTypeMANSCDP, body)
+
+	res, err := server.Request(device, req, queryTimeout())
+	if err != nil {
+		return err
+	}
+	if res.StatusCode != 200 {
+		return fmt.Errorf("%s subscribe response %d %s", device, res.StatusCode, res.Reason)
+	}
+	return nil
+}
+
+// subscribe cmdType of device with query made by SN, it is refreshed at half of expires
+func (server *Server) subscribe(device *Device, cmdType string, expires int, query func(sn int) interface{}) {
+	if err := server.Subscribe(device, query(server.nextSN()), expires); err != nil {
+		log.WithError(err).WithField("id", device.ID()).Warnf("subscribe %s", cmdType)
+		device.setSubscriptionDue(cmdType, time.Now().Add(subscribeRetryInterval))
+		return
+	}
+	log.WithField("id", device.ID()).Infof("subscribe %s, expires[%d]", cmdType, expires)
+	device.setSubscriptionDue(cmdType, time.Now().Add(time.Duration(expires)*time.Second/2))
+}
+
+// refreshSubscriptions of online device when they are due
+func (server *Server) refreshSubscriptions(device *Device, now time.Time) {
+	if !device.Online() {
+		return
+	}
+	if expires := config.SIP.AlarmSubscribeExpires; expires > 0 && device.subscriptionDue("Alarm", now) {
+		go server.subscribe(device, "Alarm", expires, func(sn int) interface{} {
+			return newAlarmQuery(device.ID(), sn)
+		})
+	}
+	if expires := config.SIP.PositionSubscribeExpires; expires > 0 && device.subscriptionDue("MobilePosition", now) {
+		go server.subscribe(device, "MobilePosition", expires, func(sn int) interface{} {
+			return newMobilePositionQuery(device.ID(), sn)
+		})
+	}
+}
@@ -0,0 +1,307 @@
+package gb28181
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// upstreamRetryInterval of register after failure
+const upstreamRetryInterval = 10 * time.Second
+
+// upstreamKeepaliveMaxFailure makes upstream offline and register again
+const upstreamKeepaliveMaxFailure = 3
+
+// Upstream is a superior platform this server registers to as a lower platform
+type Upstream struct {
+	server *Server
+	config *ConfigUpstream
+	callID string
+
+	lock        sync.RWMutex
+	addr        string
+	online      bool
+	registerAt  time.Time
+	keepaliveAt time.Time
+	stopChannel chan int
+	done        chan int
+}
+
+func newUpstream(server *Server, config *ConfigUpstream) *Upstream {
+	return &Upstream{
+		server:      server,
+		config:      config,
+		callID:      randomHex(16),
+		addr:        net.JoinHostPort(config.Host, strconv.Itoa(config.Port)),
+		stopChannel: make(chan int),
+		done:        make(chan int),
+	}
+}
+
+func (upstream *Upstream) String() string {
+	return fmt.Sprintf("upstream[%s]", upstream.ID())
+}
+
+// ID of upstream platform
+func (upstream *Upstream) ID() string {
+	return upstream.config.ID
+}
+
+// Addr of upstream signal, with transport
+func (upstream *Upstream) Addr() (transport string, addr string) {
+	upstream.lock.RLock()
+	addr = upstream.addr
+	upstream.lock.RUnlock()
+
+	return strings.ToUpper(upstream.config.Transport), addr
+}
+
+// from upstream if packet is received from its signal address, the ID in From is never trusted alone
+func (upstream *Upstream) from(packet *Packet) bool {
+	transport, addr := upstream.Addr()
+	if !strings.EqualFold(transport, packet.Transport) {
+		return false
+	}
+	if transport == TransportTCP {
+		// connection of upstream.connect
+		return addr == packet.Addr
+	}
+	expected, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return false
+	}
+	actual, err := net.ResolveUDPAddr("udp", packet.Addr)
+	if err != nil {
+		return false
+	}
+	return expected.IP.Equal(actual.IP) && expected.Port == actual.Port
+}
+
+// Online state of registration
+func (upstream *Upstream) Online() bool {
+	upstream.lock.RLock()
+	online := upstream.online
+	upstream.lock.RUnlock()
+
+	return online
+}
+
+// newRequest of method to upstream, out of dialog
+func (upstream *Upstream) newRequest(method string) *Message {
+	transport, addr := upstream.Addr()
+	server := upstream.server
+	req := NewRequest(method, fmt.Sprintf("sip:%s@%s", upstream.ID(), addr))
+	req.Header.Add("Via", server.newVia(transport))
+	req.Header.Add("From", fmt.Sprintf("<sip:%s@%s>;tag=%s", server.ID(), config.SIP.Domain, newTag()))
+	req.Header.Add("To", fmt.Sprintf("<sip:%s@%s>", upstream.ID(), upstream.config.Domain))
+	req.Header.Add("Call-ID", randomHex(16))
+	req.Header.Add("CSeq", fmt.Sprintf("%d %s", server.nextCSeq(), method))
+	req.Header.Add("Max-Forwards", "70")
+	req.Header.Add("User-Agent", userAgent)
+	return req
+}
+
+// connect upstream by TCP, the connection is shared with requests from upstream
+func (upstream *Upstream) connect() error {
+	transport, addr := upstream.Addr()
+	if transport != TransportTCP {
+		return nil
+	}
+	upstream.server.tcpConnsLock.RLock()
+	_, ok := upstream.server.tcpConns[addr]
+	upstream.server.tcpConnsLock.RUnlock()
+	if ok {
+		return nil
+	}
+
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(upstream.config.Host, strconv.Itoa(upstream.config.Port)), queryTimeout())
+	if err != nil {
+		return err
+	}
+	addr = conn.RemoteAddr().String()
+	c := &tcpConn{Conn: conn}
+	upstream.server.tcpConnsLock.Lock()
+	upstream.server.tcpConns[addr] = c
+	upstream.server.tcpConnsLock.Unlock()
+	upstream.lock.Lock()
+	upstream.addr = addr
+	upstream.lock.Unlock()
+	go upstream.server.readTCP(c)
+
+	return nil
+}
+
+// register to upstream, expires 0 to unregister
+func (upstream *Upstream) register(expires int) error {
+	if err := upstream.connect(); err != nil {
+		return err
+	}
+	server := upstream.server
+	req := upstream.newRequest(REGISTER)
+	// REGISTER is always from and to this server
+	req.Header.Set("To", fmt.Sprintf("<sip:%s@%s>", server.ID(), config.SIP.Domain))
+	req.Header.Set("Call-ID", upstream.callID)
+	req.Header.Add("Contact", fmt.Sprintf("<sip:%s@%s:%d>", server.ID(), server.Host(), server.Port))
+	req.Header.Add("Expires", strconv.Itoa(expires))
+
+	res, err := server.Request(upstream, req, queryTimeout())
+	if err != nil {
+		return err
+	}
+	if res.StatusCode == 401 || res.StatusCode == 407 {
+		challenge := res.Header.Get("WWW-Authenticate")
+		authHeader := "Authorization"
+		if res.StatusCode == 407 {
+			challenge = res.Header.Get("Proxy-Authenticate")
+			authHeader = "Proxy-Authorization"
+		}
+		transport, _ := upstream.Addr()
+		req.Header.Set("Via", server.newVia(transport))
+		req.Header.Set("CSeq", fmt.Sprintf("%d %s", server.nextCSeq(), REGISTER))
+		req.Header.Set(authHeader, digestAuthorization(challenge, REGISTER, req.RequestURI, server.ID(), upstream.config.Password))
+		if res, err = server.Request(upstream, req, queryTimeout()); err != nil {
+			return err
+		}
+	}
+	if res.StatusCode != 200 {
+		return fmt.Errorf("%s register response %d %s", upstream, res.StatusCode, res.Reason)
+	}
+	return nil
+}
+
+// keepalive to upstream
+func (upstream *Upstream) keepalive() error {
+	server := upstream.server
+	body, err := encodeMANSCDP(&Keepalive{
+		CmdType:  "Keepalive",
+		SN:       server.nextSN(),
+		DeviceID: server.ID(),
+		Status:   "OK",
+	})
+	if err != nil {
+		return err
+	}
+	req := upstream.newRequest(MESSAGE)
+	req.SetBody(ContentTypeMANSCDP, body)
+	res, err := server.Request(upstream, req, queryTimeout())
+	if err != nil {
+		return err
+	}
+	if res.StatusCode != 200 {
+		return fmt.Errorf("%s keepalive response %d %s", upstream, res.StatusCode, res.Reason)
+	}
+	return nil
+}
+
+func (upstream *Upstream) setOnline(online bool) {
+	upstream.lock.Lock()
+	upstream.online = online
+	now := time.Now()
+	if online {
+		upstream.registerAt = now
+		upstream.keepaliveAt = now
+	}
+	upstream.lock.Unlock()
+}
+
+// run registration and keepalive until stop
+func (upstream *Upstream) run() {
+	defer close(upstream.done)
+	keepaliveInterval := time.Duration(upstream.config.Keepalive) * time.Second
+	refreshInterval := time.Duration(upstream.config.Expires) * time.Second / 2
+	failures := 0
+	for {
+		wait := keepaliveInterval
+		if !upstream.Online() {
+			if err := upstream.register(upstream.config.Expires); err != nil {
+				log.WithError(err).WithField("id", upstream.ID()).Warn("upstream register")
+				wait = upstreamRetryInterval
+			} else {
+				log.WithField("id", upstream.ID()).Info("upstream registered")
+				upstream.setOnline(true)
+				failures = 0
+			}
+		}
+
+		select {
+		case <-upstream.stopChannel:
+			if upstream.Online() {
+				if err := upstream.register(0); err != nil {
+					log.WithError(err).WithField("id", upstream.ID()).Warn("upstream unregister")
+				}
+				upstream.setOnline(false)
+			}
+			return
+		case <-time.After(wait):
+		}
+
+		if !upstream.Online() {
+			continue
+		}
+		upstream.lock.RLock()
+		registerAt := upstream.registerAt
+		upstream.lock.RUnlock()
+		if time.Since(registerAt) > refreshInterval {
+			upstream.setOnline(false)
+			continue
+		}
+		if err := upstream.keepalive(); err != nil {
+			failures++
+			log.WithError(err).WithField("id", upstream.ID()).Warnf("upstream keepalive failed %d times", failures)
+			if failures >= upstreamKeepaliveMaxFailure {
+				upstream.setOnline(false)
+			}
+			continue
+		}
+		failures = 0
+		upstream.lock.Lock()
+		upstream.keepaliveAt = time.Now()
+		upstream.lock.Unlock()
+	}
+}
+
+// stop and wait for unregister
+func (upstream *Upstream) stop() {
+	close(upstream.stopChannel)
+	<-upstream.done
+}
+
+// getUpstream by ID, nil if not found
+func (server *Server) getUpstream(ID string) *Upstream {
+	server.upstreamsLock.RLock()
+	upstream := server.upstreams[ID]
+	server.upstreamsLock.RUnlock()
+
+	return upstream
+}
+
+// startUpstreams of config
+func (server *Server) startUpstreams() {
+	server.upstreamsLock.Lock()
+	defer server.upstreamsLock.Unlock()
+
+	for _, c := range config.Upstreams {
+		if c.ID == "" || c.Host == "" {
+			log.Warnf("upstream[%s] without ID or host, ignored", c.ID)
+			continue
+		}
+		upstream := newUpstream(server, c)
+		server.upstreams[c.ID] = upstream
+		go upstream.run()
+	}
+}
+
+// stopUpstreams and unregister
+func (server *Server) stopUpstreams() {
+	server.upstreamsLock.Lock()
+	upstreams := server.upstreams
+	server.upstreams = make(map[string]*Upstream)
+	server.upstreamsLock.Unlock()
+
+	for _, upstream := range upstreams {
+		upstream.stop()
+	}
+}
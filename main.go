@@ -10,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/EasyDarwin/EasyDarwin/gb28181"
 	"github.com/EasyDarwin/EasyDarwin/models"
 	"github.com/EasyDarwin/EasyDarwin/routers"
 	"github.com/EasyDarwin/EasyDarwin/rtsp"
@@ -28,6 +29,8 @@ type program struct {
 	httpServer   *http.Server
 	rtspPort     int
 	rtspServer   *rtsp.Server
+	sipPort      int
+	sipServer    *gb28181.Server
 	cert         string
 	key          string
 	streamSecret string
@@ -73,8 +76,12 @@ func (p *program) StartRTSP() (err error) {
 	if p.rtspPort != 554 {
 		sport = fmt.Sprintf(":%d", p.rtspPort)
 	}
-	link := fmt.Sprintf("rtsps://%s%s", utils.LocalIP(), sport)
+	link := fmt.Sprintf("rtsp://%s%s", utils.LocalIP(), sport)
 	log.Println("rtsp server start -->", link)
+	if p.rtspServer.TLSPort > 0 {
+		link := fmt.Sprintf("rtsps://%s:%d", utils.LocalIP(), p.rtspServer.TLSPort)
+		log.Println("rtsps server start -->", link)
+	}
 	go func() {
 		if err := p.rtspServer.Start(p.cert, p.key, p.streamSecret); err != nil {
 			log.Println("start rtsp server error", err)
@@ -93,6 +100,31 @@ func (p *program) StopRTSP() (err error) {
 	return
 }
 
+func (p *program) StartSIP() (err error) {
+	if p.sipServer == nil {
+		err = fmt.Errorf("SIP Server Not Found")
+		return
+	}
+	link := fmt.Sprintf("sip:%s@%s:%d", p.sipServer.ID(), p.sipServer.Host(), p.sipPort)
+	log.Println("sip server start -->", link)
+	go func() {
+		if err := p.sipServer.Start(); err != nil {
+			log.Println("start sip server error", err)
+		}
+		log.Println("sip server end")
+	}()
+	return
+}
+
+func (p *program) StopSIP() (err error) {
+	if p.sipServer == nil {
+		err = fmt.Errorf("SIP Server Not Found")
+		return
+	}
+	p.sipServer.Stop()
+	return
+}
+
 func (p *program) Start(s service.Service) (err error) {
 	log.Println("********** START **********")
 	if utils.IsPortInUse(p.httpPort) {
@@ -103,22 +135,29 @@ func (p *program) Start(s service.Service) (err error) {
 		err = fmt.Errorf("RTSP port[%d] In Use", p.rtspPort)
 		return
 	}
+	if utils.IsPortInUse(p.sipPort) {
+		err = fmt.Errorf("SIP port[%d] In Use", p.sipPort)
+		return
+	}
 	// Init API server
 	err = routers.Init()
 	if err != nil {
 		return
 	}
 	p.StartRTSP()
+	p.StartSIP()
 	p.StartHTTP()
 
 	// TODO: log sestup
 	go func() {
 		for range routers.API.RestartChan {
 			p.StopHTTP()
+			p.StopSIP()
 			p.StopRTSP()
 			// utils.ReloadConf()
 			// TODO : reload config and init
 			p.StartRTSP()
+			p.StartSIP()
 			p.StartHTTP()
 		}
 	}()
@@ -201,6 +240,7 @@ func (p *program) Stop(s service.Service) (err error) {
 	// defer utils.CloseLogWriter()
 	// TODO: stop log
 	p.StopHTTP()
+	p.StopSIP()
 	p.StopRTSP()
 	return
 }
@@ -232,10 +272,13 @@ func main() {
 	key := utils.Conf().Section("tls").Key("key").MustString("")
 	streamSecret := utils.Conf().Section("rtsp").Key("stream_secret_key").MustString("")
 	rtspServer := rtsp.GetServer()
+	sipServer := gb28181.GetServer()
 	p := &program{
 		httpPort:     httpPort,
 		rtspPort:     rtspServer.TCPPort,
 		rtspServer:   rtspServer,
+		sipPort:      sipServer.Port,
+		sipServer:    sipServer,
 		cert:         cert,
 		key:          key,
 		streamSecret: streamSecret,
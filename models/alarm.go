@@ -0,0 +1,109 @@
+package models
+
+import (
+	"fmt"
+
+	"github.com/go-redis/redis"
+	proto "github.com/golang/protobuf/proto"
+)
+
+// alarmTimeKey is the index of alarms by time
+const alarmTimeKey = "alarm:t"
+
+// AddAlarm to DB and index it by time
+func AddAlarm(alarm *Alarm) error {
+	bytes, err := proto.Marshal(alarm)
+	if nil != err {
+		log.Errorf("Marshal alarm [%v]", err)
+		return err
+	}
+
+	_, err = db.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.HSet("alarm", alarm.ID, bytes)
+		pipe.ZAdd(alarmTimeKey, redis.Z{
+			Score:  float64(alarm.Time),
+			Member: alarm.ID,
+		})
+		return nil
+	})
+	if nil != err {
+		log.WithError(err).WithField("id", alarm.ID).Error("redis")
+		return ErrorDB
+	}
+
+	return nil
+}
+
+// GetAlarm from DB, return nil if not exists
+func GetAlarm(ID string) (*Alarm, error) {
+	bytes, err := db.HGet("alarm", ID).Result()
+	if nil != err {
+		if redis.Nil == err {
+			return nil, nil
+		}
+		log.Errorf("DB get [%v]", err)
+		return nil, err
+	}
+
+	alarm := &Alarm{}
+	if err := proto.Unmarshal([]byte(bytes), alarm); err != nil {
+		log.Errorf("Unmarshal alarm [%v]", err)
+		return nil, err
+	}
+
+	return alarm, nil
+}
+
+// GetAlarmsByTime between start and end in unix seconds, ordered by time
+func GetAlarmsByTime(start int64, end int64) (alarms []*Alarm, err error) {
+	IDs, err := db.ZRangeByScore(alarmTimeKey, redis.ZRangeBy{
+		Min: fmt.Sprintf("%d", start),
+		Max: fmt.Sprintf("%d", end),
+	}).Result()
+	if nil != err {
+		log.WithError(err).WithField("key", alarmTimeKey).Error("redis")
+		return nil, ErrorDB
+	}
+	if len(IDs) == 0 {
+		return
+	}
+
+	values, err := db.HMGet("alarm", IDs...).Result()
+	if nil != err {
+		log.WithError(err).WithField("key", "alarm").Error("redis")
+		return nil, ErrorDB
+	}
+	for _, value := range values {
+		bytes, ok := value.(string)
+		if !ok {
+			// removed
+			continue
+		}
+		alarm := &Alarm{}
+		if err = proto.Unmarshal([]byte(bytes), alarm); err != nil {
+			return
+		}
+		alarms = append(alarms, alarm)
+	}
+
+	return
+}
+
+// AckAlarm of ID at time, return nil if not exists
+func AckAlarm(ID string, at int64) (*Alarm, error) {
+	alarm, err := GetAlarm(ID)
+	if nil != err || nil == alarm {
+		return nil, err
+	}
+	if alarm.Acked {
+		return alarm, nil
+	}
+
+	alarm.Acked = true
+	alarm.AckAt = at
+	if err := AddAlarm(alarm); nil != err {
+		return nil, err
+	}
+
+	return alarm, nil
+}
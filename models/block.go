@@ -98,3 +98,33 @@ func GetBlockByTime(block *Block, time int64) error {
 
 	return GetBlockByID(block)
 }
+
+// GetBlockBeforeTime according taskID executeID and time, the last block starts at or before time
+func GetBlockBeforeTime(block *Block, time int64) error {
+	cmd := db.ZRevRangeByScore(
+		block.TaskExecute.getTaskExecuteBlockTimeKey(),
+		redis.ZRangeBy{
+			Min:    "-inf",
+			Max:    fmt.Sprintf("%d", time),
+			Offset: 0,
+			Count:  1,
+		},
+	)
+	if nil != cmd.Err() {
+		log.WithField("cmd", cmd.Args()).Error("redis")
+		return cmd.Err()
+	}
+
+	if len(cmd.Val()) == 0 {
+		return ErrorBlockNotFount
+	}
+
+	blockID, err := strconv.ParseInt(cmd.Val()[0], 10, 63)
+	if nil != err {
+		return ErrorBlockMalformed
+	}
+
+	block.ID = blockID
+
+	return GetBlockByID(block)
+}
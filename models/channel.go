@@ -0,0 +1,90 @@
+package models
+
+import (
+	"fmt"
+
+	"github.com/go-redis/redis"
+	proto "github.com/golang/protobuf/proto"
+)
+
+func getDeviceChannelsKey(deviceID string) string {
+	return fmt.Sprintf("%s:dc", deviceID)
+}
+
+// SetDeviceChannels replace all channels of device
+func SetDeviceChannels(deviceID string, channels []*Channel) error {
+	key := getDeviceChannelsKey(deviceID)
+	fields := make(map[string]interface{}, len(channels))
+	for _, channel := range channels {
+		bytes, err := proto.Marshal(channel)
+		if nil != err {
+			log.Errorf("Marshal channel [%v]", err)
+			return err
+		}
+		fields[channel.ID] = bytes
+	}
+
+	_, err := db.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.Del(key)
+		if len(fields) > 0 {
+			pipe.HMSet(key, fields)
+		}
+		return nil
+	})
+	if nil != err {
+		log.WithError(err).WithField("key", key).Error("redis")
+		return ErrorDB
+	}
+
+	return nil
+}
+
+// GetDeviceChannel from DB, return nil if not exists
+func GetDeviceChannel(deviceID string, ID string) (*Channel, error) {
+	bytes, err := db.HGet(getDeviceChannelsKey(deviceID), ID).Result()
+	if nil != err {
+		if redis.Nil == err {
+			return nil, nil
+		}
+		log.Errorf("DB get [%v]", err)
+		return nil, err
+	}
+
+	channel := &Channel{}
+	if err := proto.Unmarshal([]byte(bytes), channel); err != nil {
+		log.Errorf("Unmarshal channel [%v]", err)
+		return nil, err
+	}
+
+	return channel, nil
+}
+
+// GetDeviceChannels stored in DB
+func GetDeviceChannels(deviceID string) (channels []*Channel, err error) {
+	all := db.HGetAll(getDeviceChannelsKey(deviceID))
+
+	if err = all.Err(); err != nil {
+		return
+	}
+
+	for _, bytes := range all.Val() {
+		channel := &Channel{}
+		if err = proto.Unmarshal([]byte(bytes), channel); err != nil {
+			return
+		}
+		channels = append(channels, channel)
+	}
+
+	return
+}
+
+// RemoveDeviceChannels from DB
+func RemoveDeviceChannels(deviceID string) error {
+	cmd := db.Del(getDeviceChannelsKey(deviceID))
+	if err := cmd.Err(); nil != err {
+		log.WithError(err).WithField("cmd", cmd.Args()).Error("redis")
+		return ErrorDB
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"github.com/go-redis/redis"
+	proto "github.com/golang/protobuf/proto"
+)
+
+// AddDevice to DB, replace if exists
+func AddDevice(device *Device) error {
+	bytes, err := proto.Marshal(device)
+	if nil != err {
+		log.Errorf("Marshal device [%v]", err)
+		return err
+	}
+	cmd := db.HSet("device", device.ID, bytes)
+
+	if nil != cmd.Err() {
+		log.WithError(cmd.Err()).WithField("cmd", cmd.Args()).Error("redis")
+		return cmd.Err()
+	}
+
+	return nil
+}
+
+// GetDevice from DB, return nil if not exists
+func GetDevice(ID string) (*Device, error) {
+	bytes, err := db.HGet("device", ID).Result()
+	if nil != err {
+		if redis.Nil == err {
+			return nil, nil
+		}
+		log.Errorf("DB get [%v]", err)
+		return nil, err
+	}
+
+	device := &Device{}
+	if err := proto.Unmarshal([]byte(bytes), device); err != nil {
+		log.Errorf("Unmarshal device [%v]", err)
+		return nil, err
+	}
+
+	return device, nil
+}
+
+// RemoveDevice and its channels and positions from DB
+func RemoveDevice(ID string) error {
+	cmd := db.HDel("device", ID)
+	if err := cmd.Err(); nil != err {
+		log.WithError(err).WithField("cmd", cmd.Args()).Error("redis")
+		return ErrorDB
+	}
+
+	if err := RemoveDeviceChannels(ID); nil != err {
+		return err
+	}
+	return RemovePositions(ID)
+}
+
+// GetAllDevices stored in DB
+func GetAllDevices() (devices []*Device, err error) {
+	all := db.HGetAll("device")
+
+	if err = all.Err(); err != nil {
+		return
+	}
+
+	for _, bytes := range all.Val() {
+		device := &Device{}
+		if err = proto.Unmarshal([]byte(bytes), device); err != nil {
+			return
+		}
+		devices = append(devices, device)
+	}
+
+	return
+}
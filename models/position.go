@@ -0,0 +1,94 @@
+package models
+
+import (
+	"fmt"
+
+	"github.com/go-redis/redis"
+	proto "github.com/golang/protobuf/proto"
+)
+
+func getDevicePositionsKey(deviceID string) string {
+	return fmt.Sprintf("%s:mp", deviceID)
+}
+
+// AddPosition of device indexed by time, positions before keepAfter are removed
+func AddPosition(position *Position, keepAfter int64) error {
+	bytes, err := proto.Marshal(position)
+	if nil != err {
+		log.Errorf("Marshal position [%v]", err)
+		return err
+	}
+
+	key := getDevicePositionsKey(position.DeviceID)
+	_, err = db.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.ZAdd(key, redis.Z{
+			Score:  float64(position.Time),
+			Member: bytes,
+		})
+		if keepAfter > 0 {
+			pipe.ZRemRangeByScore(key, "-inf", fmt.Sprintf("(%d", keepAfter))
+		}
+		return nil
+	})
+	if nil != err {
+		log.WithError(err).WithField("key", key).Error("redis")
+		return ErrorDB
+	}
+
+	return nil
+}
+
+// GetLastPosition of device, return nil if not exists
+func GetLastPosition(deviceID string) (*Position, error) {
+	key := getDevicePositionsKey(deviceID)
+	members, err := db.ZRevRange(key, 0, 0).Result()
+	if nil != err {
+		log.WithError(err).WithField("key", key).Error("redis")
+		return nil, ErrorDB
+	}
+	if len(members) == 0 {
+		return nil, nil
+	}
+
+	position := &Position{}
+	if err := proto.Unmarshal([]byte(members[0]), position); err != nil {
+		log.Errorf("Unmarshal position [%v]", err)
+		return nil, err
+	}
+
+	return position, nil
+}
+
+// GetPositionsByTime of device between start and end in unix seconds, ordered by time
+func GetPositionsByTime(deviceID string, start int64, end int64) (positions []*Position, err error) {
+	key := getDevicePositionsKey(deviceID)
+	members, err := db.ZRangeByScore(key, redis.ZRangeBy{
+		Min: fmt.Sprintf("%d", start),
+		Max: fmt.Sprintf("%d", end),
+	}).Result()
+	if nil != err {
+		log.WithError(err).WithField("key", key).Error("redis")
+		return nil, ErrorDB
+	}
+
+	for _, member := range members {
+		position := &Position{}
+		if err = proto.Unmarshal([]byte(member), position); err != nil {
+			return
+		}
+		positions = append(positions, position)
+	}
+
+	return
+}
+
+// RemovePositions of device
+func RemovePositions(deviceID string) error {
+	cmd := db.Del(getDevicePositionsKey(deviceID))
+	if err := cmd.Err(); nil != err {
+		log.WithError(err).WithField("cmd", cmd.Args()).Error("redis")
+		return ErrorDB
+	}
+
+	return nil
+}
@@ -0,0 +1,139 @@
+package models
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	proto "github.com/golang/protobuf/proto"
+)
+
+// Policy types
+const (
+	PolicyOpen   = "open"
+	PolicySigned = "signed"
+	PolicyUser   = "user"
+	PolicyIP     = "ip"
+	PolicyDeny   = "deny"
+)
+
+// Validate type of policy and IPs
+func (policy *Policy) Validate() error {
+	switch policy.Type {
+	case PolicyOpen, PolicySigned, PolicyUser, PolicyDeny:
+	case PolicyIP:
+		if len(policy.IPs) == 0 {
+			return fmt.Errorf("ip policy without IPs")
+		}
+		for _, ip := range policy.IPs {
+			if _, _, err := net.ParseCIDR(ip); err != nil && net.ParseIP(ip) == nil {
+				return fmt.Errorf("IP %s malformed", ip)
+			}
+		}
+	default:
+		return fmt.Errorf("policy %s unknown", policy.Type)
+	}
+	return nil
+}
+
+// AllowIP of policy, ip is matched with IPs or CIDRs
+func (policy *Policy) AllowIP(ip net.IP) bool {
+	for _, allowed := range policy.IPs {
+		if strings.Contains(allowed, "/") {
+			if _, ipNet, err := net.ParseCIDR(allowed); err == nil && ipNet.Contains(ip) {
+				return true
+			}
+		} else if allowedIP := net.ParseIP(allowed); allowedIP != nil && allowedIP.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowUser of policy, all users are allowed if Users is empty
+func (policy *Policy) AllowUser(username string) bool {
+	if len(policy.Users) == 0 {
+		return true
+	}
+	for _, user := range policy.Users {
+		if user == username {
+			return true
+		}
+	}
+	return false
+}
+
+// Match path with pattern of rule
+func (rule *Rule) Match(path string) bool {
+	return matchPath([]string{rule.Pattern}, path)
+}
+
+// sortRules of highest priority and then the longest pattern first, rules may be shared so they are copied
+func sortRules(rules []*Rule) []*Rule {
+	sorted := make([]*Rule, len(rules))
+	copy(sorted, rules)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Priority != sorted[j].Priority {
+			return sorted[i].Priority > sorted[j].Priority
+		}
+		return len(sorted[i].Pattern) > len(sorted[j].Pattern)
+	})
+	return sorted
+}
+
+// MatchRule of path in rules, the one with highest priority and then the longest pattern, nil if none
+func MatchRule(rules []*Rule, path string) *Rule {
+	for _, rule := range sortRules(rules) {
+		if rule.Match(path) {
+			return rule
+		}
+	}
+	return nil
+}
+
+// AddRule to DB, replace if exists
+func AddRule(rule *Rule) error {
+	bytes, err := proto.Marshal(rule)
+	if nil != err {
+		log.Errorf("Marshal rule [%v]", err)
+		return err
+	}
+	cmd := db.HSet("rule", rule.ID, bytes)
+
+	if nil != cmd.Err() {
+		log.WithError(cmd.Err()).WithField("cmd", cmd.Args()).Error("redis")
+		return cmd.Err()
+	}
+
+	return nil
+}
+
+// RemoveRule from DB
+func RemoveRule(ID string) error {
+	cmd := db.HDel("rule", ID)
+	if err := cmd.Err(); nil != err {
+		log.WithError(err).WithField("cmd", cmd.Args()).Error("redis")
+		return ErrorDB
+	}
+	return nil
+}
+
+// GetAllRules stored in DB
+func GetAllRules() (rules []*Rule, err error) {
+	all := db.HGetAll("rule")
+
+	if err = all.Err(); err != nil {
+		return
+	}
+
+	for _, bytes := range all.Val() {
+		rule := &Rule{}
+		if err = proto.Unmarshal([]byte(bytes), rule); err != nil {
+			return
+		}
+		rules = append(rules, rule)
+	}
+
+	return
+}
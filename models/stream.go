@@ -1,6 +1,9 @@
 package models
 
-import proto "github.com/golang/protobuf/proto"
+import (
+	"github.com/go-redis/redis"
+	proto "github.com/golang/protobuf/proto"
+)
 
 // AddStream to DB
 func AddStream(stream *Stream) error {
@@ -48,3 +51,23 @@ func GetAllStream() (streams []*Stream, err error) {
 
 	return
 }
+
+// GetStream from DB, return nil if not exists
+func GetStream(ID string) (*Stream, error) {
+	bytes, err := db.HGet("stream", ID).Result()
+	if nil != err {
+		if redis.Nil == err {
+			return nil, nil
+		}
+		log.Errorf("DB get [%v]", err)
+		return nil, err
+	}
+
+	stream := &Stream{}
+	if err := proto.Unmarshal([]byte(bytes), stream); err != nil {
+		log.Errorf("Unmarshal stream [%v]", err)
+		return nil, err
+	}
+
+	return stream, nil
+}
@@ -26,6 +26,10 @@ type Stream struct {
 	CustomPath           string   `protobuf:"bytes,3,opt,name=CustomPath,proto3" json:"CustomPath,omitempty"`
 	IdleTimeout          int64    `protobuf:"varint,4,opt,name=IdleTimeout,proto3" json:"IdleTimeout,omitempty"`
 	HeartbeatInterval    int64    `protobuf:"varint,5,opt,name=HeartbeatInterval,proto3" json:"HeartbeatInterval,omitempty"`
+	ONVIFAddr            string   `protobuf:"bytes,6,opt,name=ONVIFAddr,proto3" json:"ONVIFAddr,omitempty"`
+	ONVIFUsername        string   `protobuf:"bytes,7,opt,name=ONVIFUsername,proto3" json:"ONVIFUsername,omitempty"`
+	ONVIFPassword        string   `protobuf:"bytes,8,opt,name=ONVIFPassword,proto3" json:"ONVIFPassword,omitempty"`
+	ONVIFProfile         string   `protobuf:"bytes,9,opt,name=ONVIFProfile,proto3" json:"ONVIFProfile,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -91,6 +95,34 @@ func (m *Stream) GetHeartbeatInterval() int64 {
 	return 0
 }
 
+func (m *Stream) GetONVIFAddr() string {
+	if m != nil {
+		return m.ONVIFAddr
+	}
+	return ""
+}
+
+func (m *Stream) GetONVIFUsername() string {
+	if m != nil {
+		return m.ONVIFUsername
+	}
+	return ""
+}
+
+func (m *Stream) GetONVIFPassword() string {
+	if m != nil {
+		return m.ONVIFPassword
+	}
+	return ""
+}
+
+func (m *Stream) GetONVIFProfile() string {
+	if m != nil {
+		return m.ONVIFProfile
+	}
+	return ""
+}
+
 func init() {
 	proto.RegisterType((*Stream)(nil), "models.Stream")
 }
@@ -98,15 +130,19 @@ func init() {
 func init() { proto.RegisterFile("stream.proto", fileDescriptor_bb17ef3f514bfe54) }
 
 var fileDescriptor_bb17ef3f514bfe54 = []byte{
-	// 159 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0xe2, 0x29, 0x2e, 0x29, 0x4a,
-	0x4d, 0xcc, 0xd5, 0x2b, 0x28, 0xca, 0x2f, 0xc9, 0x17, 0x62, 0xcb, 0xcd, 0x4f, 0x49, 0xcd, 0x29,
-	0x56, 0x9a, 0xc5, 0xc8, 0xc5, 0x16, 0x0c, 0x96, 0x10, 0xe2, 0xe3, 0x62, 0xf2, 0x74, 0x91, 0x60,
-	0x54, 0x60, 0xd4, 0xe0, 0x0c, 0x62, 0xf2, 0x74, 0x11, 0x12, 0xe0, 0x62, 0x0e, 0x0d, 0xf2, 0x91,
-	0x60, 0x02, 0x0b, 0x80, 0x98, 0x42, 0x72, 0x5c, 0x5c, 0xce, 0xa5, 0xc5, 0x25, 0xf9, 0xb9, 0x01,
-	0x89, 0x25, 0x19, 0x12, 0xcc, 0x60, 0x09, 0x24, 0x11, 0x21, 0x05, 0x2e, 0x6e, 0xcf, 0x94, 0x9c,
-	0xd4, 0x90, 0xcc, 0xdc, 0xd4, 0xfc, 0xd2, 0x12, 0x09, 0x16, 0x05, 0x46, 0x0d, 0xe6, 0x20, 0x64,
-	0x21, 0x21, 0x1d, 0x2e, 0x41, 0x8f, 0xd4, 0xc4, 0xa2, 0x92, 0xa4, 0xd4, 0xc4, 0x12, 0xcf, 0xbc,
-	0x92, 0xd4, 0xa2, 0xb2, 0xc4, 0x1c, 0x09, 0x56, 0xb0, 0x3a, 0x4c, 0x89, 0x24, 0x36, 0xb0, 0x5b,
-	0x8d, 0x01, 0x01, 0x00, 0x00, 0xff, 0xff, 0x6e, 0xf5, 0xe2, 0x0e, 0xbb, 0x00, 0x00, 0x00,
+	// 222 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x64, 0xd0, 0xc1, 0x4a, 0x03, 0x31,
+	0x10, 0xc6, 0x71, 0x76, 0x57, 0x57, 0x77, 0xac, 0xa2, 0x73, 0x9a, 0x83, 0xc8, 0x52, 0x3c, 0xf4,
+	0x20, 0x5e, 0x7c, 0x02, 0xb1, 0x88, 0x01, 0xd1, 0xb2, 0x5a, 0xef, 0x29, 0x19, 0xb1, 0xb0, 0x69,
+	0x64, 0x32, 0xd5, 0x57, 0xf2, 0x31, 0xc5, 0x11, 0x75, 0x8b, 0xb7, 0xe4, 0xf7, 0xfd, 0x21, 0x10,
+	0x18, 0x65, 0x15, 0xf6, 0xf1, 0xfc, 0x55, 0x92, 0x26, 0xac, 0x63, 0x0a, 0xdc, 0xe7, 0xf1, 0x47,
+	0x09, 0xf5, 0x83, 0x0d, 0x78, 0x00, 0xa5, 0x9b, 0x52, 0xd1, 0x16, 0x93, 0xa6, 0x2b, 0xdd, 0x14,
+	0x0f, 0xa1, 0x9a, 0x77, 0xb7, 0x54, 0x1a, 0x7c, 0x1d, 0xf1, 0x04, 0xe0, 0x6a, 0x9d, 0x35, 0xc5,
+	0x99, 0xd7, 0x17, 0xaa, 0x6c, 0x18, 0x08, 0xb6, 0xb0, 0xe7, 0x42, 0xcf, 0x8f, 0xcb, 0xc8, 0x69,
+	0xad, 0xb4, 0xd5, 0x16, 0x93, 0xaa, 0x1b, 0x12, 0x9e, 0xc1, 0xd1, 0x0d, 0x7b, 0xd1, 0x05, 0x7b,
+	0x75, 0x2b, 0x65, 0x79, 0xf3, 0x3d, 0x6d, 0x5b, 0xf7, 0x7f, 0xc0, 0x63, 0x68, 0xee, 0xef, 0x9e,
+	0xdc, 0xf5, 0x65, 0x08, 0x42, 0xb5, 0x3d, 0xf7, 0x07, 0x78, 0x0a, 0xfb, 0x76, 0x99, 0x67, 0x96,
+	0x95, 0x8f, 0x4c, 0x3b, 0x56, 0x6c, 0xe2, 0x6f, 0x35, 0xf3, 0x39, 0xbf, 0x27, 0x09, 0xb4, 0x3b,
+	0xa8, 0x7e, 0x10, 0xc7, 0x30, 0xfa, 0x06, 0x49, 0xcf, 0xcb, 0x9e, 0xa9, 0xb1, 0x68, 0xc3, 0x16,
+	0xb5, 0xfd, 0xdc, 0xc5, 0xe7, 0x00, 0xe4, 0xac, 0x6f, 0x4f, 0x49, 0x01, 0x00, 0x00,
 }
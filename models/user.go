@@ -0,0 +1,104 @@
+package models
+
+import (
+	"crypto/md5"
+	"fmt"
+	"path"
+
+	"github.com/go-redis/redis"
+	proto "github.com/golang/protobuf/proto"
+)
+
+// HA1 of Digest authorization, md5(username:realm:password)
+func HA1(username string, realm string, password string) string {
+	return fmt.Sprintf("%x", md5.Sum([]byte(fmt.Sprintf("%s:%s:%s", username, realm, password))))
+}
+
+// matchPath of patterns, "*" matches all paths, others are matched by path.Match
+func matchPath(patterns []string, p string) bool {
+	for _, pattern := range patterns {
+		if pattern == "*" {
+			return true
+		}
+		if ok, _ := path.Match(pattern, p); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// CanPublish to path
+func (user *User) CanPublish(path string) bool {
+	return user.Enabled && matchPath(user.PublishPaths, path)
+}
+
+// CanPlay of path
+func (user *User) CanPlay(path string) bool {
+	return user.Enabled && matchPath(user.PlayPaths, path)
+}
+
+// AddUser to DB, replace if exists
+func AddUser(user *User) error {
+	bytes, err := proto.Marshal(user)
+	if nil != err {
+		log.Errorf("Marshal user [%v]", err)
+		return err
+	}
+	cmd := db.HSet("user", user.Username, bytes)
+
+	if nil != cmd.Err() {
+		log.WithError(cmd.Err()).WithField("cmd", cmd.Args()).Error("redis")
+		return cmd.Err()
+	}
+
+	return nil
+}
+
+// GetUser from DB, return nil if not exists
+func GetUser(username string) (*User, error) {
+	bytes, err := db.HGet("user", username).Result()
+	if nil != err {
+		if redis.Nil == err {
+			return nil, nil
+		}
+		log.Errorf("DB get [%v]", err)
+		return nil, err
+	}
+
+	user := &User{}
+	if err := proto.Unmarshal([]byte(bytes), user); err != nil {
+		log.Errorf("Unmarshal user [%v]", err)
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// RemoveUser from DB
+func RemoveUser(username string) error {
+	cmd := db.HDel("user", username)
+	if err := cmd.Err(); nil != err {
+		log.WithError(err).WithField("cmd", cmd.Args()).Error("redis")
+		return ErrorDB
+	}
+	return nil
+}
+
+// GetAllUsers stored in DB
+func GetAllUsers() (users []*User, err error) {
+	all := db.HGetAll("user")
+
+	if err = all.Err(); err != nil {
+		return
+	}
+
+	for _, bytes := range all.Val() {
+		user := &User{}
+		if err = proto.Unmarshal([]byte(bytes), user); err != nil {
+			return
+		}
+		users = append(users, user)
+	}
+
+	return
+}
@@ -0,0 +1,231 @@
+package onvif
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const envelopeTemplate = `<?xml version="1.0" encoding="UTF-8"?>
+<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope" xmlns:tds="http://www.onvif.org/ver10/device/wsdl" xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tptz="http://www.onvif.org/ver20/ptz/wsdl" xmlns:timg="http://www.onvif.org/ver20/imaging/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+<s:Header>%s</s:Header>
+<s:Body>%s</s:Body>
+</s:Envelope>`
+
+// securityTemplate of WS-Security UsernameToken with PasswordDigest
+const securityTemplate = `<wsse:Security s:mustUnderstand="1" xmlns:wsse="http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-secext-1.0.xsd" xmlns:wsu="http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-utility-1.0.xsd">
+<wsse:UsernameToken>
+<wsse:Username>%s</wsse:Username>
+<wsse:Password Type="http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-username-token-profile-1.0#PasswordDigest">%s</wsse:Password>
+<wsse:Nonce EncodingType="http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-soap-message-security-1.0#Base64Binary">%s</wsse:Nonce>
+<wsu:Created>%s</wsu:Created>
+</wsse:UsernameToken>
+</wsse:Security>`
+
+type envelope struct {
+	XMLName xml.Name `xml:"Envelope"`
+	Body    struct {
+		Fault *struct {
+			Code   string `xml:"Code>Subcode>Value"`
+			Reason string `xml:"Reason>Text"`
+		} `xml:"Fault"`
+		Content []byte `xml:",innerxml"`
+	} `xml:"Body"`
+}
+
+// Client of ONVIF device
+type Client struct {
+	XAddr    string
+	Username string
+	Password string
+
+	httpClient *http.Client
+	lock       sync.RWMutex
+	// device time minus local time, UsernameToken is rejected on clock skew
+	timeOffset  time.Duration
+	mediaAddr   string
+	ptzAddr     string
+	imagingAddr string
+	// video source of profiles for imaging, key is profile token
+	videoSources map[string]string
+}
+
+var (
+	clients     = make(map[string]*Client)
+	clientsLock sync.Mutex
+)
+
+// GetClient of device dialed before with the same credentials, or dial a new one
+func GetClient(xaddr string, username string, password string) (*Client, error) {
+	key := fmt.Sprintf("%s|%s|%s", xaddr, username, password)
+	clientsLock.Lock()
+	client, ok := clients[key]
+	clientsLock.Unlock()
+	if ok {
+		return client, nil
+	}
+
+	client, err := Dial(xaddr, username, password)
+	if nil != err {
+		return nil, err
+	}
+	clientsLock.Lock()
+	clients[key] = client
+	clientsLock.Unlock()
+	return client, nil
+}
+
+// Dial device service at xaddr, empty username for the one of config.
+// Time of device is synced and services are got by GetCapabilities
+func Dial(xaddr string, username string, password string) (*Client, error) {
+	if username == "" {
+		username, password = config.ONVIF.Username, config.ONVIF.Password
+	}
+	client := &Client{
+		XAddr:        xaddr,
+		Username:     username,
+		Password:     password,
+		videoSources: make(map[string]string),
+		httpClient: &http.Client{
+			Timeout: time.Duration(config.ONVIF.RequestTimeout) * time.Second,
+		},
+	}
+	if err := client.syncTime(); nil != err {
+		log.WithError(err).WithField("addr", xaddr).Warn("GetSystemDateAndTime")
+	}
+	if err := client.getCapabilities(); nil != err {
+		return nil, err
+	}
+	return client, nil
+}
+
+func escape(value string) string {
+	buf := &bytes.Buffer{}
+	xml.EscapeText(buf, []byte(value))
+	return buf.String()
+}
+
+func (client *Client) securityHeader() string {
+	if client.Username == "" {
+		return ""
+	}
+	nonce := make([]byte, 16)
+	rand.Read(nonce)
+	client.lock.RLock()
+	created := time.Now().Add(client.timeOffset).UTC().Format("2006-01-02T15:04:05.000Z")
+	client.lock.RUnlock()
+
+	// Base64(SHA1(nonce + created + password))
+	hash := sha1.New()
+	hash.Write(nonce)
+	hash.Write([]byte(created))
+	hash.Write([]byte(client.Password))
+	digest := base64.StdEncoding.EncodeToString(hash.Sum(nil))
+
+	return fmt.Sprintf(securityTemplate, escape(client.Username), digest, base64.StdEncoding.EncodeToString(nonce), created)
+}
+
+// call SOAP request of body at addr, the response element in body is decoded into res
+func (client *Client) call(addr string, body string, res interface{}, auth bool) error {
+	header := ""
+	if auth {
+		header = client.securityHeader()
+	}
+	req, err := http.NewRequest("POST", addr, strings.NewReader(fmt.Sprintf(envelopeTemplate, header, body)))
+	if nil != err {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/soap+xml; charset=utf-8")
+	resp, err := client.httpClient.Do(req)
+	if nil != err {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if nil != err {
+		return err
+	}
+
+	env := &envelope{}
+	if err := xml.Unmarshal(data, env); nil != err {
+		return fmt.Errorf("ONVIF response %d %s: %v", resp.StatusCode, resp.Status, err)
+	}
+	if fault := env.Body.Fault; nil != fault {
+		return fmt.Errorf("ONVIF fault %s %s", fault.Code, strings.TrimSpace(fault.Reason))
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("ONVIF response %s", resp.Status)
+	}
+	if nil == res {
+		return nil
+	}
+	return xml.Unmarshal(env.Body.Content, res)
+}
+
+type getSystemDateAndTimeResponse struct {
+	XMLName  xml.Name `xml:"GetSystemDateAndTimeResponse"`
+	DateTime struct {
+		Date struct {
+			Year  int `xml:"Year"`
+			Month int `xml:"Month"`
+			Day   int `xml:"Day"`
+		} `xml:"Date"`
+		Time struct {
+			Hour   int `xml:"Hour"`
+			Minute int `xml:"Minute"`
+			Second int `xml:"Second"`
+		} `xml:"Time"`
+	} `xml:"SystemDateAndTime>UTCDateTime"`
+}
+
+// syncTime of device without authentication
+func (client *Client) syncTime() error {
+	res := &getSystemDateAndTimeResponse{}
+	if err := client.call(client.XAddr, "<tds:GetSystemDateAndTime/>", res, false); nil != err {
+		return err
+	}
+	date, t := res.DateTime.Date, res.DateTime.Time
+	if date.Year == 0 {
+		return fmt.Errorf("ONVIF device without UTCDateTime")
+	}
+	deviceTime := time.Date(date.Year, time.Month(date.Month), date.Day, t.Hour, t.Minute, t.Second, 0, time.UTC)
+	client.lock.Lock()
+	client.timeOffset = deviceTime.Sub(time.Now())
+	client.lock.Unlock()
+	return nil
+}
+
+type getCapabilitiesResponse struct {
+	XMLName      xml.Name `xml:"GetCapabilitiesResponse"`
+	Capabilities struct {
+		Media struct {
+			XAddr string `xml:"XAddr"`
+		} `xml:"Media"`
+		PTZ struct {
+			XAddr string `xml:"XAddr"`
+		} `xml:"PTZ"`
+		Imaging struct {
+			XAddr string `xml:"XAddr"`
+		} `xml:"Imaging"`
+	} `xml:"Capabilities"`
+}
+
+func (client *Client) getCapabilities() error {
+	res := &getCapabilitiesResponse{}
+	body := "<tds:GetCapabilities><tds:Category>All</tds:Category></tds:GetCapabilities>"
+	if err := client.call(client.XAddr, body, res, true); nil != err {
+		return err
+	}
+	client.mediaAddr = res.Capabilities.Media.XAddr
+	client.ptzAddr = res.Capabilities.PTZ.XAddr
+	client.imagingAddr = res.Capabilities.Imaging.XAddr
+	return nil
+}
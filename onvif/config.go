@@ -0,0 +1,40 @@
+package onvif
+
+import "github.com/go-ini/ini"
+
+// ConfigONVIF of discovery and device requests
+type ConfigONVIF struct {
+	// DiscoveryTimeout in seconds, wait for ProbeMatches of devices
+	DiscoveryTimeout int `ini:"discovery_timeout"`
+	// RequestTimeout in seconds of SOAP request to device
+	RequestTimeout int `ini:"request_timeout"`
+	// Username and Password of devices when API gives none
+	Username string `ini:"username"`
+	Password string `ini:"password"`
+}
+
+type ConfigLog struct {
+	Level string `ini:"level"`
+}
+
+// Config of ONVIF
+type Config struct {
+	ONVIF ConfigONVIF `ini:"onvif"`
+	Log   ConfigLog   `ini:"log"`
+}
+
+var config *Config
+
+func initConfig() error {
+	config = &Config{
+		ONVIF: ConfigONVIF{
+			DiscoveryTimeout: 3,
+			RequestTimeout:   5,
+			Username:         "admin",
+		},
+		Log: ConfigLog{
+			Level: "info",
+		},
+	}
+	return ini.MapTo(config, "./easydarwin.ini")
+}
@@ -0,0 +1,138 @@
+package onvif
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// discoveryAddr of WS-Discovery multicast
+const discoveryAddr = "239.255.255.250:3702"
+
+const probeTemplate = `<?xml version="1.0" encoding="UTF-8"?>
+<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope" xmlns:a="http://schemas.xmlsoap.org/ws/2004/08/addressing" xmlns:d="http://schemas.xmlsoap.org/ws/2005/04/discovery" xmlns:dn="http://www.onvif.org/ver10/network/wsdl">
+<s:Header>
+<a:MessageID>uuid:%s</a:MessageID>
+<a:To s:mustUnderstand="true">urn:schemas-xmlsoap-org:ws:2005:04:discovery</a:To>
+<a:Action s:mustUnderstand="true">http://schemas.xmlsoap.org/ws/2005/04/discovery/Probe</a:Action>
+</s:Header>
+<s:Body>
+<d:Probe><d:Types>dn:NetworkVideoTransmitter</d:Types></d:Probe>
+</s:Body>
+</s:Envelope>`
+
+type probeMatches struct {
+	XMLName   xml.Name `xml:"Envelope"`
+	RelatesTo string   `xml:"Header>RelatesTo"`
+	Matches   []struct {
+		Address string `xml:"EndpointReference>Address"`
+		Types   string `xml:"Types"`
+		Scopes  string `xml:"Scopes"`
+		XAddrs  string `xml:"XAddrs"`
+	} `xml:"Body>ProbeMatches>ProbeMatch"`
+}
+
+// Device discovered by WS-Discovery
+type Device struct {
+	// Address of endpoint reference, unique for device
+	Address string
+	// XAddrs of device service
+	XAddrs   []string
+	Name     string
+	Hardware string
+	Location string
+	// IP of device replied
+	IP string
+}
+
+// XAddr of device service, the one on IP replied is preferred
+func (device *Device) XAddr() string {
+	for _, xaddr := range device.XAddrs {
+		if u, err := url.Parse(xaddr); nil == err && u.Hostname() == device.IP {
+			return xaddr
+		}
+	}
+	if len(device.XAddrs) > 0 {
+		return device.XAddrs[0]
+	}
+	return ""
+}
+
+// scopeValue of onvif://www.onvif.org/<name>/<value>
+func scopeValue(scopes []string, name string) string {
+	prefix := fmt.Sprintf("onvif://www.onvif.org/%s/", name)
+	for _, scope := range scopes {
+		if strings.HasPrefix(scope, prefix) {
+			value, err := url.PathUnescape(scope[len(prefix):])
+			if nil != err {
+				return scope[len(prefix):]
+			}
+			return value
+		}
+	}
+	return ""
+}
+
+// Discover NetworkVideoTransmitter devices by WS-Discovery Probe on local network,
+// timeout 0 for discovery_timeout of config
+func Discover(timeout time.Duration) ([]*Device, error) {
+	if timeout <= 0 {
+		timeout = time.Duration(config.ONVIF.DiscoveryTimeout) * time.Second
+	}
+	addr, err := net.ResolveUDPAddr("udp4", discoveryAddr)
+	if nil != err {
+		return nil, err
+	}
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{})
+	if nil != err {
+		return nil, err
+	}
+	defer conn.Close()
+
+	messageID := uuid.New().String()
+	if _, err := conn.WriteToUDP([]byte(fmt.Sprintf(probeTemplate, messageID)), addr); nil != err {
+		return nil, err
+	}
+	conn.SetReadDeadline(time.Now().Add(timeout))
+
+	devices := make([]*Device, 0)
+	found := make(map[string]bool)
+	buf := make([]byte, 65536)
+	for {
+		n, from, err := conn.ReadFromUDP(buf)
+		if nil != err {
+			// deadline
+			break
+		}
+		matches := &probeMatches{}
+		if err := xml.Unmarshal(buf[:n], matches); nil != err {
+			log.WithError(err).WithField("addr", from).Debug("decode ProbeMatches")
+			continue
+		}
+		if !strings.HasSuffix(matches.RelatesTo, messageID) {
+			continue
+		}
+		for _, match := range matches.Matches {
+			if found[match.Address] {
+				continue
+			}
+			found[match.Address] = true
+			scopes := strings.Fields(match.Scopes)
+			devices = append(devices, &Device{
+				Address:  match.Address,
+				XAddrs:   strings.Fields(match.XAddrs),
+				Name:     scopeValue(scopes, "name"),
+				Hardware: scopeValue(scopes, "hardware"),
+				Location: scopeValue(scopes, "location"),
+				IP:       from.IP.String(),
+			})
+		}
+	}
+	log.Infof("discover %d devices", len(devices))
+	return devices, nil
+}
@@ -0,0 +1,9 @@
+package onvif
+
+import "errors"
+
+// Common errors
+var (
+	ErrorProfileNotFound = errors.New("Profile not found")
+	ErrorServiceNotFound = errors.New("Service not supported by device")
+)
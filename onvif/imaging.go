@@ -0,0 +1,96 @@
+package onvif
+
+import (
+	"encoding/xml"
+	"fmt"
+)
+
+// ImagingSettings of video source, nil fields are not set, values are in range of device, usually 0-100
+type ImagingSettings struct {
+	Brightness      *float64
+	ColorSaturation *float64
+	Contrast        *float64
+	Sharpness       *float64
+}
+
+type getImagingSettingsResponse struct {
+	XMLName  xml.Name `xml:"GetImagingSettingsResponse"`
+	Settings struct {
+		Brightness      *float64 `xml:"Brightness"`
+		ColorSaturation *float64 `xml:"ColorSaturation"`
+		Contrast        *float64 `xml:"Contrast"`
+		Sharpness       *float64 `xml:"Sharpness"`
+	} `xml:"ImagingSettings"`
+}
+
+func (client *Client) imaging() (string, error) {
+	if client.imagingAddr == "" {
+		return "", ErrorServiceNotFound
+	}
+	return client.imagingAddr, nil
+}
+
+// VideoSource token of profile for imaging
+func (client *Client) VideoSource(profileToken string) (string, error) {
+	client.lock.RLock()
+	source, ok := client.videoSources[profileToken]
+	client.lock.RUnlock()
+	if !ok {
+		if _, err := client.GetProfiles(); nil != err {
+			return "", err
+		}
+		client.lock.RLock()
+		source, ok = client.videoSources[profileToken]
+		client.lock.RUnlock()
+	}
+	if !ok || source == "" {
+		return "", ErrorProfileNotFound
+	}
+	return source, nil
+}
+
+// GetImagingSettings of video source
+func (client *Client) GetImagingSettings(videoSourceToken string) (*ImagingSettings, error) {
+	addr, err := client.imaging()
+	if nil != err {
+		return nil, err
+	}
+	body := fmt.Sprintf(`<timg:GetImagingSettings><timg:VideoSourceToken>%s</timg:VideoSourceToken></timg:GetImagingSettings>`,
+		escape(videoSourceToken))
+	res := &getImagingSettingsResponse{}
+	if err := client.call(addr, body, res, true); nil != err {
+		return nil, err
+	}
+	return &ImagingSettings{
+		Brightness:      res.Settings.Brightness,
+		ColorSaturation: res.Settings.ColorSaturation,
+		Contrast:        res.Settings.Contrast,
+		Sharpness:       res.Settings.Sharpness,
+	}, nil
+}
+
+// SetImagingSettings of video source, only fields not nil are changed
+func (client *Client) SetImagingSettings(videoSourceToken string, settings *ImagingSettings) error {
+	addr, err := client.imaging()
+	if nil != err {
+		return err
+	}
+	// elements in order of schema
+	elements := ""
+	for _, field := range []struct {
+		name  string
+		value *float64
+	}{
+		{"Brightness", settings.Brightness},
+		{"ColorSaturation", settings.ColorSaturation},
+		{"Contrast", settings.Contrast},
+		{"Sharpness", settings.Sharpness},
+	} {
+		if nil != field.value {
+			elements += fmt.Sprintf("<tt:%s>%g</tt:%s>", field.name, *field.value, field.name)
+		}
+	}
+	body := fmt.Sprintf(`<timg:SetImagingSettings><timg:VideoSourceToken>%s</timg:VideoSourceToken><timg:ImagingSettings>%s</timg:ImagingSettings></timg:SetImagingSettings>`,
+		escape(videoSourceToken), elements)
+	return client.call(addr, body, nil, true)
+}
@@ -0,0 +1,15 @@
+package onvif
+
+func init() {
+	var err error
+
+	err = initConfig()
+	if nil != err {
+		panic(err)
+	}
+
+	err = initLog()
+	if nil != err {
+		panic(err)
+	}
+}
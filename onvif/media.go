@@ -0,0 +1,132 @@
+package onvif
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/url"
+)
+
+// Profile of media service
+type Profile struct {
+	Token     string
+	Name      string
+	Encoding  string
+	Width     int
+	Height    int
+	FrameRate int
+	Bitrate   int
+	// VideoSource token of profile
+	VideoSource string
+	// PTZ configured in profile
+	PTZ bool
+}
+
+type getProfilesResponse struct {
+	XMLName  xml.Name `xml:"GetProfilesResponse"`
+	Profiles []struct {
+		Token       string `xml:"token,attr"`
+		Name        string `xml:"Name"`
+		VideoSource struct {
+			SourceToken string `xml:"SourceToken"`
+		} `xml:"VideoSourceConfiguration"`
+		Video *struct {
+			Encoding   string `xml:"Encoding"`
+			Resolution struct {
+				Width  int `xml:"Width"`
+				Height int `xml:"Height"`
+			} `xml:"Resolution"`
+			RateControl struct {
+				FrameRateLimit int `xml:"FrameRateLimit"`
+				BitrateLimit   int `xml:"BitrateLimit"`
+			} `xml:"RateControl"`
+		} `xml:"VideoEncoderConfiguration"`
+		PTZ *struct {
+			Token string `xml:"token,attr"`
+		} `xml:"PTZConfiguration"`
+	} `xml:"Profiles"`
+}
+
+type getStreamURIResponse struct {
+	XMLName xml.Name `xml:"GetStreamUriResponse"`
+	URI     string   `xml:"MediaUri>Uri"`
+}
+
+func (client *Client) media() (string, error) {
+	if client.mediaAddr == "" {
+		return "", ErrorServiceNotFound
+	}
+	return client.mediaAddr, nil
+}
+
+// GetProfiles of media service
+func (client *Client) GetProfiles() ([]*Profile, error) {
+	addr, err := client.media()
+	if nil != err {
+		return nil, err
+	}
+	res := &getProfilesResponse{}
+	if err := client.call(addr, "<trt:GetProfiles/>", res, true); nil != err {
+		return nil, err
+	}
+
+	profiles := make([]*Profile, 0, len(res.Profiles))
+	for _, p := range res.Profiles {
+		profile := &Profile{
+			Token:       p.Token,
+			Name:        p.Name,
+			VideoSource: p.VideoSource.SourceToken,
+			PTZ:         nil != p.PTZ,
+		}
+		if nil != p.Video {
+			profile.Encoding = p.Video.Encoding
+			profile.Width = p.Video.Resolution.Width
+			profile.Height = p.Video.Resolution.Height
+			profile.FrameRate = p.Video.RateControl.FrameRateLimit
+			profile.Bitrate = p.Video.RateControl.BitrateLimit
+		}
+		profiles = append(profiles, profile)
+	}
+
+	client.lock.Lock()
+	for _, profile := range profiles {
+		client.videoSources[profile.Token] = profile.VideoSource
+	}
+	client.lock.Unlock()
+	return profiles, nil
+}
+
+// GetStreamURI of profile for RTSP over unicast
+func (client *Client) GetStreamURI(profileToken string) (string, error) {
+	addr, err := client.media()
+	if nil != err {
+		return "", err
+	}
+	body := fmt.Sprintf(`<trt:GetStreamUri>
+<trt:StreamSetup><tt:Stream>RTP-Unicast</tt:Stream><tt:Transport><tt:Protocol>RTSP</tt:Protocol></tt:Transport></trt:StreamSetup>
+<trt:ProfileToken>%s</trt:ProfileToken>
+</trt:GetStreamUri>`, escape(profileToken))
+	res := &getStreamURIResponse{}
+	if err := client.call(addr, body, res, true); nil != err {
+		return "", err
+	}
+	if res.URI == "" {
+		return "", ErrorProfileNotFound
+	}
+	return res.URI, nil
+}
+
+// StreamURL of profile with credentials of client, for pulling without another authorization setting
+func (client *Client) StreamURL(profileToken string) (string, error) {
+	uri, err := client.GetStreamURI(profileToken)
+	if nil != err {
+		return "", err
+	}
+	u, err := url.Parse(uri)
+	if nil != err {
+		return "", err
+	}
+	if nil == u.User && client.Username != "" {
+		u.User = url.UserPassword(client.Username, client.Password)
+	}
+	return u.String(), nil
+}
@@ -0,0 +1,82 @@
+package onvif
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Velocity of ContinuousMove, each in -1 to 1
+type Velocity struct {
+	X    float64
+	Y    float64
+	Zoom float64
+}
+
+func (client *Client) ptz() (string, error) {
+	if client.ptzAddr == "" {
+		return "", ErrorServiceNotFound
+	}
+	return client.ptzAddr, nil
+}
+
+// ContinuousMove of profile until Stop
+func (client *Client) ContinuousMove(profileToken string, velocity Velocity) error {
+	addr, err := client.ptz()
+	if nil != err {
+		return err
+	}
+	vectors := make([]string, 0, 2)
+	if velocity.X != 0 || velocity.Y != 0 {
+		vectors = append(vectors, fmt.Sprintf(`<tt:PanTilt x="%.3f" y="%.3f"/>`, velocity.X, velocity.Y))
+	}
+	if velocity.Zoom != 0 {
+		vectors = append(vectors, fmt.Sprintf(`<tt:Zoom x="%.3f"/>`, velocity.Zoom))
+	}
+	body := fmt.Sprintf(`<tptz:ContinuousMove><tptz:ProfileToken>%s</tptz:ProfileToken><tptz:Velocity>%s</tptz:Velocity></tptz:ContinuousMove>`,
+		escape(profileToken), strings.Join(vectors, ""))
+	return client.call(addr, body, nil, true)
+}
+
+// Stop pan, tilt and zoom of profile
+func (client *Client) Stop(profileToken string) error {
+	addr, err := client.ptz()
+	if nil != err {
+		return err
+	}
+	body := fmt.Sprintf(`<tptz:Stop><tptz:ProfileToken>%s</tptz:ProfileToken><tptz:PanTilt>true</tptz:PanTilt><tptz:Zoom>true</tptz:Zoom></tptz:Stop>`,
+		escape(profileToken))
+	return client.call(addr, body, nil, true)
+}
+
+// GotoPreset of profile
+func (client *Client) GotoPreset(profileToken string, presetToken string) error {
+	addr, err := client.ptz()
+	if nil != err {
+		return err
+	}
+	body := fmt.Sprintf(`<tptz:GotoPreset><tptz:ProfileToken>%s</tptz:ProfileToken><tptz:PresetToken>%s</tptz:PresetToken></tptz:GotoPreset>`,
+		escape(profileToken), escape(presetToken))
+	return client.call(addr, body, nil, true)
+}
+
+// SetPreset of profile at current position, the preset of token is overwritten
+func (client *Client) SetPreset(profileToken string, presetToken string) error {
+	addr, err := client.ptz()
+	if nil != err {
+		return err
+	}
+	body := fmt.Sprintf(`<tptz:SetPreset><tptz:ProfileToken>%s</tptz:ProfileToken><tptz:PresetToken>%s</tptz:PresetToken></tptz:SetPreset>`,
+		escape(profileToken), escape(presetToken))
+	return client.call(addr, body, nil, true)
+}
+
+// RemovePreset of profile
+func (client *Client) RemovePreset(profileToken string, presetToken string) error {
+	addr, err := client.ptz()
+	if nil != err {
+		return err
+	}
+	body := fmt.Sprintf(`<tptz:RemovePreset><tptz:ProfileToken>%s</tptz:ProfileToken><tptz:PresetToken>%s</tptz:PresetToken></tptz:RemovePreset>`,
+		escape(profileToken), escape(presetToken))
+	return client.call(addr, body, nil, true)
+}
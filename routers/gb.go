@@ -0,0 +1,891 @@
+package routers
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/EasyDarwin/EasyDarwin/gb28181"
+	"github.com/EasyDarwin/EasyDarwin/models"
+	"github.com/EasyDarwin/EasyDarwin/utils"
+	"github.com/gin-gonic/gin"
+)
+
+/**
+ * @apiDefine gb GB28181
+ */
+
+/**
+ * @api {get} /api/v1/gb/devices 获取GB28181设备列表
+ * @apiGroup gb
+ * @apiName GBDevices
+ * @apiParam {Number} [start] 分页开始,从零开始
+ * @apiParam {Number} [limit] 分页大小
+ * @apiParam {String} [sort] 排序字段
+ * @apiParam {String=ascending,descending} [order] 排序顺序
+ * @apiParam {String} [q] 查询参数, 匹配设备ID或名称
+ * @apiParam {Boolean} [online] 只查询在线或离线设备
+ * @apiSuccess (200) {Number} total 总数
+ * @apiSuccess (200) {Array} rows 设备列表
+ * @apiSuccess (200) {String} rows.id 设备ID
+ * @apiSuccess (200) {String} rows.name 设备名称
+ * @apiSuccess (200) {String} rows.transport 信令传输模式
+ * @apiSuccess (200) {String} rows.host 设备地址
+ * @apiSuccess (200) {Number} rows.port 设备端口
+ * @apiSuccess (200) {Boolean} rows.online 是否在线
+ * @apiSuccess (200) {String} rows.registerAt 注册时间
+ * @apiSuccess (200) {String} rows.keepaliveAt 最后心跳时间
+ * @apiSuccess (200) {Number} rows.expires 注册有效期, 秒
+ * @apiSuccess (200) {String} rows.manufacturer 厂商, 查询设备信息后才有
+ * @apiSuccess (200) {String} rows.model 型号
+ * @apiSuccess (200) {String} rows.firmware 固件版本
+ */
+func (h *APIHandler) GBDevices(c *gin.Context) {
+	type Form struct {
+		PageRequest
+		Online string `form:"online"`
+	}
+	form := &Form{PageRequest: *NewPageRequest()}
+	if err := c.Bind(form); err != nil {
+		return
+	}
+
+	devices := make([]interface{}, 0)
+	for _, device := range gb28181.GetServer().GetDevices() {
+		info := device.Info()
+		if form.Q != "" &&
+			!strings.Contains(info.ID, form.Q) &&
+			!strings.Contains(strings.ToLower(info.Name), strings.ToLower(form.Q)) {
+			continue
+		}
+		if form.Online != "" && fmt.Sprintf("%v", info.Online) != strings.ToLower(form.Online) {
+			continue
+		}
+		devices = append(devices, map[string]interface{}{
+			"id":           info.ID,
+			"name":         info.Name,
+			"transport":    info.Transport,
+			"host":         info.Host,
+			"port":         info.Port,
+			"online":       info.Online,
+			"registerAt":   utils.DateTime(time.Unix(info.RegisterAt, 0)),
+			"keepaliveAt":  utils.DateTime(time.Unix(info.KeepaliveAt, 0)),
+			"expires":      info.Expires,
+			"manufacturer": info.GetInfo().GetManufacturer(),
+			"model":        info.GetInfo().GetModel(),
+			"firmware":     info.GetInfo().GetFirmware(),
+		})
+	}
+	pr := NewPageResponse(devices)
+	if form.Sort != "" {
+		pr.Sort(form.Sort, form.Order)
+	}
+	pr.Slice(form.Start, form.Limit)
+	c.IndentedJSON(200, pr)
+}
+
+/**
+ * @api {get} /api/v1/gb/devices/remove 删除GB28181设备
+ * @apiGroup gb
+ * @apiName GBDeviceRemove
+ * @apiParam {String} id 设备ID
+ * @apiUse simpleSuccess
+ */
+func (h *APIHandler) GBDeviceRemove(c *gin.Context) {
+	type Form struct {
+		ID string `form:"id" binding:"required"`
+	}
+	var form Form
+	if err := c.Bind(&form); err != nil {
+		return
+	}
+
+	if err := gb28181.GetServer().RemoveDevice(form.ID); err != nil {
+		c.AbortWithStatusJSON(http.StatusNotFound, fmt.Sprintf("Device[%s] remove error: %v", form.ID, err))
+		return
+	}
+	c.IndentedJSON(200, "OK")
+}
+
+func (h *APIHandler) gbDevice(c *gin.Context, deviceID string) *gb28181.Device {
+	device := gb28181.GetServer().GetDevice(deviceID)
+	if device == nil {
+		c.AbortWithStatusJSON(http.StatusNotFound, fmt.Sprintf("Device[%s] not found", deviceID))
+	}
+	return device
+}
+
+/**
+ * @api {get} /api/v1/gb/devices/info 查询GB28181设备信息
+ * @apiDescription 向设备发送DeviceInfo查询, 结果保存在设备记录中
+ * @apiGroup gb
+ * @apiName GBDeviceInfo
+ * @apiParam {String} device 设备ID
+ * @apiSuccess (200) {String} name 设备名称
+ * @apiSuccess (200) {String} manufacturer 厂商
+ * @apiSuccess (200) {String} model 型号
+ * @apiSuccess (200) {String} firmware 固件版本
+ * @apiSuccess (200) {Number} channel 通道数
+ * @apiSuccess (200) {String} updateAt 更新时间
+ */
+func (h *APIHandler) GBDeviceInfo(c *gin.Context) {
+	type Form struct {
+		Device string `form:"device" binding:"required"`
+	}
+	var form Form
+	if err := c.Bind(&form); err != nil {
+		return
+	}
+
+	device := h.gbDevice(c, form.Device)
+	if device == nil {
+		return
+	}
+	info, err := gb28181.GetServer().QueryDeviceInfo(device)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, fmt.Sprintf("Device[%s] info error: %v", form.Device, err))
+		return
+	}
+	c.IndentedJSON(200, gin.H{
+		"name":         info.Name,
+		"manufacturer": info.Manufacturer,
+		"model":        info.Model,
+		"firmware":     info.Firmware,
+		"channel":      info.Channel,
+		"updateAt":     utils.DateTime(time.Unix(info.UpdateAt, 0)),
+	})
+}
+
+/**
+ * @api {get} /api/v1/gb/devices/status 查询GB28181设备状态
+ * @apiDescription 向设备发送DeviceStatus查询, 结果保存在设备记录中
+ * @apiGroup gb
+ * @apiName GBDeviceStatus
+ * @apiParam {String} device 设备ID
+ * @apiSuccess (200) {Boolean} online 是否在线
+ * @apiSuccess (200) {String} status 是否正常工作, OK或ERROR
+ * @apiSuccess (200) {String} reason 不正常工作原因
+ * @apiSuccess (200) {Boolean} encode 是否编码
+ * @apiSuccess (200) {Boolean} record 是否录像
+ * @apiSuccess (200) {String} deviceTime 设备时间
+ * @apiSuccess (200) {Array} alarmStatus 报警设备状态列表
+ * @apiSuccess (200) {String} alarmStatus.channel 报警设备ID
+ * @apiSuccess (200) {String} alarmStatus.dutyStatus 布防状态, ONDUTY, OFFDUTY或ALARM
+ * @apiSuccess (200) {String} updateAt 更新时间
+ */
+func (h *APIHandler) GBDeviceStatus(c *gin.Context) {
+	type Form struct {
+		Device string `form:"device" binding:"required"`
+	}
+	var form Form
+	if err := c.Bind(&form); err != nil {
+		return
+	}
+
+	device := h.gbDevice(c, form.Device)
+	if device == nil {
+		return
+	}
+	status, err := gb28181.GetServer().QueryDeviceStatus(device)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, fmt.Sprintf("Device[%s] status error: %v", form.Device, err))
+		return
+	}
+	alarmStatus := make([]interface{}, 0)
+	for _, item := range status.AlarmStatus {
+		alarmStatus = append(alarmStatus, gin.H{
+			"channel":    item.ChannelID,
+			"dutyStatus": item.DutyStatus,
+		})
+	}
+	c.IndentedJSON(200, gin.H{
+		"online":      status.Online,
+		"status":      status.Status,
+		"reason":      status.Reason,
+		"encode":      status.Encode,
+		"record":      status.Record,
+		"deviceTime":  status.DeviceTime,
+		"alarmStatus": alarmStatus,
+		"updateAt":    utils.DateTime(time.Unix(status.UpdateAt, 0)),
+	})
+}
+
+/**
+ * @api {get} /api/v1/gb/devices/config 查询或修改GB28181设备基本参数
+ * @apiDescription 不带修改参数时通过ConfigDownload查询, 否则发送DeviceConfig修改, 结果保存在设备记录中
+ * @apiGroup gb
+ * @apiName GBDeviceConfig
+ * @apiParam {String} device 设备ID
+ * @apiParam {String} [name] 设备名称
+ * @apiParam {Number} [expiration] 注册过期时间, 秒
+ * @apiParam {Number} [heartBeatInterval] 心跳间隔, 秒
+ * @apiParam {Number} [heartBeatCount] 心跳超时次数
+ * @apiSuccess (200) {String} name 设备名称
+ * @apiSuccess (200) {Number} expiration 注册过期时间, 秒
+ * @apiSuccess (200) {Number} heartBeatInterval 心跳间隔, 秒
+ * @apiSuccess (200) {Number} heartBeatCount 心跳超时次数
+ * @apiSuccess (200) {String} updateAt 更新时间
+ */
+func (h *APIHandler) GBDeviceConfig(c *gin.Context) {
+	type Form struct {
+		Device            string `form:"device" binding:"required"`
+		Name              string `form:"name"`
+		Expiration        int    `form:"expiration"`
+		HeartBeatInterval int    `form:"heartBeatInterval"`
+		HeartBeatCount    int    `form:"heartBeatCount"`
+	}
+	var form Form
+	if err := c.Bind(&form); err != nil {
+		return
+	}
+
+	device := h.gbDevice(c, form.Device)
+	if device == nil {
+		return
+	}
+	param := &gb28181.BasicParam{
+		Name:              form.Name,
+		Expiration:        form.Expiration,
+		HeartBeatInterval: form.HeartBeatInterval,
+		HeartBeatCount:    form.HeartBeatCount,
+	}
+	server := gb28181.GetServer()
+	var deviceConfig *models.DeviceConfig
+	var err error
+	if *param == (gb28181.BasicParam{}) {
+		deviceConfig, err = server.QueryDeviceConfig(device)
+	} else {
+		deviceConfig, err = server.SetDeviceConfig(device, param)
+	}
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, fmt.Sprintf("Device[%s] config error: %v", form.Device, err))
+		return
+	}
+	c.IndentedJSON(200, gin.H{
+		"name":              deviceConfig.Name,
+		"expiration":        deviceConfig.Expiration,
+		"heartBeatInterval": deviceConfig.HeartBeatInterval,
+		"heartBeatCount":    deviceConfig.HeartBeatCount,
+		"updateAt":          utils.DateTime(time.Unix(deviceConfig.UpdateAt, 0)),
+	})
+}
+
+/**
+ * @api {get} /api/v1/gb/channels 获取GB28181设备通道列表
+ * @apiGroup gb
+ * @apiName GBChannels
+ * @apiParam {String} device 设备ID
+ * @apiParam {Number} [start] 分页开始,从零开始
+ * @apiParam {Number} [limit] 分页大小
+ * @apiParam {String} [sort] 排序字段
+ * @apiParam {String=ascending,descending} [order] 排序顺序
+ * @apiParam {String} [q] 查询参数, 匹配通道ID或名称
+ * @apiParam {String} [parentID] 父节点ID, 查询目录树的子节点
+ * @apiParam {String} [civilCode] 行政区划
+ * @apiParam {String} [status] 通道状态, ON或OFF
+ * @apiSuccess (200) {Number} total 总数
+ * @apiSuccess (200) {Array} rows 通道列表
+ * @apiSuccess (200) {String} rows.id 通道ID
+ * @apiSuccess (200) {String} rows.deviceID 设备ID
+ * @apiSuccess (200) {String} rows.name 通道名称
+ * @apiSuccess (200) {String} rows.manufacturer 厂商
+ * @apiSuccess (200) {String} rows.model 型号
+ * @apiSuccess (200) {String} rows.owner 归属
+ * @apiSuccess (200) {String} rows.civilCode 行政区划
+ * @apiSuccess (200) {String} rows.address 安装地址
+ * @apiSuccess (200) {Number} rows.parental 是否有子节点
+ * @apiSuccess (200) {String} rows.parentID 父节点ID
+ * @apiSuccess (200) {Number} rows.secrecy 保密属性
+ * @apiSuccess (200) {String} rows.ipAddress 通道IP地址
+ * @apiSuccess (200) {Number} rows.port 通道端口
+ * @apiSuccess (200) {String} rows.status 通道状态
+ * @apiSuccess (200) {Number} rows.longitude 经度
+ * @apiSuccess (200) {Number} rows.latitude 纬度
+ * @apiSuccess (200) {Number} rows.ptzType 摄像机类型, 1球机 2半球 3固定枪机 4遥控枪机
+ * @apiSuccess (200) {String} rows.updateAt 更新时间
+ */
+func (h *APIHandler) GBChannels(c *gin.Context) {
+	type Form struct {
+		PageRequest
+		Device    string `form:"device" binding:"required"`
+		ParentID  string `form:"parentID"`
+		CivilCode string `form:"civilCode"`
+		Status    string `form:"status"`
+	}
+	form := &Form{PageRequest: *NewPageRequest()}
+	if err := c.Bind(form); err != nil {
+		return
+	}
+
+	all, err := models.GetDeviceChannels(form.Device)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, fmt.Sprintf("Device[%s] channels error: %v", form.Device, err))
+		return
+	}
+	channels := make([]interface{}, 0)
+	for _, channel := range all {
+		if form.Q != "" &&
+			!strings.Contains(channel.ID, form.Q) &&
+			!strings.Contains(strings.ToLower(channel.Name), strings.ToLower(form.Q)) {
+			continue
+		}
+		if form.ParentID != "" && channel.ParentID != form.ParentID {
+			continue
+		}
+		if form.CivilCode != "" && channel.CivilCode != form.CivilCode {
+			continue
+		}
+		if form.Status != "" && !strings.EqualFold(channel.Status, form.Status) {
+			continue
+		}
+		channels = append(channels, map[string]interface{}{
+			"id":           channel.ID,
+			"deviceID":     channel.DeviceID,
+			"name":         channel.Name,
+			"manufacturer": channel.Manufacturer,
+			"model":        channel.Model,
+			"owner":        channel.Owner,
+			"civilCode":    channel.CivilCode,
+			"address":      channel.Address,
+			"parental":     channel.Parental,
+			"parentID":     channel.ParentID,
+			"secrecy":      channel.Secrecy,
+			"ipAddress":    channel.IPAddress,
+			"port":         channel.Port,
+			"status":       channel.Status,
+			"longitude":    channel.Longitude,
+			"latitude":     channel.Latitude,
+			"ptzType":      channel.PTZType,
+			"updateAt":     utils.DateTime(time.Unix(channel.UpdateAt, 0)),
+		})
+	}
+	pr := NewPageResponse(channels)
+	if form.Sort != "" {
+		pr.Sort(form.Sort, form.Order)
+	}
+	pr.Slice(form.Start, form.Limit)
+	c.IndentedJSON(200, pr)
+}
+
+/**
+ * @api {get} /api/v1/gb/catalog 查询GB28181设备目录
+ * @apiDescription 向设备发送目录查询, 等待全部响应后更新通道列表
+ * @apiGroup gb
+ * @apiName GBCatalog
+ * @apiParam {String} device 设备ID
+ * @apiSuccess (200) {Number} total 通道总数
+ */
+func (h *APIHandler) GBCatalog(c *gin.Context) {
+	type Form struct {
+		Device string `form:"device" binding:"required"`
+	}
+	var form Form
+	if err := c.Bind(&form); err != nil {
+		return
+	}
+
+	server := gb28181.GetServer()
+	device := server.GetDevice(form.Device)
+	if device == nil {
+		c.AbortWithStatusJSON(http.StatusNotFound, fmt.Sprintf("Device[%s] not found", form.Device))
+		return
+	}
+	channels, err := server.QueryCatalog(device)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, fmt.Sprintf("Device[%s] catalog error: %v", form.Device, err))
+		return
+	}
+	c.IndentedJSON(200, gin.H{
+		"total": len(channels),
+	})
+}
+
+/**
+ * @api {get} /api/v1/gb/ptz GB28181云台控制
+ * @apiDescription 向通道发送PTZCmd, 方向与变倍命令需要发送stop停止
+ * @apiGroup gb
+ * @apiName GBPTZ
+ * @apiParam {String} device 设备ID
+ * @apiParam {String} channel 通道ID
+ * @apiParam {String=stop,left,right,up,down,upleft,upright,downleft,downright,zoomin,zoomout,focusnear,focusfar,irisopen,irisclose,presetset,presetgoto,presetremove,cruiseadd,cruiseremove,cruisespeed,cruisedwell,cruisestart,cruisestop} command 控制命令
+ * @apiParam {Number} [speed=128] 速度, 0-255, 变倍速度取高4位
+ * @apiParam {Number} [preset] 预置位号, 1-255
+ * @apiParam {Number} [group] 巡航组号, 0-255
+ * @apiParam {Number} [value] 巡航速度或停留时间(秒)
+ * @apiSuccess (200) {String} result 设备响应结果
+ */
+func (h *APIHandler) GBPTZ(c *gin.Context) {
+	type Form struct {
+		Device  string `form:"device" binding:"required"`
+		Channel string `form:"channel" binding:"required"`
+		Command string `form:"command" binding:"required"`
+		Speed   int    `form:"speed"`
+		Preset  int    `form:"preset"`
+		Group   int    `form:"group"`
+		Value   int    `form:"value"`
+	}
+	form := &Form{Speed: 128}
+	if err := c.Bind(form); err != nil {
+		return
+	}
+
+	cmd, err := gb28181.ParsePTZCmd(form.Command, gb28181.PTZParam{
+		Speed:  form.Speed,
+		Preset: form.Preset,
+		Group:  form.Group,
+		Value:  form.Value,
+	})
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, err.Error())
+		return
+	}
+	h.gbControl(c, form.Device, gb28181.NewPTZControl(form.Channel, cmd))
+}
+
+/**
+ * @api {get} /api/v1/gb/control GB28181设备控制
+ * @apiDescription 强制关键帧, 远程启动, 录像控制, 布防撤防, 报警复位
+ * @apiGroup gb
+ * @apiName GBControl
+ * @apiParam {String} device 设备ID
+ * @apiParam {String} [channel] 通道ID, 默认为设备ID
+ * @apiParam {String=iframe,teleboot,record,stoprecord,setguard,resetguard,resetalarm} command 控制命令
+ * @apiSuccess (200) {String} result 设备响应结果
+ */
+func (h *APIHandler) GBControl(c *gin.Context) {
+	type Form struct {
+		Device  string `form:"device" binding:"required"`
+		Channel string `form:"channel"`
+		Command string `form:"command" binding:"required"`
+	}
+	var form Form
+	if err := c.Bind(&form); err != nil {
+		return
+	}
+
+	control, err := gb28181.NewControl(form.Command)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, err.Error())
+		return
+	}
+	control.DeviceID = form.Channel
+	h.gbControl(c, form.Device, control)
+}
+
+func (h *APIHandler) gbControl(c *gin.Context, deviceID string, control *gb28181.Control) {
+	server := gb28181.GetServer()
+	device := server.GetDevice(deviceID)
+	if device == nil {
+		c.AbortWithStatusJSON(http.StatusNotFound, fmt.Sprintf("Device[%s] not found", deviceID))
+		return
+	}
+	result, err := server.DeviceControl(device, control)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, fmt.Sprintf("Device[%s] control error: %v", deviceID, err))
+		return
+	}
+	c.IndentedJSON(200, gin.H{
+		"result": result,
+	})
+}
+
+// GBRecordsResponse of GBRecords, same as QueryRecordResponse
+type GBRecordsResponse struct {
+	Code int                       `form:"code" json:"code"`
+	Msg  string                    `form:"msg" json:"msg"`
+	Data []*gb28181.RecordTimeline `form:"data" json:"data"`
+}
+
+/**
+ * @api {get} /api/v1/gb/records 查询GB28181设备录像
+ * @apiDescription 向设备发送录像查询, 合并后返回每个通道的录像时间轴
+ * @apiGroup gb
+ * @apiName GBRecords
+ * @apiParam {String} device 设备ID
+ * @apiParam {String} channel 通道ID
+ * @apiParam {Number} start 开始时间, unix秒
+ * @apiParam {Number} end 结束时间, unix秒
+ * @apiParam {String=all,time,alarm,manual} [type=all] 录像类型
+ * @apiSuccess (200) {Number} code 0为成功
+ * @apiSuccess (200) {String} msg 错误信息
+ * @apiSuccess (200) {Array} data 通道录像时间轴
+ * @apiSuccess (200) {String} data.channelID 通道ID
+ * @apiSuccess (200) {Array} data.segments 录像时间段
+ * @apiSuccess (200) {Number} data.segments.start 开始时间, unix秒
+ * @apiSuccess (200) {Number} data.segments.end 结束时间, unix秒
+ */
+func (h *APIHandler) GBRecords(c *gin.Context) {
+	type Form struct {
+		Device  string `form:"device" binding:"required"`
+		Channel string `form:"channel" binding:"required"`
+		Start   int64  `form:"start" binding:"required"`
+		End     int64  `form:"end" binding:"required"`
+		Type    string `form:"type"`
+	}
+	var form Form
+	if err := c.ShouldBind(&form); err != nil || form.End <= form.Start {
+		c.IndentedJSON(200, &GBRecordsResponse{
+			Code: 400,
+			Msg:  "Bad request",
+		})
+		return
+	}
+
+	server := gb28181.GetServer()
+	device := server.GetDevice(form.Device)
+	if device == nil {
+		c.IndentedJSON(200, &GBRecordsResponse{
+			Code: 404,
+			Msg:  fmt.Sprintf("Device[%s] not found", form.Device),
+		})
+		return
+	}
+	records, err := server.QueryRecordInfo(device, form.Channel, form.Start, form.End, form.Type)
+	if err != nil {
+		c.IndentedJSON(200, &GBRecordsResponse{
+			Code: 400,
+			Msg:  err.Error(),
+		})
+		return
+	}
+
+	c.IndentedJSON(200, &GBRecordsResponse{
+		Code: 0,
+		Msg:  "OK",
+		Data: gb28181.MergeRecords(records),
+	})
+}
+
+/**
+ * @api {get} /api/v1/gb/alarms 获取GB28181报警记录
+ * @apiGroup gb
+ * @apiName GBAlarms
+ * @apiParam {Number} [start] 分页开始,从零开始
+ * @apiParam {Number} [limit] 分页大小
+ * @apiParam {String} [sort] 排序字段
+ * @apiParam {String=ascending,descending} [order] 排序顺序
+ * @apiParam {String} [device] 设备ID
+ * @apiParam {String} [channel] 通道ID
+ * @apiParam {Number} [begin] 开始时间, unix秒, 默认为24小时前
+ * @apiParam {Number} [end] 结束时间, unix秒, 默认为当前时间
+ * @apiParam {Number} [type] 报警类型
+ * @apiParam {Boolean} [acked] 只查询已确认或未确认的报警
+ * @apiSuccess (200) {Number} total 总数
+ * @apiSuccess (200) {Array} rows 报警列表
+ * @apiSuccess (200) {String} rows.id 报警ID
+ * @apiSuccess (200) {String} rows.deviceID 设备ID
+ * @apiSuccess (200) {String} rows.channelID 通道ID
+ * @apiSuccess (200) {Number} rows.priority 报警级别, 1一级 2二级 3三级 4四级
+ * @apiSuccess (200) {Number} rows.method 报警方式, 1电话 2设备 3短信 4GPS 5视频 6设备故障 7其他
+ * @apiSuccess (200) {Number} rows.type 报警类型
+ * @apiSuccess (200) {String} rows.time 报警时间
+ * @apiSuccess (200) {String} rows.description 报警描述
+ * @apiSuccess (200) {Number} rows.longitude 经度
+ * @apiSuccess (200) {Number} rows.latitude 纬度
+ * @apiSuccess (200) {Boolean} rows.acked 是否已确认
+ * @apiSuccess (200) {String} rows.ackAt 确认时间
+ */
+func (h *APIHandler) GBAlarms(c *gin.Context) {
+	type Form struct {
+		PageRequest
+		Device  string `form:"device"`
+		Channel string `form:"channel"`
+		Begin   int64  `form:"begin"`
+		End     int64  `form:"end"`
+		Type    string `form:"type"`
+		Acked   string `form:"acked"`
+	}
+	now := time.Now()
+	form := &Form{
+		PageRequest: *NewPageRequest(),
+		Begin:       now.Add(-24 * time.Hour).Unix(),
+		End:         now.Unix(),
+	}
+	if err := c.Bind(form); err != nil {
+		return
+	}
+
+	all, err := models.GetAlarmsByTime(form.Begin, form.End)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, fmt.Sprintf("Alarms error: %v", err))
+		return
+	}
+	alarms := make([]interface{}, 0)
+	for _, alarm := range all {
+		if form.Device != "" && alarm.DeviceID != form.Device {
+			continue
+		}
+		if form.Channel != "" && alarm.ChannelID != form.Channel {
+			continue
+		}
+		if form.Type != "" && fmt.Sprintf("%d", alarm.Type) != form.Type {
+			continue
+		}
+		if form.Acked != "" && fmt.Sprintf("%v", alarm.Acked) != strings.ToLower(form.Acked) {
+			continue
+		}
+		var ackAt interface{} = ""
+		if alarm.Acked {
+			ackAt = utils.DateTime(time.Unix(alarm.AckAt, 0))
+		}
+		alarms = append(alarms, map[string]interface{}{
+			"id":          alarm.ID,
+			"deviceID":    alarm.DeviceID,
+			"channelID":   alarm.ChannelID,
+			"priority":    alarm.Priority,
+			"method":      alarm.Method,
+			"type":        alarm.Type,
+			"time":        utils.DateTime(time.Unix(alarm.Time, 0)),
+			"description": alarm.Description,
+			"longitude":   alarm.Longitude,
+			"latitude":    alarm.Latitude,
+			"acked":       alarm.Acked,
+			"ackAt":       ackAt,
+		})
+	}
+	pr := NewPageResponse(alarms)
+	if form.Sort != "" {
+		pr.Sort(form.Sort, form.Order)
+	}
+	pr.Slice(form.Start, form.Limit)
+	c.IndentedJSON(200, pr)
+}
+
+/**
+ * @api {get} /api/v1/gb/alarms/ack 确认GB28181报警
+ * @apiGroup gb
+ * @apiName GBAlarmAck
+ * @apiParam {String} id 报警ID
+ * @apiUse simpleSuccess
+ */
+func (h *APIHandler) GBAlarmAck(c *gin.Context) {
+	type Form struct {
+		ID string `form:"id" binding:"required"`
+	}
+	var form Form
+	if err := c.Bind(&form); err != nil {
+		return
+	}
+
+	alarm, err := models.AckAlarm(form.ID, time.Now().Unix())
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, fmt.Sprintf("Alarm[%s] ack error: %v", form.ID, err))
+		return
+	}
+	if alarm == nil {
+		c.AbortWithStatusJSON(http.StatusNotFound, fmt.Sprintf("Alarm[%s] not found", form.ID))
+		return
+	}
+	c.IndentedJSON(200, "OK")
+}
+
+/**
+ * @api {get} /api/v1/gb/broadcast GB28181语音广播
+ * @apiDescription 将RTSP推流(ANNOUNCE/RECORD)中的G.711音频广播到设备, 设备收到广播通知后INVITE拉取音频.
+ * 语音对讲为语音广播加上同一通道的实时流, 实时流中带有设备音频
+ * @apiGroup gb
+ * @apiName GBBroadcast
+ * @apiParam {String} device 设备ID
+ * @apiParam {String} [channel] 音频输出通道ID, 默认为设备ID
+ * @apiParam {String} path 音频来源的推流路径, 音频须为PCMA或PCMU
+ * @apiSuccess (200) {String} id 广播ID, 用于停止广播
+ */
+func (h *APIHandler) GBBroadcast(c *gin.Context) {
+	type Form struct {
+		Device  string `form:"device" binding:"required"`
+		Channel string `form:"channel"`
+		Path    string `form:"path" binding:"required"`
+	}
+	var form Form
+	if err := c.Bind(&form); err != nil {
+		return
+	}
+
+	broadcast, err := gb28181.GetServer().Broadcast(form.Device, form.Channel, form.Path)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, fmt.Sprintf("Device[%s] broadcast error: %v", form.Device, err))
+		return
+	}
+	c.IndentedJSON(200, gin.H{
+		"id": broadcast.ID,
+	})
+}
+
+/**
+ * @api {post} /api/v1/gb/broadcast/upload GB28181语音广播上传音频
+ * @apiDescription 上传G.711音频广播到设备, 音频播放完毕后自动停止
+ * @apiGroup gb
+ * @apiName GBBroadcastUpload
+ * @apiParam {String} device 设备ID
+ * @apiParam {String} [channel] 音频输出通道ID, 默认为设备ID
+ * @apiParam {File} file 音频文件, A-law或mu-law编码的8000Hz单声道WAV, 或G.711裸数据
+ * @apiParam {String=PCMA,PCMU} [codec=PCMA] G.711裸数据的编码, WAV文件忽略该参数
+ * @apiSuccess (200) {String} id 广播ID, 用于停止广播
+ */
+func (h *APIHandler) GBBroadcastUpload(c *gin.Context) {
+	type Form struct {
+		Device  string `form:"device" binding:"required"`
+		Channel string `form:"channel"`
+		Codec   string `form:"codec"`
+	}
+	form := &Form{Codec: "PCMA"}
+	if err := c.Bind(form); err != nil {
+		return
+	}
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, fmt.Sprintf("Upload file error: %v", err))
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, fmt.Sprintf("Upload file error: %v", err))
+		return
+	}
+	data, err := ioutil.ReadAll(file)
+	file.Close()
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, fmt.Sprintf("Upload file error: %v", err))
+		return
+	}
+
+	codec, samples := form.Codec, data
+	if bytes.HasPrefix(data, []byte("RIFF")) {
+		if codec, samples, err = gb28181.ParseG711WAV(data); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	broadcast, err := gb28181.GetServer().BroadcastSamples(form.Device, form.Channel, codec, samples)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, fmt.Sprintf("Device[%s] broadcast error: %v", form.Device, err))
+		return
+	}
+	c.IndentedJSON(200, gin.H{
+		"id": broadcast.ID,
+	})
+}
+
+/**
+ * @api {get} /api/v1/gb/broadcast/stop 停止GB28181语音广播
+ * @apiGroup gb
+ * @apiName GBBroadcastStop
+ * @apiParam {String} id 广播ID
+ * @apiUse simpleSuccess
+ */
+func (h *APIHandler) GBBroadcastStop(c *gin.Context) {
+	type Form struct {
+		ID string `form:"id" binding:"required"`
+	}
+	var form Form
+	if err := c.Bind(&form); err != nil {
+		return
+	}
+
+	broadcast := gb28181.GetServer().GetBroadcast(form.ID)
+	if broadcast == nil {
+		c.AbortWithStatusJSON(http.StatusNotFound, fmt.Sprintf("Broadcast[%s] not found", form.ID))
+		return
+	}
+	broadcast.Stop()
+	c.IndentedJSON(200, "OK")
+}
+
+func gbPosition(position *models.Position) map[string]interface{} {
+	return map[string]interface{}{
+		"deviceID":  position.DeviceID,
+		"channelID": position.ChannelID,
+		"time":      utils.DateTime(time.Unix(position.Time, 0)),
+		"longitude": position.Longitude,
+		"latitude":  position.Latitude,
+		"speed":     position.Speed,
+		"direction": position.Direction,
+		"altitude":  position.Altitude,
+	}
+}
+
+/**
+ * @api {get} /api/v1/gb/positions/last 获取GB28181设备最后位置
+ * @apiGroup gb
+ * @apiName GBPositionLast
+ * @apiParam {String} device 设备ID
+ * @apiSuccess (200) {String} deviceID 设备ID
+ * @apiSuccess (200) {String} channelID 上报位置的通道ID
+ * @apiSuccess (200) {String} time 定位时间
+ * @apiSuccess (200) {Number} longitude 经度
+ * @apiSuccess (200) {Number} latitude 纬度
+ * @apiSuccess (200) {Number} speed 速度, 千米/小时
+ * @apiSuccess (200) {Number} direction 方向, 与正北方的顺时针夹角
+ * @apiSuccess (200) {Number} altitude 海拔高度, 米
+ */
+func (h *APIHandler) GBPositionLast(c *gin.Context) {
+	type Form struct {
+		Device string `form:"device" binding:"required"`
+	}
+	var form Form
+	if err := c.Bind(&form); err != nil {
+		return
+	}
+
+	position, err := models.GetLastPosition(form.Device)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, fmt.Sprintf("Device[%s] position error: %v", form.Device, err))
+		return
+	}
+	if position == nil {
+		c.AbortWithStatusJSON(http.StatusNotFound, fmt.Sprintf("Device[%s] position not found", form.Device))
+		return
+	}
+	c.IndentedJSON(200, gbPosition(position))
+}
+
+/**
+ * @api {get} /api/v1/gb/positions 获取GB28181设备轨迹
+ * @apiGroup gb
+ * @apiName GBPositions
+ * @apiParam {String} device 设备ID
+ * @apiParam {String} [channel] 上报位置的通道ID
+ * @apiParam {Number} [begin] 开始时间, unix秒, 默认为1小时前
+ * @apiParam {Number} [end] 结束时间, unix秒, 默认为当前时间
+ * @apiParam {Number} [start] 分页开始,从零开始
+ * @apiParam {Number} [limit] 分页大小
+ * @apiSuccess (200) {Number} total 总数
+ * @apiSuccess (200) {Array} rows 按时间排序的位置列表, 字段同最后位置
+ */
+func (h *APIHandler) GBPositions(c *gin.Context) {
+	type Form struct {
+		PageRequest
+		Device  string `form:"device" binding:"required"`
+		Channel string `form:"channel"`
+		Begin   int64  `form:"begin"`
+		End     int64  `form:"end"`
+	}
+	now := time.Now()
+	form := &Form{
+		PageRequest: *NewPageRequest(),
+		Begin:       now.Add(-time.Hour).Unix(),
+		End:         now.Unix(),
+	}
+	if err := c.Bind(form); err != nil {
+		return
+	}
+
+	all, err := models.GetPositionsByTime(form.Device, form.Begin, form.End)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, fmt.Sprintf("Device[%s] positions error: %v", form.Device, err))
+		return
+	}
+	positions := make([]interface{}, 0)
+	for _, position := range all {
+		if form.Channel != "" && position.ChannelID != form.Channel {
+			continue
+		}
+		positions = append(positions, gbPosition(position))
+	}
+	pr := NewPageResponse(positions)
+	pr.Slice(form.Start, form.Limit)
+	c.IndentedJSON(200, pr)
+}
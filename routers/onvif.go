@@ -0,0 +1,361 @@
+package routers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/EasyDarwin/EasyDarwin/gb28181"
+	"github.com/EasyDarwin/EasyDarwin/models"
+	"github.com/EasyDarwin/EasyDarwin/onvif"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+/**
+ * @apiDefine onvif ONVIF
+ */
+
+/**
+ * @api {get} /api/v1/onvif/discover 发现ONVIF设备
+ * @apiDescription 在本地网络发送WS-Discovery探测, 返回响应的设备
+ * @apiGroup onvif
+ * @apiName ONVIFDiscover
+ * @apiParam {Number} [timeout] 等待设备响应的时间(秒), 默认使用配置
+ * @apiSuccess (200) {Number} total 总数
+ * @apiSuccess (200) {Array} rows 设备列表
+ * @apiSuccess (200) {String} rows.address 设备端点地址
+ * @apiSuccess (200) {String} rows.xaddr 设备服务地址
+ * @apiSuccess (200) {String} rows.ip 设备IP
+ * @apiSuccess (200) {String} rows.name 设备名称
+ * @apiSuccess (200) {String} rows.hardware 硬件型号
+ * @apiSuccess (200) {String} rows.location 位置
+ */
+func (h *APIHandler) ONVIFDiscover(c *gin.Context) {
+	type Form struct {
+		Timeout int `form:"timeout"`
+	}
+	var form Form
+	if err := c.Bind(&form); err != nil {
+		return
+	}
+
+	devices, err := onvif.Discover(time.Duration(form.Timeout) * time.Second)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, fmt.Sprintf("ONVIF discover error: %v", err))
+		return
+	}
+	rows := make([]interface{}, 0, len(devices))
+	for _, device := range devices {
+		rows = append(rows, map[string]interface{}{
+			"address":  device.Address,
+			"xaddr":    device.XAddr(),
+			"ip":       device.IP,
+			"name":     device.Name,
+			"hardware": device.Hardware,
+			"location": device.Location,
+		})
+	}
+	c.IndentedJSON(200, NewPageResponse(rows))
+}
+
+/**
+ * @api {get} /api/v1/onvif/profiles 获取ONVIF设备媒体配置
+ * @apiGroup onvif
+ * @apiName ONVIFProfiles
+ * @apiParam {String} xaddr 设备服务地址
+ * @apiParam {String} [username] 用户名, 默认使用配置
+ * @apiParam {String} [password] 密码
+ * @apiSuccess (200) {Number} total 总数
+ * @apiSuccess (200) {Array} rows 媒体配置列表
+ * @apiSuccess (200) {String} rows.token 媒体配置标识
+ * @apiSuccess (200) {String} rows.name 媒体配置名称
+ * @apiSuccess (200) {String} rows.encoding 视频编码
+ * @apiSuccess (200) {Number} rows.width 宽
+ * @apiSuccess (200) {Number} rows.height 高
+ * @apiSuccess (200) {Number} rows.frameRate 帧率
+ * @apiSuccess (200) {Number} rows.bitrate 码率, kbps
+ * @apiSuccess (200) {Boolean} rows.ptz 是否支持云台
+ */
+func (h *APIHandler) ONVIFProfiles(c *gin.Context) {
+	type Form struct {
+		XAddr    string `form:"xaddr" binding:"required"`
+		Username string `form:"username"`
+		Password string `form:"password"`
+	}
+	var form Form
+	if err := c.Bind(&form); err != nil {
+		return
+	}
+
+	client, err := onvif.Dial(form.XAddr, form.Username, form.Password)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, fmt.Sprintf("ONVIF[%s] error: %v", form.XAddr, err))
+		return
+	}
+	profiles, err := client.GetProfiles()
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, fmt.Sprintf("ONVIF[%s] profiles error: %v", form.XAddr, err))
+		return
+	}
+	rows := make([]interface{}, 0, len(profiles))
+	for _, profile := range profiles {
+		rows = append(rows, map[string]interface{}{
+			"token":     profile.Token,
+			"name":      profile.Name,
+			"encoding":  profile.Encoding,
+			"width":     profile.Width,
+			"height":    profile.Height,
+			"frameRate": profile.FrameRate,
+			"bitrate":   profile.Bitrate,
+			"ptz":       profile.PTZ,
+		})
+	}
+	c.IndentedJSON(200, NewPageResponse(rows))
+}
+
+/**
+ * @api {get} /api/v1/onvif/provision ONVIF设备媒体配置启动拉转推
+ * @apiDescription 通过GetStreamUri获取RTSP地址, 保存为拉流并启动
+ * @apiGroup onvif
+ * @apiName ONVIFProvision
+ * @apiParam {String} xaddr 设备服务地址
+ * @apiParam {String} profile 媒体配置标识
+ * @apiParam {String} [username] 用户名, 默认使用配置
+ * @apiParam {String} [password] 密码
+ * @apiParam {String} [customPath] 转推时的推送PATH
+ * @apiParam {String=TCP,UDP} [transType=TCP] 拉流传输模式
+ * @apiParam {Number} [idleTimeout] 拉流时的超时时间
+ * @apiParam {Number} [heartbeatInterval] 拉流时的心跳间隔，毫秒为单位
+ * @apiSuccess (200) {String} ID	拉流的ID。后续可以通过该ID来停止拉流
+ */
+func (h *APIHandler) ONVIFProvision(c *gin.Context) {
+	type Form struct {
+		XAddr             string `form:"xaddr" binding:"required"`
+		Profile           string `form:"profile" binding:"required"`
+		Username          string `form:"username"`
+		Password          string `form:"password"`
+		CustomPath        string `form:"customPath"`
+		TransType         string `form:"transType"`
+		IdleTimeout       int64  `form:"idleTimeout"`
+		HeartbeatInterval int64  `form:"heartbeatInterval"`
+	}
+	var form Form
+	if err := c.Bind(&form); err != nil {
+		return
+	}
+
+	client, err := onvif.Dial(form.XAddr, form.Username, form.Password)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, fmt.Sprintf("ONVIF[%s] error: %v", form.XAddr, err))
+		return
+	}
+	url, err := client.StreamURL(form.Profile)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, fmt.Sprintf("ONVIF[%s] stream uri error: %v", form.XAddr, err))
+		return
+	}
+	if form.CustomPath != "" && !strings.HasPrefix(form.CustomPath, "/") {
+		form.CustomPath = "/" + form.CustomPath
+	}
+	pusher, status, err := startStream(&models.Stream{
+		ID:                uuid.New().String(),
+		URL:               url,
+		CustomPath:        form.CustomPath,
+		IdleTimeout:       form.IdleTimeout,
+		HeartbeatInterval: form.HeartbeatInterval,
+		ONVIFAddr:         form.XAddr,
+		ONVIFUsername:     client.Username,
+		ONVIFPassword:     client.Password,
+		ONVIFProfile:      form.Profile,
+	}, form.TransType)
+	if err != nil {
+		c.AbortWithStatusJSON(status, err.Error())
+		return
+	}
+	log.Printf("ONVIF %s profile %s pull to push %s", form.XAddr, form.Profile, pusher.Path())
+
+	c.IndentedJSON(200, pusher.ID())
+}
+
+// onvifClient of stream provisioned by ONVIF, response is aborted on error
+func (h *APIHandler) onvifClient(c *gin.Context, streamID string) (*onvif.Client, *models.Stream) {
+	stream, err := models.GetStream(streamID)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, fmt.Sprintf("Stream[%s] error: %v", streamID, err))
+		return nil, nil
+	}
+	if stream == nil {
+		c.AbortWithStatusJSON(http.StatusNotFound, fmt.Sprintf("Stream[%s] not found", streamID))
+		return nil, nil
+	}
+	if stream.ONVIFAddr == "" {
+		c.AbortWithStatusJSON(http.StatusBadRequest, fmt.Sprintf("Stream[%s] not provisioned by ONVIF", streamID))
+		return nil, nil
+	}
+	client, err := onvif.GetClient(stream.ONVIFAddr, stream.ONVIFUsername, stream.ONVIFPassword)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, fmt.Sprintf("ONVIF[%s] error: %v", stream.ONVIFAddr, err))
+		return nil, nil
+	}
+	return client, stream
+}
+
+// onvifPTZ of command same as GB28181 PTZ, speed 0-255 is normalized to ONVIF velocity
+func onvifPTZ(client *onvif.Client, profile string, command string, speed int, preset int) error {
+	if speed < 0 {
+		speed = 0
+	} else if speed > 255 {
+		speed = 255
+	}
+	v := float64(speed) / 255
+	velocities := map[string]onvif.Velocity{
+		gb28181.PTZLeft:      {X: -v},
+		gb28181.PTZRight:     {X: v},
+		gb28181.PTZUp:        {Y: v},
+		gb28181.PTZDown:      {Y: -v},
+		gb28181.PTZUpLeft:    {X: -v, Y: v},
+		gb28181.PTZUpRight:   {X: v, Y: v},
+		gb28181.PTZDownLeft:  {X: -v, Y: -v},
+		gb28181.PTZDownRight: {X: v, Y: -v},
+		gb28181.PTZZoomIn:    {Zoom: v},
+		gb28181.PTZZoomOut:   {Zoom: -v},
+	}
+
+	command = strings.ToLower(command)
+	if velocity, ok := velocities[command]; ok {
+		return client.ContinuousMove(profile, velocity)
+	}
+	switch command {
+	case gb28181.PTZStop:
+		return client.Stop(profile)
+	case gb28181.PTZPresetSet, gb28181.PTZPresetGoto, gb28181.PTZPresetRemove:
+		if preset < 1 || preset > 255 {
+			return fmt.Errorf("preset of %s should be 1-255", command)
+		}
+		// preset number is used as token
+		token := strconv.Itoa(preset)
+		switch command {
+		case gb28181.PTZPresetSet:
+			return client.SetPreset(profile, token)
+		case gb28181.PTZPresetGoto:
+			return client.GotoPreset(profile, token)
+		default:
+			return client.RemovePreset(profile, token)
+		}
+	}
+	return fmt.Errorf("PTZ command %s unsupported by ONVIF", command)
+}
+
+/**
+ * @api {get} /api/v1/onvif/ptz ONVIF云台控制
+ * @apiDescription 对ONVIF接入的拉流发送云台命令, 命令与GB28181云台控制相同, 方向与变倍命令需要发送stop停止
+ * @apiGroup onvif
+ * @apiName ONVIFPTZ
+ * @apiParam {String} stream 拉流的ID
+ * @apiParam {String=stop,left,right,up,down,upleft,upright,downleft,downright,zoomin,zoomout,presetset,presetgoto,presetremove} command 控制命令
+ * @apiParam {Number} [speed=128] 速度, 0-255
+ * @apiParam {Number} [preset] 预置位号, 1-255, 作为ONVIF预置位标识
+ * @apiSuccess (200) {String} result 设备响应结果
+ */
+func (h *APIHandler) ONVIFPTZ(c *gin.Context) {
+	type Form struct {
+		Stream  string `form:"stream" binding:"required"`
+		Command string `form:"command" binding:"required"`
+		Speed   int    `form:"speed"`
+		Preset  int    `form:"preset"`
+	}
+	form := &Form{Speed: 128}
+	if err := c.Bind(form); err != nil {
+		return
+	}
+
+	client, stream := h.onvifClient(c, form.Stream)
+	if client == nil {
+		return
+	}
+	if err := onvifPTZ(client, stream.ONVIFProfile, form.Command, form.Speed, form.Preset); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, fmt.Sprintf("Stream[%s] PTZ error: %v", form.Stream, err))
+		return
+	}
+	c.IndentedJSON(200, gin.H{
+		"result": "OK",
+	})
+}
+
+/**
+ * @api {get} /api/v1/onvif/imaging 查询或修改ONVIF图像参数
+ * @apiDescription 不带修改参数时查询, 否则修改给出的参数, 取值范围由设备决定, 通常为0-100
+ * @apiGroup onvif
+ * @apiName ONVIFImaging
+ * @apiParam {String} stream 拉流的ID
+ * @apiParam {Number} [brightness] 亮度
+ * @apiParam {Number} [colorSaturation] 饱和度
+ * @apiParam {Number} [contrast] 对比度
+ * @apiParam {Number} [sharpness] 锐度
+ * @apiSuccess (200) {Number} brightness 亮度
+ * @apiSuccess (200) {Number} colorSaturation 饱和度
+ * @apiSuccess (200) {Number} contrast 对比度
+ * @apiSuccess (200) {Number} sharpness 锐度
+ */
+func (h *APIHandler) ONVIFImaging(c *gin.Context) {
+	type Form struct {
+		Stream          string `form:"stream" binding:"required"`
+		Brightness      string `form:"brightness"`
+		ColorSaturation string `form:"colorSaturation"`
+		Contrast        string `form:"contrast"`
+		Sharpness       string `form:"sharpness"`
+	}
+	var form Form
+	if err := c.Bind(&form); err != nil {
+		return
+	}
+
+	settings := &onvif.ImagingSettings{}
+	changed := false
+	for _, field := range []struct {
+		name  string
+		value string
+		dest  **float64
+	}{
+		{"brightness", form.Brightness, &settings.Brightness},
+		{"colorSaturation", form.ColorSaturation, &settings.ColorSaturation},
+		{"contrast", form.Contrast, &settings.Contrast},
+		{"sharpness", form.Sharpness, &settings.Sharpness},
+	} {
+		if field.value == "" {
+			continue
+		}
+		value, err := strconv.ParseFloat(field.value, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, fmt.Sprintf("%s %s invalid", field.name, field.value))
+			return
+		}
+		*field.dest = &value
+		changed = true
+	}
+
+	client, stream := h.onvifClient(c, form.Stream)
+	if client == nil {
+		return
+	}
+	source, err := client.VideoSource(stream.ONVIFProfile)
+	if err == nil && changed {
+		err = client.SetImagingSettings(source, settings)
+	}
+	if err == nil {
+		settings, err = client.GetImagingSettings(source)
+	}
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, fmt.Sprintf("Stream[%s] imaging error: %v", form.Stream, err))
+		return
+	}
+	c.IndentedJSON(200, gin.H{
+		"brightness":      settings.Brightness,
+		"colorSaturation": settings.ColorSaturation,
+		"contrast":        settings.Contrast,
+		"sharpness":       settings.Sharpness,
+	})
+}
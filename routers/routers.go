@@ -9,6 +9,7 @@ import (
 	"github.com/gin-contrib/static"
 	"github.com/gin-gonic/gin"
 	"github.com/EasyDarwin/EasyDarwin/cors"
+	"github.com/EasyDarwin/EasyDarwin/rtsp"
 //	"github.com/penggy/EasyGoLib/utils"
 	"github.com/EasyDarwin/EasyDarwin/sessions"
 	validator "gopkg.in/go-playground/validator.v8"
@@ -96,6 +97,18 @@ func NeedLogin() gin.HandlerFunc {
 	}
 }
 
+// RTSPTunnel serves RTSP over HTTP on the HTTP listener
+func RTSPTunnel() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !rtsp.IsTunnelRequest(c.Request) {
+			c.Next()
+			return
+		}
+		c.Abort()
+		rtsp.GetServer().ServeTunnel(c.Writer, c.Request)
+	}
+}
+
 // Init API of RTSP server
 func Init() (err error) {
 	Router = gin.New()
@@ -103,6 +116,7 @@ func Init() (err error) {
 	// Router.Use(gin.Logger())
 	Router.Use(gin.Recovery())
 	Router.Use(Errors())
+	Router.Use(RTSPTunnel())
 	Router.Use(cors.Default())
 
 	Router.Use(static.Serve("/", static.LocalFile(config.HTTP.Static, true)))
@@ -117,9 +131,38 @@ func Init() (err error) {
 
 		api.GET("/stream/start", API.StreamStart)
 		api.GET("/stream/stop", API.StreamStop)
+		api.GET("/rtsp/users", API.RTSPUsers)
+		api.GET("/rtsp/users/save", API.RTSPUserSave)
+		api.GET("/rtsp/users/remove", API.RTSPUserRemove)
+		api.GET("/rtsp/rules", API.RTSPRules)
+		api.GET("/rtsp/rules/save", API.RTSPRuleSave)
+		api.GET("/rtsp/rules/remove", API.RTSPRuleRemove)
+		api.GET("/onvif/discover", API.ONVIFDiscover)
+		api.GET("/onvif/profiles", API.ONVIFProfiles)
+		api.GET("/onvif/provision", API.ONVIFProvision)
+		api.GET("/onvif/ptz", API.ONVIFPTZ)
+		api.GET("/onvif/imaging", API.ONVIFImaging)
 
 		api.GET("/record/start", API.StartRecord)
 		api.GET("/record", API.QueryRecord)
+
+		api.GET("/gb/devices", API.GBDevices)
+		api.GET("/gb/devices/remove", API.GBDeviceRemove)
+		api.GET("/gb/devices/info", API.GBDeviceInfo)
+		api.GET("/gb/devices/status", API.GBDeviceStatus)
+		api.GET("/gb/devices/config", API.GBDeviceConfig)
+		api.GET("/gb/channels", API.GBChannels)
+		api.GET("/gb/catalog", API.GBCatalog)
+		api.GET("/gb/ptz", API.GBPTZ)
+		api.GET("/gb/control", API.GBControl)
+		api.GET("/gb/records", API.GBRecords)
+		api.GET("/gb/alarms", API.GBAlarms)
+		api.GET("/gb/alarms/ack", API.GBAlarmAck)
+		api.GET("/gb/broadcast", API.GBBroadcast)
+		api.POST("/gb/broadcast/upload", API.GBBroadcastUpload)
+		api.GET("/gb/broadcast/stop", API.GBBroadcastStop)
+		api.GET("/gb/positions/last", API.GBPositionLast)
+		api.GET("/gb/positions", API.GBPositions)
 	}
 
 	return
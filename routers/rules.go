@@ -0,0 +1,180 @@
+package routers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/EasyDarwin/EasyDarwin/models"
+	"github.com/EasyDarwin/EasyDarwin/rtsp"
+	"github.com/EasyDarwin/EasyDarwin/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+/**
+ * @apiDefine rtsprule RTSP路径规则
+ */
+
+/**
+ * @apiDefine policyParam
+ * @apiParam {String=open,signed,user,ip,deny} publish 推流策略. open 不验证, signed 验证签名URL, user 验证RTSP用户, ip 验证IP白名单, deny 拒绝
+ * @apiParam {String} [publishUsers] user 策略允许推流的用户, 逗号分隔, 为空时按用户允许推流的路径验证
+ * @apiParam {String} [publishIPs] ip 策略允许推流的IP或网段, 逗号分隔, 如 192.168.1.0/24
+ * @apiParam {String=open,signed,user,ip,deny} play 播放策略
+ * @apiParam {String} [playUsers] user 策略允许播放的用户, 逗号分隔, 为空时按用户允许播放的路径验证
+ * @apiParam {String} [playIPs] ip 策略允许播放的IP或网段, 逗号分隔
+ */
+
+func policyInfo(policy *models.Policy) map[string]interface{} {
+	return map[string]interface{}{
+		"type":  policy.GetType(),
+		"users": policy.GetUsers(),
+		"ips":   policy.GetIPs(),
+	}
+}
+
+/**
+ * @api {get} /api/v1/rtsp/rules 获取RTSP路径规则列表
+ * @apiDescription 推流与播放时, 优先级高的规则先匹配, 优先级相同时路径长的先匹配, 没有匹配的规则时使用配置中的 default_policy
+ * @apiGroup rtsprule
+ * @apiName RTSPRules
+ * @apiParam {Number} [start] 分页开始,从零开始
+ * @apiParam {Number} [limit] 分页大小
+ * @apiParam {String} [sort] 排序字段
+ * @apiParam {String=ascending,descending} [order] 排序顺序
+ * @apiParam {String} [q] 查询参数, 匹配路径
+ * @apiSuccess (200) {Number} total 总数
+ * @apiSuccess (200) {Array} rows 规则列表
+ * @apiSuccess (200) {String} rows.id 规则ID
+ * @apiSuccess (200) {String} rows.pattern 路径, 支持通配符
+ * @apiSuccess (200) {Number} rows.priority 优先级
+ * @apiSuccess (200) {Object} rows.publish 推流策略
+ * @apiSuccess (200) {String} rows.publish.type 策略类型
+ * @apiSuccess (200) {Array} rows.publish.users 允许的用户
+ * @apiSuccess (200) {Array} rows.publish.ips 允许的IP或网段
+ * @apiSuccess (200) {Object} rows.play 播放策略
+ * @apiSuccess (200) {String} rows.createAt 创建时间
+ */
+func (h *APIHandler) RTSPRules(c *gin.Context) {
+	form := NewPageRequest()
+	if err := c.Bind(form); err != nil {
+		return
+	}
+
+	all, err := models.GetAllRules()
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, fmt.Sprintf("Get rules error: %v", err))
+		return
+	}
+	rules := make([]interface{}, 0)
+	for _, rule := range all {
+		if form.Q != "" && !strings.Contains(strings.ToLower(rule.Pattern), strings.ToLower(form.Q)) {
+			continue
+		}
+		rules = append(rules, map[string]interface{}{
+			"id":       rule.ID,
+			"pattern":  rule.Pattern,
+			"priority": rule.Priority,
+			"publish":  policyInfo(rule.Publish),
+			"play":     policyInfo(rule.Play),
+			"createAt": utils.DateTime(time.Unix(rule.CreateAt, 0)),
+		})
+	}
+	pr := NewPageResponse(rules)
+	if form.Sort != "" {
+		pr.Sort(form.Sort, form.Order)
+	}
+	pr.Slice(form.Start, form.Limit)
+	c.IndentedJSON(200, pr)
+}
+
+/**
+ * @api {get} /api/v1/rtsp/rules/save 新增或修改RTSP路径规则
+ * @apiGroup rtsprule
+ * @apiName RTSPRuleSave
+ * @apiParam {String} [id] 规则ID, 为空时新增
+ * @apiParam {String} pattern 路径, 支持通配符如 /live/*, * 表示全部
+ * @apiParam {Number} [priority=0] 优先级
+ * @apiUse policyParam
+ * @apiSuccess (200) {String} id 规则ID
+ */
+func (h *APIHandler) RTSPRuleSave(c *gin.Context) {
+	type Form struct {
+		ID           string `form:"id"`
+		Pattern      string `form:"pattern" binding:"required"`
+		Priority     int32  `form:"priority"`
+		Publish      string `form:"publish" binding:"required"`
+		PublishUsers string `form:"publishUsers"`
+		PublishIPs   string `form:"publishIPs"`
+		Play         string `form:"play" binding:"required"`
+		PlayUsers    string `form:"playUsers"`
+		PlayIPs      string `form:"playIPs"`
+	}
+	var form Form
+	if err := c.Bind(&form); err != nil {
+		return
+	}
+
+	rule := &models.Rule{
+		ID:       form.ID,
+		Pattern:  form.Pattern,
+		Priority: form.Priority,
+		Publish: &models.Policy{
+			Type:  strings.ToLower(form.Publish),
+			Users: splitList(form.PublishUsers),
+			IPs:   splitList(form.PublishIPs),
+		},
+		Play: &models.Policy{
+			Type:  strings.ToLower(form.Play),
+			Users: splitList(form.PlayUsers),
+			IPs:   splitList(form.PlayIPs),
+		},
+		CreateAt: time.Now().Unix(),
+	}
+	if err := rule.Publish.Validate(); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, fmt.Sprintf("Rule publish error: %v", err))
+		return
+	}
+	if err := rule.Play.Validate(); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, fmt.Sprintf("Rule play error: %v", err))
+		return
+	}
+	if rule.ID == "" {
+		rule.ID = uuid.New().String()
+	}
+
+	err := models.AddRule(rule)
+	rtsp.InvalidateRules()
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, fmt.Sprintf("Rule[%s] save error: %v", rule.ID, err))
+		return
+	}
+	c.IndentedJSON(200, gin.H{"id": rule.ID})
+}
+
+/**
+ * @api {get} /api/v1/rtsp/rules/remove 删除RTSP路径规则
+ * @apiGroup rtsprule
+ * @apiName RTSPRuleRemove
+ * @apiParam {String} id 规则ID
+ * @apiUse simpleSuccess
+ */
+func (h *APIHandler) RTSPRuleRemove(c *gin.Context) {
+	type Form struct {
+		ID string `form:"id" binding:"required"`
+	}
+	var form Form
+	if err := c.Bind(&form); err != nil {
+		return
+	}
+
+	err := models.RemoveRule(form.ID)
+	rtsp.InvalidateRules()
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, fmt.Sprintf("Rule[%s] remove error: %v", form.ID, err))
+		return
+	}
+	c.IndentedJSON(200, "OK")
+}
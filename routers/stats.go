@@ -3,6 +3,7 @@ package routers
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/EasyDarwin/EasyDarwin/rtsp"
 	"github.com/EasyDarwin/EasyDarwin/utils"
@@ -23,6 +24,43 @@ import (
  * @apiSuccess (200) {String} rows.startAt 开始时间
  */
 
+/**
+ * @apiDefine rtcpInfo
+ * @apiSuccess (200) {Array} rows.rtcp RTCP 统计, 按音视频轨道
+ * @apiSuccess (200) {String} rows.rtcp.track 轨道, video, audio0, audio1
+ * @apiSuccess (200) {Number} rows.rtcp.ssrc SSRC
+ * @apiSuccess (200) {Number} rows.rtcp.packetsLost 累计丢包数
+ * @apiSuccess (200) {Number} rows.rtcp.fractionLost 最近一个报告周期的丢包率, 0 到 1
+ * @apiSuccess (200) {Number} rows.rtcp.jitter 抖动, 毫秒
+ * @apiSuccess (200) {Number} rows.rtcp.rtt 往返时延, 毫秒, 0 表示未知
+ * @apiSuccess (200) {String} rows.rtcp.lastSR 推流为最后收到 SR 的时间, 播放为最后发送 SR 的时间
+ */
+
+// rtcpInfo of pusher or player, empty if RTCP is not counted
+func rtcpInfo(v interface{}) []interface{} {
+	infos := make([]interface{}, 0)
+	reporter, ok := v.(rtsp.RTCPReporter)
+	if !ok {
+		return infos
+	}
+	for _, stats := range reporter.RTCPStats() {
+		lastSR := ""
+		if !stats.LastSR.IsZero() {
+			lastSR = utils.DateTime(stats.LastSR).String()
+		}
+		infos = append(infos, map[string]interface{}{
+			"track":        stats.Track,
+			"ssrc":         stats.SSRC,
+			"packetsLost":  stats.PacketsLost,
+			"fractionLost": stats.FractionLost,
+			"jitter":       float64(stats.Jitter) / float64(time.Millisecond),
+			"rtt":          float64(stats.RTT) / float64(time.Millisecond),
+			"lastSR":       lastSR,
+		})
+	}
+	return infos
+}
+
 /**
  * @api {get} /api/v1/pushers 获取推流列表
  * @apiGroup stats
@@ -41,6 +79,7 @@ import (
  * @apiSuccess (200) {Number} rows.outBytes 出口流量
  * @apiSuccess (200) {String} rows.startAt 开始时间
  * @apiSuccess (200) {Number} rows.onlines 在线人数
+ * @apiUse rtcpInfo
  */
 func (h *APIHandler) Pushers(c *gin.Context) {
 	form := NewPageRequest()
@@ -73,6 +112,7 @@ func (h *APIHandler) Pushers(c *gin.Context) {
 			"outBytes":  pusher.OutBytes(),
 			"startAt":   utils.DateTime(pusher.StartAt()),
 			"onlines":   pusher.GetPlayers().Len(),
+			"rtcp":      rtcpInfo(pusher),
 		})
 	}
 	pr := NewPageResponse(stats)
@@ -100,6 +140,7 @@ func (h *APIHandler) Pushers(c *gin.Context) {
  * @apiSuccess (200) {Number} rows.inBytes 入口流量
  * @apiSuccess (200) {Number} rows.outBytes 出口流量
  * @apiSuccess (200) {String} rows.startAt 开始时间
+ * @apiUse rtcpInfo
  */
 func (h *APIHandler) Players(c *gin.Context) {
 	form := NewPageRequest()
@@ -129,6 +170,7 @@ func (h *APIHandler) Players(c *gin.Context) {
 			"inBytes":   player.InBytes(),
 			"outBytes":  player.OutBytes(),
 			"startAt":   utils.DateTime(player.StartAt()),
+			"rtcp":      rtcpInfo(player),
 		})
 	}
 	pr := NewPageResponse(_players)
@@ -41,25 +41,43 @@ func (h *APIHandler) StreamStart(c *gin.Context) {
 		log.Printf("Pull to push err:%v", err)
 		return
 	}
+	if form.CustomPath != "" && !strings.HasPrefix(form.CustomPath, "/") {
+		form.CustomPath = "/" + form.CustomPath
+	}
+	pusher, status, err := startStream(&models.Stream{
+		ID:                uuid.New().String(),
+		URL:               form.URL,
+		CustomPath:        form.CustomPath,
+		IdleTimeout:       form.IdleTimeout,
+		HeartbeatInterval: form.HeartbeatInterval,
+	}, form.TransType)
+	if err != nil {
+		c.AbortWithStatusJSON(status, err.Error())
+		return
+	}
+	log.Printf("Pull to push %v success ", form)
+
+	c.IndentedJSON(200, pusher.ID())
+}
+
+// startStream pulls URL of stream and pushes it on CustomPath, stream is saved to DB after started.
+// HTTP status is returned with error
+func startStream(stream *models.Stream, transType string) (rtsp.Pusher, int, error) {
 	agent := fmt.Sprintf("EasyDarwinGo/%s", BuildVersion)
 	if BuildDateTime != "" {
 		agent = fmt.Sprintf("%s(%s)", agent, BuildDateTime)
 	}
 	client, err := rtsp.NewRTSPClient(
 		rtsp.GetServer(),
-		uuid.New().String(),
-		form.URL,
-		int64(form.HeartbeatInterval)*1000,
+		stream.ID,
+		stream.URL,
+		int64(stream.HeartbeatInterval)*1000,
 		agent)
 	if err != nil {
-		c.AbortWithStatusJSON(http.StatusBadRequest, err.Error())
-		return
-	}
-	if form.CustomPath != "" && !strings.HasPrefix(form.CustomPath, "/") {
-		form.CustomPath = "/" + form.CustomPath
+		return nil, http.StatusBadRequest, err
 	}
-	client.CustomPath = form.CustomPath
-	switch strings.ToLower(form.TransType) {
+	client.CustomPath = stream.CustomPath
+	switch strings.ToLower(transType) {
 	case "udp":
 		client.TransType = rtsp.TRANS_TYPE_UDP
 	case "tcp":
@@ -70,35 +88,23 @@ func (h *APIHandler) StreamStart(c *gin.Context) {
 
 	pusher := rtsp.NewClientPusher(client)
 	if rtsp.GetServer().GetPusher(pusher.Path(), nil) != nil {
-		c.AbortWithStatusJSON(http.StatusBadRequest, fmt.Sprintf("Path %s already exists", client.Path))
-		return
+		return nil, http.StatusBadRequest, fmt.Errorf("Path %s already exists", client.Path)
 	}
-	err = client.Start(time.Duration(form.IdleTimeout) * time.Second)
+	err = client.Start(time.Duration(stream.IdleTimeout) * time.Second)
 	if err != nil {
 		log.Printf("Pull stream err :%v", err)
-		c.AbortWithStatusJSON(http.StatusBadRequest, fmt.Sprintf("Pull stream err: %v", err))
-		return
+		return nil, http.StatusBadRequest, fmt.Errorf("Pull stream err: %v", err)
 	}
-	log.Printf("Pull to push %v success ", form)
 	rtsp.GetServer().AddPusher(pusher, true)
 	// save to db.
-	stream := models.Stream{
-		ID:                client.ID,
-		URL:               form.URL,
-		CustomPath:        form.CustomPath,
-		IdleTimeout:       form.IdleTimeout,
-		HeartbeatInterval: form.HeartbeatInterval,
-	}
-
-	err = models.AddStream(&stream)
+	err = models.AddStream(stream)
 	if err != nil {
 		pusher.Stop()
 		log.Printf("Pull stream err :%v", err)
-		c.AbortWithStatusJSON(http.StatusInternalServerError, fmt.Sprintf("Pull stream err: %v", err))
-		return
+		return nil, http.StatusInternalServerError, fmt.Errorf("Pull stream err: %v", err)
 	}
 
-	c.IndentedJSON(200, pusher.ID())
+	return pusher, 0, nil
 }
 
 /**
@@ -0,0 +1,165 @@
+package routers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/EasyDarwin/EasyDarwin/models"
+	"github.com/EasyDarwin/EasyDarwin/rtsp"
+	"github.com/EasyDarwin/EasyDarwin/utils"
+	"github.com/gin-gonic/gin"
+)
+
+/**
+ * @apiDefine rtspuser RTSP用户
+ */
+
+// splitList of comma separated values
+func splitList(value string) []string {
+	values := make([]string, 0)
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+/**
+ * @api {get} /api/v1/rtsp/users 获取RTSP用户列表
+ * @apiGroup rtspuser
+ * @apiName RTSPUsers
+ * @apiParam {Number} [start] 分页开始,从零开始
+ * @apiParam {Number} [limit] 分页大小
+ * @apiParam {String} [sort] 排序字段
+ * @apiParam {String=ascending,descending} [order] 排序顺序
+ * @apiParam {String} [q] 查询参数, 匹配用户名
+ * @apiSuccess (200) {Number} total 总数
+ * @apiSuccess (200) {Array} rows 用户列表
+ * @apiSuccess (200) {String} rows.username 用户名
+ * @apiSuccess (200) {Boolean} rows.enabled 是否启用
+ * @apiSuccess (200) {Array} rows.publishPaths 允许推流的路径
+ * @apiSuccess (200) {Array} rows.playPaths 允许播放的路径
+ * @apiSuccess (200) {Boolean} rows.password 是否设置了当前 realm 的密码
+ * @apiSuccess (200) {String} rows.createAt 创建时间
+ */
+func (h *APIHandler) RTSPUsers(c *gin.Context) {
+	form := NewPageRequest()
+	if err := c.Bind(form); err != nil {
+		return
+	}
+
+	all, err := models.GetAllUsers()
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, fmt.Sprintf("Get users error: %v", err))
+		return
+	}
+	users := make([]interface{}, 0)
+	for _, user := range all {
+		if form.Q != "" && !strings.Contains(strings.ToLower(user.Username), strings.ToLower(form.Q)) {
+			continue
+		}
+		_, password := user.HA1[rtsp.Realm()]
+		users = append(users, map[string]interface{}{
+			"username":     user.Username,
+			"enabled":      user.Enabled,
+			"publishPaths": user.GetPublishPaths(),
+			"playPaths":    user.GetPlayPaths(),
+			"password":     password,
+			"createAt":     utils.DateTime(time.Unix(user.CreateAt, 0)),
+		})
+	}
+	pr := NewPageResponse(users)
+	if form.Sort != "" {
+		pr.Sort(form.Sort, form.Order)
+	}
+	pr.Slice(form.Start, form.Limit)
+	c.IndentedJSON(200, pr)
+}
+
+/**
+ * @api {get} /api/v1/rtsp/users/save 新增或修改RTSP用户
+ * @apiDescription 服务器只保存 md5(username:realm:password), 修改用户时不传密码则保留原密码
+ * @apiGroup rtspuser
+ * @apiName RTSPUserSave
+ * @apiParam {String} username 用户名
+ * @apiParam {String} [password] 密码, 新增用户时必填
+ * @apiParam {Boolean} [enabled=true] 是否启用
+ * @apiParam {String} [publishPaths] 允许推流的路径, 逗号分隔, 支持通配符如 /live/*, * 表示全部
+ * @apiParam {String} [playPaths] 允许播放的路径, 逗号分隔, 支持通配符如 /live/*, * 表示全部
+ * @apiUse simpleSuccess
+ */
+func (h *APIHandler) RTSPUserSave(c *gin.Context) {
+	type Form struct {
+		Username     string `form:"username" binding:"required"`
+		Password     string `form:"password"`
+		Enabled      string `form:"enabled"`
+		PublishPaths string `form:"publishPaths"`
+		PlayPaths    string `form:"playPaths"`
+	}
+	var form Form
+	if err := c.Bind(&form); err != nil {
+		return
+	}
+	if strings.ContainsAny(form.Username, ":\"") {
+		c.AbortWithStatusJSON(http.StatusBadRequest, fmt.Sprintf("User[%s] name malformed", form.Username))
+		return
+	}
+
+	user, err := models.GetUser(form.Username)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, fmt.Sprintf("User[%s] get error: %v", form.Username, err))
+		return
+	}
+	if user == nil {
+		if form.Password == "" {
+			c.AbortWithStatusJSON(http.StatusBadRequest, fmt.Sprintf("User[%s] password required", form.Username))
+			return
+		}
+		user = &models.User{
+			Username: form.Username,
+			HA1:      make(map[string]string),
+			CreateAt: time.Now().Unix(),
+		}
+	}
+	if form.Password != "" {
+		// password of other realm is unknown then
+		user.HA1 = map[string]string{
+			rtsp.Realm(): models.HA1(form.Username, rtsp.Realm(), form.Password),
+		}
+	}
+	user.Enabled = strings.ToLower(form.Enabled) != "false"
+	user.PublishPaths = splitList(form.PublishPaths)
+	user.PlayPaths = splitList(form.PlayPaths)
+
+	if err := models.AddUser(user); err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, fmt.Sprintf("User[%s] save error: %v", form.Username, err))
+		return
+	}
+	c.IndentedJSON(200, "OK")
+}
+
+/**
+ * @api {get} /api/v1/rtsp/users/remove 删除RTSP用户
+ * @apiGroup rtspuser
+ * @apiName RTSPUserRemove
+ * @apiParam {String} username 用户名
+ * @apiUse simpleSuccess
+ */
+func (h *APIHandler) RTSPUserRemove(c *gin.Context) {
+	type Form struct {
+		Username string `form:"username" binding:"required"`
+	}
+	var form Form
+	if err := c.Bind(&form); err != nil {
+		return
+	}
+
+	if err := models.RemoveUser(form.Username); err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, fmt.Sprintf("User[%s] remove error: %v", form.Username, err))
+		return
+	}
+	c.IndentedJSON(200, "OK")
+}
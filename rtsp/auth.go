@@ -4,9 +4,13 @@ import (
 	"crypto/md5"
 	"encoding/base64"
 	"fmt"
+	"net"
 	"net/url"
 	"regexp"
 	"strings"
+	"sync"
+
+	"github.com/EasyDarwin/EasyDarwin/models"
 )
 
 // BasicAuth of RTSP
@@ -54,7 +58,7 @@ func DigestAuth(authLine string, method string, URL string) (string, error) {
 	password, _ := l.User.Password()
 	l.User = nil
 	if l.Port() == "" {
-		l.Host = fmt.Sprintf("%s:%s", l.Host, "554")
+		l.Host = fmt.Sprintf("%s:%s", l.Host, defaultPort(l.Scheme))
 	}
 	md5UserRealmPwd := fmt.Sprintf("%x", md5.Sum([]byte(fmt.Sprintf("%s:%s:%s", username, realm, password))))
 	md5MethodURL := fmt.Sprintf("%x", md5.Sum([]byte(fmt.Sprintf("%s:%s", method, l.String()))))
@@ -98,7 +102,13 @@ func (client *RTSPClient) checkAuth(method string, resp *Response) (string, erro
 	return "", nil
 }
 
-func CheckAuth(authLine string, method string, sessionNonce string) error {
+// CheckAuth of Authorization header by users in DB, Digest with sessionNonce or Basic is accepted.
+// uri of Digest must be of the path of request URL
+func CheckAuth(authLine string, method string, URL string, sessionNonce string) (*models.User, error) {
+	if strings.HasPrefix(authLine, "Basic ") {
+		return checkBasicAuth(strings.TrimPrefix(authLine, "Basic "))
+	}
+
 	realmRex := regexp.MustCompile(`realm="(.*?)"`)
 	nonceRex := regexp.MustCompile(`nonce="(.*?)"`)
 	usernameRex := regexp.MustCompile(`username="(.*?)"`)
@@ -114,46 +124,251 @@ func CheckAuth(authLine string, method string, sessionNonce string) error {
 	if len(result1) == 2 {
 		realm = result1[1]
 	} else {
-		return fmt.Errorf("CheckAuth error : no realm found")
+		return nil, fmt.Errorf("CheckAuth error : no realm found")
+	}
+	if realm != config.RTSP.Realm {
+		return nil, fmt.Errorf("CheckAuth error : realm not same as %s", config.RTSP.Realm)
 	}
 	result1 = nonceRex.FindStringSubmatch(authLine)
 	if len(result1) == 2 {
 		nonce = result1[1]
 	} else {
-		return fmt.Errorf("CheckAuth error : no nonce found")
+		return nil, fmt.Errorf("CheckAuth error : no nonce found")
 	}
 	if sessionNonce != nonce {
-		return fmt.Errorf("CheckAuth error : sessionNonce not same as nonce")
+		return nil, fmt.Errorf("CheckAuth error : sessionNonce not same as nonce")
 	}
 
 	result1 = usernameRex.FindStringSubmatch(authLine)
 	if len(result1) == 2 {
 		username = result1[1]
 	} else {
-		return fmt.Errorf("CheckAuth error : username not found")
+		return nil, fmt.Errorf("CheckAuth error : username not found")
 	}
 
 	result1 = responseRex.FindStringSubmatch(authLine)
 	if len(result1) == 2 {
 		response = result1[1]
 	} else {
-		return fmt.Errorf("CheckAuth error : response not found")
+		return nil, fmt.Errorf("CheckAuth error : response not found")
 	}
 
 	result1 = uriRex.FindStringSubmatch(authLine)
 	if len(result1) == 2 {
 		uri = result1[1]
 	} else {
-		return fmt.Errorf("CheckAuth error : uri not found")
+		return nil, fmt.Errorf("CheckAuth error : uri not found")
+	}
+	if !sameURIPath(uri, URL) {
+		return nil, fmt.Errorf("CheckAuth error : uri %s not of request %s", uri, URL)
 	}
 
-	// TODO: query user
-
-	md5UserRealmPwd := fmt.Sprintf("%x", md5.Sum([]byte(fmt.Sprintf("%s:%s:%s", username, realm, "user.Password"))))
+	user, ha1, err := getAuthUser(username)
+	if err != nil {
+		return nil, err
+	}
 	md5MethodURL := fmt.Sprintf("%x", md5.Sum([]byte(fmt.Sprintf("%s:%s", method, uri))))
-	myResponse := fmt.Sprintf("%x", md5.Sum([]byte(fmt.Sprintf("%s:%s:%s", md5UserRealmPwd, nonce, md5MethodURL))))
+	myResponse := fmt.Sprintf("%x", md5.Sum([]byte(fmt.Sprintf("%s:%s:%s", ha1, nonce, md5MethodURL))))
 	if myResponse != response {
-		return fmt.Errorf("CheckAuth error : response not equal")
+		return nil, fmt.Errorf("CheckAuth error : response not equal")
+	}
+	return user, nil
+}
+
+// sameURIPath of Digest uri and request URL, uri may be absolute or the path only
+func sameURIPath(uri string, URL string) bool {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return false
+	}
+	l, err := url.Parse(URL)
+	if err != nil {
+		return false
+	}
+	return strings.TrimRight(u.Path, "/") == strings.TrimRight(l.Path, "/")
+}
+
+// checkBasicAuth of base64(username:password)
+func checkBasicAuth(credentials string) (*models.User, error) {
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(credentials))
+	if err != nil {
+		return nil, fmt.Errorf("CheckAuth error : basic credentials malformed")
+	}
+	pair := strings.SplitN(string(decoded), ":", 2)
+	if len(pair) != 2 {
+		return nil, fmt.Errorf("CheckAuth error : basic credentials malformed")
+	}
+
+	user, ha1, err := getAuthUser(pair[0])
+	if err != nil {
+		return nil, err
+	}
+	if models.HA1(pair[0], config.RTSP.Realm, pair[1]) != ha1 {
+		return nil, fmt.Errorf("CheckAuth error : password not equal")
+	}
+	return user, nil
+}
+
+// getUser of DB, replaced in tests
+var getUser = models.GetUser
+
+// getAuthUser enabled with HA1 of realm in config
+func getAuthUser(username string) (*models.User, string, error) {
+	user, err := getUser(username)
+	if err != nil {
+		return nil, "", fmt.Errorf("CheckAuth error : query user %v", err)
+	}
+	if user == nil {
+		return nil, "", fmt.Errorf("CheckAuth error : user not exists")
+	}
+	if !user.Enabled {
+		return nil, "", fmt.Errorf("CheckAuth error : user disabled")
+	}
+	ha1, ok := user.HA1[config.RTSP.Realm]
+	if !ok {
+		return nil, "", fmt.Errorf("CheckAuth error : no password of realm %s", config.RTSP.Realm)
+	}
+	return user, ha1, nil
+}
+
+// defaultPort of RTSP url scheme, 322 for rtsps
+func defaultPort(scheme string) string {
+	if strings.ToLower(scheme) == "rtsps" {
+		return "322"
+	}
+	return "554"
+}
+
+// isAbsoluteURL of control attribute, rtsp://... or rtsps://...
+func isAbsoluteURL(control string) bool {
+	control = strings.ToLower(control)
+	return strings.HasPrefix(control, "rtsp://") || strings.HasPrefix(control, "rtsps://")
+}
+
+// checkPermission of user on path of ANNOUNCE or DESCRIBE, following requests are on the path checked
+func (session *Session) checkPermission(user *models.User, req *Request) error {
+	switch req.Method {
+	case "ANNOUNCE":
+		u, err := url.Parse(req.URL)
+		if err != nil {
+			return err
+		}
+		if !user.CanPublish(u.Path) {
+			return fmt.Errorf("CheckAuth error : user %s can not publish %s", user.Username, u.Path)
+		}
+	case "DESCRIBE":
+		u, err := url.Parse(req.URL)
+		if err != nil {
+			return err
+		}
+		if !user.CanPlay(u.Path) {
+			return fmt.Errorf("CheckAuth error : user %s can not play %s", user.Username, u.Path)
+		}
+	}
+	return nil
+}
+
+// Realm of Digest authorization in config
+func Realm() string {
+	return config.RTSP.Realm
+}
+
+// rulesCache of DB, loaded again after InvalidateRules
+var rulesCache struct {
+	rules  []*models.Rule
+	loaded bool
+	// version is increased by InvalidateRules, rules loaded of an old version are not cached
+	version int
+	lock    sync.Mutex
+}
+
+// getRules cached, loaded from DB if they are changed
+func getRules() ([]*models.Rule, error) {
+	rulesCache.lock.Lock()
+	if rulesCache.loaded {
+		rules := rulesCache.rules
+		rulesCache.lock.Unlock()
+		return rules, nil
+	}
+	version := rulesCache.version
+	rulesCache.lock.Unlock()
+
+	rules, err := models.GetAllRules()
+	if err != nil {
+		return nil, err
+	}
+
+	rulesCache.lock.Lock()
+	if rulesCache.version == version {
+		rulesCache.rules = rules
+		rulesCache.loaded = true
+	}
+	rulesCache.lock.Unlock()
+	return rules, nil
+}
+
+// InvalidateRules cached, called after rules in DB are changed
+func InvalidateRules() {
+	rulesCache.lock.Lock()
+	rulesCache.rules = nil
+	rulesCache.loaded = false
+	rulesCache.version++
+	rulesCache.lock.Unlock()
+}
+
+// authorize publishing or playing of path by rule in DB, or default_policy if none matched.
+// RTSP status code is returned
+func (session *Session) authorize(req *Request, path string, publish bool) int {
+	rules, err := getRules()
+	if err != nil {
+		log.WithError(err).Error("get rules")
+		return 500
+	}
+	var policy *models.Policy
+	if rule := models.MatchRule(rules, path); rule != nil {
+		if publish {
+			policy = rule.Publish
+		} else {
+			policy = rule.Play
+		}
+	}
+	if policy == nil {
+		policy = &models.Policy{Type: config.RTSP.DefaultPolicy}
+	}
+
+	switch policy.Type {
+	case models.PolicyOpen:
+		return 200
+	case models.PolicySigned:
+		// This is to be consistent with API server.
+		return session.authenticate(req)
+	case models.PolicyUser:
+		authLine := req.Header["Authorization"]
+		if authLine == "" {
+			return 401
+		}
+		user, err := CheckAuth(authLine, req.Method, req.URL, session.nonce)
+		if err != nil {
+			log.Errorf("[%s] %v", session, err)
+			return 401
+		}
+		if len(policy.Users) > 0 {
+			if !policy.AllowUser(user.Username) {
+				return 403
+			}
+		} else if publish && !user.CanPublish(path) || !publish && !user.CanPlay(path) {
+			return 403
+		}
+		return 200
+	case models.PolicyIP:
+		host, _, _ := net.SplitHostPort(session.Conn.RemoteAddr().String())
+		if ip := net.ParseIP(host); ip != nil && policy.AllowIP(ip) {
+			return 200
+		}
+		return 403
+	case models.PolicyDeny:
+		return 403
 	}
-	return fmt.Errorf("CheckAuth error : user not exists")
+	log.Errorf("[%s] policy %s unknown", session, policy.Type)
+	return 403
 }
@@ -3,12 +3,30 @@ package rtsp
 import "github.com/go-ini/ini"
 
 type ConfigRTSP struct {
-	NetworkBuffer       int `ini:"network_buffer"`
-	Timeout             int `ini:"timeout"`
-	AuthorizationEnable int `ini:"authorization_enable"`
-	CloseOld            int `ini:"close_old"`
-	GopCacheEnable      int `ini:"gop_cache_enable"`
-	Port                int `ini:"port"`
+	NetworkBuffer       int    `ini:"network_buffer"`
+	Timeout             int    `ini:"timeout"`
+	AuthorizationEnable int    `ini:"authorization_enable"`
+	Realm               string `ini:"realm"`
+	DefaultPolicy       string `ini:"default_policy"`
+	SessionTimeout      int    `ini:"session_timeout"`
+	CloseOld            int    `ini:"close_old"`
+	KeepPlayers         int    `ini:"keep_players"`
+	KeepPlayersTimeout  int    `ini:"keep_players_timeout"`
+	GopCacheEnable      int    `ini:"gop_cache_enable"`
+	NoConnectionTimeout int    `ini:"check_no_connection_interval"`
+	Port                int    `ini:"port"`
+	TLSPort             int    `ini:"tls_port"`
+	TLSSkipVerify       int    `ini:"tls_skip_verify"`
+	MulticastRange      string `ini:"multicast_range"`
+	MulticastPort       int    `ini:"multicast_port"`
+}
+
+type ConfigRTP struct {
+	MaxSize        int `ini:"rtp_max_size"`
+	ReceiveTimeout int `ini:"receive_timeout"`
+	MuxPort        int `ini:"mux_port"`
+	PortMin        int `ini:"port_min"`
+	PortMax        int `ini:"port_max"`
 }
 
 type ConfigRecord struct {
@@ -21,6 +39,18 @@ type ConfigLog struct {
 	Level string `ini:"level"`
 }
 
+// ConfigHook of HTTP callbacks, hook is disabled if URL is empty
+type ConfigHook struct {
+	OnPublish         string `ini:"on_publish"`
+	OnPublishTimeout  int    `ini:"on_publish_timeout"`
+	OnPublishFailOpen int    `ini:"on_publish_fail_open"`
+	OnPlay            string `ini:"on_play"`
+	OnPlayTimeout     int    `ini:"on_play_timeout"`
+	OnPlayFailOpen    int    `ini:"on_play_fail_open"`
+	OnDone            string `ini:"on_done"`
+	OnDoneTimeout     int    `ini:"on_done_timeout"`
+}
+
 type ConfigPlayer struct {
 	SendQueueLength int `ini:"send_queue_length"`
 }
@@ -31,6 +61,8 @@ type Config struct {
 	RTSP   ConfigRTSP   `ini:"rtsp"`
 	Log    ConfigLog    `ini:"log"`
 	Player ConfigPlayer `ini:"player"`
+	RTP    ConfigRTP    `ini:"rtp"`
+	Hook   ConfigHook   `ini:"hook"`
 }
 
 var config *Config
@@ -41,9 +73,25 @@ func initConfig() error {
 			NetworkBuffer:       204800,
 			Timeout:             5 * 60 * 1000,
 			AuthorizationEnable: 0,
+			Realm:               "EasyDarwin",
+			DefaultPolicy:       "signed",
+			SessionTimeout:      0,
 			CloseOld:            0,
+			KeepPlayers:         0,
+			KeepPlayersTimeout:  10,
 			GopCacheEnable:      0,
+			NoConnectionTimeout: 30,
 			Port:                554,
+			MulticastPort:       30000,
+		},
+		RTP: ConfigRTP{
+			MaxSize:        1200,
+			ReceiveTimeout: 30,
+		},
+		Hook: ConfigHook{
+			OnPublishTimeout: 1,
+			OnPlayTimeout:    1,
+			OnDoneTimeout:    3,
 		},
 		Player: ConfigPlayer{
 			SendQueueLength: 128,
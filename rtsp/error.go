@@ -9,4 +9,10 @@ var (
 	errorDecodeRTP    = errors.New("error when deocde RTP")
 	ErrorRTPTooShort  = errors.New("RTP packet is too short")
 	ErrorSDPMalformed = errors.New("SDP malformed")
+	ErrorPSMalformed  = errors.New("PS malformed")
+	ErrorTimeout      = errors.New("Timeout")
+	ErrorStoped       = errors.New("Stoped")
+
+	ErrorMulticastDisabled  = errors.New("Multicast disabled")
+	ErrorMulticastExhausted = errors.New("Multicast groups exhausted")
 )
@@ -0,0 +1,123 @@
+package rtsp
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Hooks of HTTP callback
+const (
+	HookOnPublish = "on_publish"
+	HookOnPlay    = "on_play"
+	HookOnDone    = "on_done"
+)
+
+// hookAuthTimeout of on_publish and on_play if not configured, request is waiting for them
+const hookAuthTimeout = time.Second
+
+// hookConfig of URL, timeout and allowed if callback failed
+func hookConfig(name string) (hookURL string, timeout time.Duration, failOpen bool) {
+	hook := config.Hook
+	switch name {
+	case HookOnPublish:
+		return hook.OnPublish, authTimeout(hook.OnPublishTimeout), hook.OnPublishFailOpen != 0
+	case HookOnPlay:
+		return hook.OnPlay, authTimeout(hook.OnPlayTimeout), hook.OnPlayFailOpen != 0
+	case HookOnDone:
+		return hook.OnDone, time.Duration(hook.OnDoneTimeout) * time.Second, true
+	}
+	return "", 0, true
+}
+
+func authTimeout(timeout int) time.Duration {
+	if timeout <= 0 {
+		return hookAuthTimeout
+	}
+	return time.Duration(timeout) * time.Second
+}
+
+// callHook of authorization, allowed if 2xx responded. Request waits for it, in a short timeout.
+// It is allowed if hook is not configured, or fail_open of hook if callback failed
+func callHook(name string, values url.Values) bool {
+	hookURL, timeout, failOpen := hookConfig(name)
+	if hookURL == "" {
+		return true
+	}
+	ok, err := postHook(name, hookURL, timeout, values)
+	if err != nil {
+		log.WithError(err).WithField("url", hookURL).Errorf("hook %s, fail open[%v]", name, failOpen)
+		return failOpen
+	}
+	return ok
+}
+
+// notifyHook of notification in background, result is ignored
+func notifyHook(name string, values url.Values) {
+	hookURL, timeout, _ := hookConfig(name)
+	if hookURL == "" {
+		return
+	}
+	go func() {
+		if _, err := postHook(name, hookURL, timeout, values); err != nil {
+			log.WithError(err).WithField("url", hookURL).Errorf("hook %s", name)
+		}
+	}()
+}
+
+// postHook of values as form, true if 2xx responded
+func postHook(name string, hookURL string, timeout time.Duration, values url.Values) (bool, error) {
+	values.Set("action", name)
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.PostForm(hookURL, values)
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		log.WithField("url", hookURL).Warnf("hook %s rejected, %s", name, resp.Status)
+		return false, nil
+	}
+	return true, nil
+}
+
+// hookValues of session request
+func (session *Session) hookValues() url.Values {
+	return url.Values{
+		"id":   {session.ID},
+		"type": {session.Type.String()},
+		"path": {session.Path},
+		"url":  {session.URL},
+		"addr": {session.Conn.RemoteAddr().String()},
+	}
+}
+
+// pusherHookValues of pusher
+func pusherHookValues(pusher Pusher) url.Values {
+	return url.Values{
+		"id":       {pusher.ID()},
+		"type":     {"pusher"},
+		"mode":     {pusher.Mode().String()},
+		"path":     {pusher.Path()},
+		"source":   {pusher.Source()},
+		"inBytes":  {strconv.FormatUint(uint64(pusher.InBytes()), 10)},
+		"outBytes": {strconv.FormatUint(uint64(pusher.OutBytes()), 10)},
+	}
+}
+
+// hookPlay of player session, allowed to play or not
+func (session *Session) hookPlay() bool {
+	return callHook(HookOnPlay, session.hookValues())
+}
+
+// hookPlayDone of player added to pusher, on_done is called when session stops
+func (session *Session) hookPlayDone() {
+	values := session.hookValues()
+	session.addStopHandle(func() {
+		values.Set("inBytes", strconv.FormatUint(uint64(session.InBytes), 10))
+		values.Set("outBytes", strconv.FormatUint(uint64(session.OutBytes), 10))
+		notifyHook(HookOnDone, values)
+	})
+}
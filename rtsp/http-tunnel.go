@@ -0,0 +1,165 @@
+package rtsp
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+)
+
+// TunnelContentType of RTSP over HTTP
+const TunnelContentType = "application/x-rtsp-tunnelled"
+
+// tunnelPairTimeout of GET waiting for POST with the same x-sessioncookie
+const tunnelPairTimeout = 10 * time.Second
+
+// IsTunnelRequest of RTSP over HTTP, GET or POST with x-sessioncookie
+func IsTunnelRequest(r *http.Request) bool {
+	if r.Header.Get("x-sessioncookie") == "" {
+		return false
+	}
+	return r.Method == http.MethodGet || r.Method == http.MethodPost
+}
+
+// tunnelConn of RTSP over HTTP, RTSP requests are read from base64 body of POST,
+// and responses and RTP are written to GET
+type tunnelConn struct {
+	get    net.Conn
+	post   net.Conn
+	reader io.Reader
+}
+
+func (conn *tunnelConn) Read(b []byte) (int, error) {
+	return conn.reader.Read(b)
+}
+
+func (conn *tunnelConn) Write(b []byte) (int, error) {
+	return conn.get.Write(b)
+}
+
+func (conn *tunnelConn) Close() error {
+	conn.post.Close()
+	return conn.get.Close()
+}
+
+func (conn *tunnelConn) LocalAddr() net.Addr {
+	return conn.get.LocalAddr()
+}
+
+func (conn *tunnelConn) RemoteAddr() net.Addr {
+	return conn.get.RemoteAddr()
+}
+
+func (conn *tunnelConn) SetDeadline(t time.Time) error {
+	conn.post.SetDeadline(t)
+	return conn.get.SetDeadline(t)
+}
+
+func (conn *tunnelConn) SetReadDeadline(t time.Time) error {
+	return conn.post.SetReadDeadline(t)
+}
+
+func (conn *tunnelConn) SetWriteDeadline(t time.Time) error {
+	return conn.get.SetWriteDeadline(t)
+}
+
+// base64Reader of POST body, every request may be encoded and padded by itself
+type base64Reader struct {
+	reader  io.Reader
+	buf     []byte
+	quantum []byte
+	decoded []byte
+	err     error
+}
+
+func (r *base64Reader) Read(p []byte) (int, error) {
+	for len(r.decoded) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		n, err := r.reader.Read(r.buf)
+		for _, c := range r.buf[:n] {
+			if c == '\r' || c == '\n' || c == ' ' || c == '\t' {
+				continue
+			}
+			r.quantum = append(r.quantum, c)
+			if len(r.quantum) < 4 {
+				continue
+			}
+			out := make([]byte, 3)
+			m, derr := base64.StdEncoding.Decode(out, r.quantum)
+			if derr != nil {
+				return 0, derr
+			}
+			r.decoded = append(r.decoded, out[:m]...)
+			r.quantum = r.quantum[:0]
+		}
+		r.err = err
+	}
+	n := copy(p, r.decoded)
+	r.decoded = r.decoded[n:]
+	return n, nil
+}
+
+// ServeTunnel of RTSP over HTTP. GET is kept for output and paired with POST of
+// the same x-sessioncookie for input, then served as a normal session
+func (server *Server) ServeTunnel(w http.ResponseWriter, r *http.Request) {
+	cookie := r.Header.Get("x-sessioncookie")
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "RTSP over HTTP not supported", http.StatusInternalServerError)
+		return
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		log.WithError(err).Error("RTSP over HTTP hijack")
+		return
+	}
+	// deadlines of HTTP server
+	conn.SetDeadline(time.Time{})
+
+	switch r.Method {
+	case http.MethodGet:
+		fmt.Fprintf(rw, "HTTP/1.0 200 OK\r\nServer: EasyDarwin\r\nConnection: close\r\nCache-Control: no-store\r\nPragma: no-cache\r\nContent-Type: %s\r\n\r\n", TunnelContentType)
+		if err := rw.Flush(); err != nil {
+			conn.Close()
+			return
+		}
+		tunnel := &tunnelConn{get: conn}
+		server.tunnelsLock.Lock()
+		if old, ok := server.tunnels[cookie]; ok {
+			old.get.Close()
+		}
+		server.tunnels[cookie] = tunnel
+		server.tunnelsLock.Unlock()
+
+		time.AfterFunc(tunnelPairTimeout, func() {
+			server.tunnelsLock.Lock()
+			defer server.tunnelsLock.Unlock()
+			if server.tunnels[cookie] == tunnel {
+				delete(server.tunnels, cookie)
+				log.Warnf("RTSP over HTTP [%s] without POST", cookie)
+				conn.Close()
+			}
+		})
+	case http.MethodPost:
+		server.tunnelsLock.Lock()
+		tunnel, ok := server.tunnels[cookie]
+		delete(server.tunnels, cookie)
+		server.tunnelsLock.Unlock()
+		if !ok {
+			log.Warnf("RTSP over HTTP [%s] without GET", cookie)
+			conn.Close()
+			return
+		}
+		tunnel.post = conn
+		// body read by HTTP server is buffered in rw
+		tunnel.reader = &base64Reader{reader: rw.Reader, buf: make([]byte, 4096)}
+
+		log.Infof("RTSP over HTTP [%s] from %s", cookie, conn.RemoteAddr())
+		session := NewSession(server, tunnel)
+		go session.Start()
+	}
+}
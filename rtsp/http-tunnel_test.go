@@ -0,0 +1,54 @@
+package rtsp
+
+import (
+	"encoding/base64"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBase64Reader(t *testing.T) {
+	options := "OPTIONS rtsp://host/live/1 RTSP/1.0\r\nCSeq: 1\r\n\r\n"
+	describe := "DESCRIBE rtsp://host/live/1 RTSP/1.0\r\nCSeq: 2\r\n\r\n"
+	encode := base64.StdEncoding.EncodeToString
+
+	tests := []struct {
+		name   string
+		reader io.Reader
+		data   string
+		err    bool
+	}{
+		{name: "one request", reader: strings.NewReader(encode([]byte(options))), data: options},
+		{name: "one byte a read", reader: iotest.OneByteReader(strings.NewReader(encode([]byte(options)))), data: options},
+		{
+			name:   "requests padded by themselves",
+			reader: io.MultiReader(strings.NewReader(encode([]byte("ab"))), strings.NewReader(encode([]byte("cde")))),
+			data:   "abcde",
+		},
+		{
+			name:   "requests in one read",
+			reader: strings.NewReader(encode([]byte(options)) + encode([]byte(describe))),
+			data:   options + describe,
+		},
+		{name: "line breaks skipped", reader: strings.NewReader("T1BU\r\nSU9O\nUw==\r\n"), data: "OPTIONS"},
+		{name: "partial quantum at EOF dropped", reader: strings.NewReader("YWJj" + "ZA"), data: "abc"},
+		{name: "not base64", reader: strings.NewReader("YW*j"), err: true},
+		{name: "empty", reader: strings.NewReader(""), data: ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reader := &base64Reader{reader: test.reader, buf: make([]byte, 16)}
+			data, err := ioutil.ReadAll(iotest.OneByteReader(reader))
+			if test.err {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, test.data, string(data))
+		})
+	}
+}
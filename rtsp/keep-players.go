@@ -0,0 +1,200 @@
+package rtsp
+
+import (
+	"time"
+
+	"github.com/EasyDarwin/EasyDarwin/utils"
+	"github.com/benbjohnson/immutable"
+)
+
+// playersHandover of pusher, players of a stoped pusher are kept for the next one of the path
+type playersHandover interface {
+	detachPlayers() []*_Player
+	attachPlayer(player *_Player)
+}
+
+// keptPlayers of a path, stoped if no pusher comes back in keep_players_timeout
+type keptPlayers struct {
+	players []*_Player
+	timer   *time.Timer
+}
+
+// keepable player has its RTP rewritten, multicast ones follow the sender of pusher
+func keepable(player Player) (*_Player, bool) {
+	_player, ok := player.(*_Player)
+	if !ok || _player.rewriter == nil || _player.TransType() == TRANS_TYPE_MULTICAST {
+		return nil, false
+	}
+	return _player, true
+}
+
+func detachPlayers(players **immutable.Map, locker *utils.SpinLock) []*_Player {
+	detached := make([]*_Player, 0)
+	locker.Lock()
+	for itPlayer := (*players).Iterator(); !itPlayer.Done(); {
+		ID, _player := itPlayer.Next()
+		if player, ok := keepable(_player.(Player)); ok {
+			*players = (*players).Delete(ID)
+			detached = append(detached, player)
+		}
+	}
+	locker.Unlock()
+	return detached
+}
+
+func attachPlayer(players **immutable.Map, locker *utils.SpinLock, player *_Player) {
+	locker.Lock()
+	*players = (*players).Set(player.ID(), player)
+	locker.Unlock()
+}
+
+func (pusher *_Pusher) detachPlayers() []*_Player {
+	return detachPlayers(&pusher.players, pusher.playersLocker)
+}
+
+func (pusher *_Pusher) attachPlayer(player *_Player) {
+	attachPlayer(&pusher.players, pusher.playersLocker, player)
+}
+
+func (pusher *defaultPusher) detachPlayers() []*_Player {
+	return detachPlayers(&pusher.players, pusher.playersLocker)
+}
+
+func (pusher *defaultPusher) attachPlayer(player *_Player) {
+	attachPlayer(&pusher.players, pusher.playersLocker, player)
+}
+
+// keepPlayers of stoping pusher, before players are cleared.
+// Handed over at once if the path is pushed again already, or kept for keep_players_timeout
+func (server *Server) keepPlayers(pusher Pusher) {
+	if config.RTSP.KeepPlayers == 0 {
+		return
+	}
+	from, ok := pusher.(playersHandover)
+	if !ok {
+		return
+	}
+	players := from.detachPlayers()
+	if len(players) == 0 {
+		return
+	}
+
+	result := make(chan int, 1)
+	server.pusherCommandChannel <- func() {
+		if _current, ok := server.pushers.Get(pusher.Path()); ok && _current.(Pusher) != pusher {
+			handOverPlayers(_current.(Pusher), players)
+		} else {
+			server._keepPlayers(pusher.Path(), players)
+		}
+		result <- 1
+	}
+	<-result
+	log.Infof("%d players of pusher[%s] kept", len(players), pusher.ID())
+}
+
+func (server *Server) _keepPlayers(path string, players []*_Player) {
+	server.keptPlayersLock.Lock()
+	defer server.keptPlayersLock.Unlock()
+
+	kept, ok := server.keptPlayers[path]
+	if !ok {
+		kept = &keptPlayers{}
+		kept.timer = time.AfterFunc(time.Duration(config.RTSP.KeepPlayersTimeout)*time.Second, func() {
+			server.dropKeptPlayers(path, kept)
+		})
+		server.keptPlayers[path] = kept
+	}
+	kept.players = append(kept.players, players...)
+}
+
+// dropKeptPlayers of path if no pusher adopted them
+func (server *Server) dropKeptPlayers(path string, kept *keptPlayers) {
+	server.keptPlayersLock.Lock()
+	if server.keptPlayers[path] != kept {
+		server.keptPlayersLock.Unlock()
+		return
+	}
+	delete(server.keptPlayers, path)
+	server.keptPlayersLock.Unlock()
+
+	log.Infof("%d players of path[%s] kept, but no pusher in %d seconds", len(kept.players), path, config.RTSP.KeepPlayersTimeout)
+	for _, player := range kept.players {
+		player.Stop()
+	}
+}
+
+// adoptPlayers kept of the path by new pusher, in pusher loop
+func (server *Server) adoptPlayers(pusher Pusher) {
+	server.keptPlayersLock.Lock()
+	kept, ok := server.keptPlayers[pusher.Path()]
+	if ok {
+		delete(server.keptPlayers, pusher.Path())
+		kept.timer.Stop()
+	}
+	server.keptPlayersLock.Unlock()
+
+	if ok {
+		handOverPlayers(pusher, kept.players)
+	}
+}
+
+// handOverPlayers to pusher, players keep their connections and RTP is rewritten
+// to continue the old sequence
+func handOverPlayers(pusher Pusher, players []*_Player) {
+	to, ok := pusher.(playersHandover)
+	if !ok {
+		for _, player := range players {
+			go player.Stop()
+		}
+		return
+	}
+
+	count := 0
+	for _, player := range players {
+		if !player.sameMedia(pusher) {
+			log.Infof("player[%s] stoped, media of pusher[%s] not the same as negotiated", player.ID(), pusher.ID())
+			go player.Stop()
+			continue
+		}
+		if player.switchPusher(pusher, to) {
+			count++
+		}
+	}
+	if count > 0 {
+		log.Infof("%d players handed over to pusher[%s]", count, pusher.ID())
+	}
+}
+
+// sameMedia of pusher as negotiated by player, or player can not decode RTP of it
+func (player *_Player) sameMedia(pusher Pusher) bool {
+	if !sdpSameMedia(player.sdpRaw, pusher.SDPRaw()) {
+		return false
+	}
+	aControl := pusher.AControl()
+	if len(aControl) != len(player.Session.AControl) {
+		return false
+	}
+	for i := range aControl {
+		if aControl[i] != player.Session.AControl[i] {
+			return false
+		}
+	}
+	return pusher.VControl() == player.Session.VControl
+}
+
+// sdpSameMedia if codec, payload type, clock rate and control of tracks are the same
+func sdpSameMedia(negotiated string, sdpRaw string) bool {
+	from := ParseSDP(negotiated)
+	to := ParseSDP(sdpRaw)
+	if len(from) != len(to) {
+		return false
+	}
+	for avType, info := range from {
+		next, ok := to[avType]
+		if !ok || next.Codec != info.Codec || next.PayloadType != info.PayloadType ||
+			next.TimeScale != info.TimeScale || next.Control != info.Control {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,33 @@
+package rtsp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSDPSameMedia(t *testing.T) {
+	h264 := "v=0\r\nm=video 0 RTP/AVP 96\r\na=rtpmap:96 H264/90000\r\na=control:trackID=0\r\n" +
+		"m=audio 0 RTP/AVP 8\r\na=rtpmap:8 PCMA/8000\r\na=control:trackID=1\r\n"
+	tests := []struct {
+		name   string
+		sdpRaw string
+		same   bool
+	}{
+		{name: "same", sdpRaw: h264, same: true},
+		{name: "codec", sdpRaw: "v=0\r\nm=video 0 RTP/AVP 96\r\na=rtpmap:96 H265/90000\r\na=control:trackID=0\r\n" +
+			"m=audio 0 RTP/AVP 8\r\na=rtpmap:8 PCMA/8000\r\na=control:trackID=1\r\n"},
+		{name: "payload type", sdpRaw: "v=0\r\nm=video 0 RTP/AVP 98\r\na=rtpmap:98 H264/90000\r\na=control:trackID=0\r\n" +
+			"m=audio 0 RTP/AVP 8\r\na=rtpmap:8 PCMA/8000\r\na=control:trackID=1\r\n"},
+		{name: "control", sdpRaw: "v=0\r\nm=video 0 RTP/AVP 96\r\na=rtpmap:96 H264/90000\r\na=control:streamid=0\r\n" +
+			"m=audio 0 RTP/AVP 8\r\na=rtpmap:8 PCMA/8000\r\na=control:streamid=1\r\n"},
+		{name: "clock rate", sdpRaw: "v=0\r\nm=video 0 RTP/AVP 96\r\na=rtpmap:96 H264/90000\r\na=control:trackID=0\r\n" +
+			"m=audio 0 RTP/AVP 8\r\na=rtpmap:8 PCMA/16000\r\na=control:trackID=1\r\n"},
+		{name: "audio missing", sdpRaw: "v=0\r\nm=video 0 RTP/AVP 96\r\na=rtpmap:96 H264/90000\r\na=control:trackID=0\r\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.same, sdpSameMedia(h264, test.sdpRaw))
+		})
+	}
+}
@@ -0,0 +1,359 @@
+package rtsp
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// multicastTTL of groups, TTL of system default which keeps multicast in LAN
+const multicastTTL = 1
+
+// MulticastSender is the player of pusher sending RTP to a multicast group.
+// It is shared by all multicast players of the pusher, and added to pusher
+// only when there is one playing at least.
+//
+// Ports of group are: video RTP/RTCP on port and port+1, audio of channel i on port+2+2i and port+3+2i
+type MulticastSender struct {
+	pusher   Pusher
+	group    net.IP
+	port     int
+	startAt  time.Time
+	outBytes uint64
+	// playing sessions, key is session ID
+	sessions map[string]bool
+	queue    chan *RTPPack
+	// sending queue of the running Start, only one is sending for a queue
+	sending chan *RTPPack
+	// rtcp of SR sent to group, RTCP of pusher is not forwarded
+	rtcp *rtcpSender
+	lock sync.Mutex
+	// joinLock serializes join and leave, which add to and remove from pusher out of lock
+	joinLock sync.Mutex
+}
+
+func newMulticastSender(pusher Pusher, group net.IP) *MulticastSender {
+	return &MulticastSender{
+		pusher:   pusher,
+		group:    group,
+		port:     config.RTSP.MulticastPort,
+		sessions: make(map[string]bool),
+		rtcp:     newRTCPSender(pusher.SDPRaw()),
+	}
+}
+
+// Group of multicast
+func (sender *MulticastSender) Group() net.IP {
+	return sender.group
+}
+
+// VideoPort of RTP, RTCP is on the next port
+func (sender *MulticastSender) VideoPort() int {
+	return sender.port
+}
+
+// AudioPort of RTP of channel, RTCP is on the next port
+func (sender *MulticastSender) AudioPort(channel int) int {
+	return sender.port + 2 + 2*channel
+}
+
+func (sender *MulticastSender) packPort(pack *RTPPack) int {
+	switch pack.Type {
+	case RTP_TYPE_VIDEO:
+		return sender.VideoPort()
+	case RTP_TYPE_VIDEOCONTROL:
+		return sender.VideoPort() + 1
+	case RTP_TYPE_AUDIO:
+		return sender.AudioPort(pack.Channel)
+	case RTP_TYPE_AUDIOCONTROL:
+		return sender.AudioPort(pack.Channel) + 1
+	}
+	return -1
+}
+
+// join of session playing, false if it is joined already.
+// Sender is added to pusher by the first one, out of lock for GOP cache is queued at once
+func (sender *MulticastSender) join(sessionID string) bool {
+	sender.joinLock.Lock()
+	defer sender.joinLock.Unlock()
+
+	sender.lock.Lock()
+	if sender.sessions[sessionID] {
+		sender.lock.Unlock()
+		return false
+	}
+	sender.sessions[sessionID] = true
+	start := sender.queue == nil
+	if start {
+		sender.queue = make(chan *RTPPack, config.Player.SendQueueLength)
+	}
+	sender.lock.Unlock()
+
+	if start {
+		sender.pusher.AddPlayer(sender)
+		log.Infof("multicast %s:%d of pusher[%s] start", sender.group, sender.port, sender.pusher.Path())
+	}
+	return true
+}
+
+// leave of session, sender is removed from pusher by the last one.
+// It is out of lock too, on-demand pusher may stop and remove the sender
+func (sender *MulticastSender) leave(sessionID string) {
+	sender.joinLock.Lock()
+	defer sender.joinLock.Unlock()
+
+	sender.lock.Lock()
+	if !sender.sessions[sessionID] {
+		sender.lock.Unlock()
+		return
+	}
+	delete(sender.sessions, sessionID)
+	stop := len(sender.sessions) == 0 && sender.queue != nil
+	if stop {
+		close(sender.queue)
+		sender.queue = nil
+	}
+	sender.lock.Unlock()
+
+	if stop {
+		sender.pusher.RemovePlayer(sender)
+		log.Infof("multicast %s:%d of pusher[%s] stop", sender.group, sender.port, sender.pusher.Path())
+	}
+}
+
+// SDP of pusher with c= of group and m= of ports
+func (sender *MulticastSender) SDP(raw string) string {
+	connection := fmt.Sprintf("c=IN IP4 %s/%d", sender.group, multicastTTL)
+	lines := make([]string, 0)
+	audioChannel := 0
+	connectionAdded := false
+	for _, line := range strings.Split(raw, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if line == "" || strings.HasPrefix(line, "c=") {
+			continue
+		}
+		if !connectionAdded && (strings.HasPrefix(line, "t=") || strings.HasPrefix(line, "m=")) {
+			lines = append(lines, connection)
+			connectionAdded = true
+		}
+		if strings.HasPrefix(line, "m=") {
+			fields := strings.Fields(line)
+			if len(fields) > 1 {
+				switch strings.TrimPrefix(fields[0], "m=") {
+				case "video":
+					fields[1] = fmt.Sprintf("%d", sender.VideoPort())
+				case "audio":
+					fields[1] = fmt.Sprintf("%d", sender.AudioPort(audioChannel))
+					audioChannel++
+				}
+				line = strings.Join(fields, " ")
+			}
+		}
+		lines = append(lines, line)
+	}
+	if !connectionAdded {
+		lines = append(lines, connection)
+	}
+	return strings.Join(lines, "\r\n") + "\r\n"
+}
+
+// ID of multicast sender
+func (sender *MulticastSender) ID() string {
+	return fmt.Sprintf("multicast-%s", sender.pusher.ID())
+}
+
+// Path of pusher
+func (sender *MulticastSender) Path() string {
+	return sender.pusher.Path()
+}
+
+// TransType of multicast sender
+func (sender *MulticastSender) TransType() TransType {
+	return TRANS_TYPE_MULTICAST
+}
+
+// InBytes of multicast sender
+func (sender *MulticastSender) InBytes() uint {
+	return 0
+}
+
+// OutBytes to multicast group
+func (sender *MulticastSender) OutBytes() uint {
+	return uint(atomic.LoadUint64(&sender.outBytes))
+}
+
+// StartAt of multicast sender
+func (sender *MulticastSender) StartAt() time.Time {
+	sender.lock.Lock()
+	defer sender.lock.Unlock()
+	return sender.startAt
+}
+
+// Pause of multicast sender is not supported, it is shared by players
+func (sender *MulticastSender) Pause(paused bool) {
+}
+
+// QueueRTP from pusher
+func (sender *MulticastSender) QueueRTP(pack *RTPPack) Player {
+	if pack == nil {
+		return sender
+	}
+	sender.lock.Lock()
+	defer sender.lock.Unlock()
+
+	if sender.queue == nil {
+		return sender
+	}
+	select {
+	case sender.queue <- pack:
+	default:
+		log.WithField("id", sender.ID()).Debug("multicast queue full, drop it")
+	}
+	return sender
+}
+
+// Start sending until all sessions leave, it is called by Pusher.AddPlayer.
+// It returns at once if the queue is sending already, e.g. sender is added to a new pusher
+func (sender *MulticastSender) Start() {
+	sender.lock.Lock()
+	queue := sender.queue
+	if queue == nil || sender.sending == queue {
+		sender.lock.Unlock()
+		return
+	}
+	sender.sending = queue
+	sender.startAt = time.Now()
+	sender.lock.Unlock()
+
+	conns := make(map[int]*net.UDPConn)
+	defer func() {
+		for _, conn := range conns {
+			conn.Close()
+		}
+		sender.lock.Lock()
+		if sender.sending == queue {
+			sender.sending = nil
+		}
+		sender.lock.Unlock()
+	}()
+	for pack := range queue {
+		if err := sender.send(conns, pack); err != nil {
+			log.WithError(err).WithField("id", sender.ID()).Error("multicast dial")
+			return
+		}
+		now := time.Now()
+		sender.rtcp.sent(pack, now)
+		source, _ := sender.pusher.(rtcpSource)
+		for _, sr := range sender.rtcp.reports(source, now) {
+			if err := sender.send(conns, sr); err != nil {
+				log.WithError(err).WithField("id", sender.ID()).Error("multicast dial")
+				return
+			}
+		}
+	}
+}
+
+// send pack to port of group, error is returned only if port can not be dialed
+func (sender *MulticastSender) send(conns map[int]*net.UDPConn, pack *RTPPack) error {
+	port := sender.packPort(pack)
+	if port < 0 {
+		return nil
+	}
+	conn, ok := conns[port]
+	if !ok {
+		var err error
+		conn, err = net.DialUDP("udp", nil, &net.UDPAddr{IP: sender.group, Port: port})
+		if err != nil {
+			return err
+		}
+		if err := conn.SetWriteBuffer(config.RTSP.NetworkBuffer); err != nil {
+			log.Errorf("multicast conn set write buffer error, %v", err)
+		}
+		conns[port] = conn
+	}
+	n, err := conn.Write(pack.Buffer.Bytes())
+	if err != nil {
+		log.WithError(err).WithField("id", sender.ID()).Error("multicast write")
+		return nil
+	}
+	atomic.AddUint64(&sender.outBytes, uint64(n))
+	return nil
+}
+
+// Stop sending, all sessions are left
+func (sender *MulticastSender) Stop() {
+	sender.lock.Lock()
+	defer sender.lock.Unlock()
+
+	sender.sessions = make(map[string]bool)
+	if sender.queue != nil {
+		close(sender.queue)
+		sender.queue = nil
+	}
+}
+
+// MulticastEnabled if range of groups is configured
+func (server *Server) MulticastEnabled() bool {
+	return config.RTSP.MulticastRange != ""
+}
+
+// GetMulticastSender of pusher, a group in range is allocated for the new one.
+// The group is kept until pusher is removed
+func (server *Server) GetMulticastSender(pusher Pusher) (*MulticastSender, error) {
+	if !server.MulticastEnabled() {
+		return nil, ErrorMulticastDisabled
+	}
+	server.multicastLock.Lock()
+	defer server.multicastLock.Unlock()
+
+	if sender, ok := server.multicastSenders[pusher.ID()]; ok {
+		return sender, nil
+	}
+
+	ip, ipNet, err := net.ParseCIDR(config.RTSP.MulticastRange)
+	if err != nil || !ip.IsMulticast() {
+		log.WithField("range", config.RTSP.MulticastRange).Error("multicast range malformed")
+		return nil, ErrorMulticastDisabled
+	}
+	used := make(map[string]bool)
+	for _, sender := range server.multicastSenders {
+		used[sender.group.String()] = true
+	}
+	// the first address of range is skipped like a network address
+	group := nextIP(ip.Mask(ipNet.Mask))
+	for ; ipNet.Contains(group); group = nextIP(group) {
+		if !used[group.String()] {
+			sender := newMulticastSender(pusher, group)
+			server.multicastSenders[pusher.ID()] = sender
+			return sender, nil
+		}
+	}
+	return nil, ErrorMulticastExhausted
+}
+
+// removeMulticastSender of pusher and release the group
+func (server *Server) removeMulticastSender(pusher Pusher) {
+	server.multicastLock.Lock()
+	sender, ok := server.multicastSenders[pusher.ID()]
+	delete(server.multicastSenders, pusher.ID())
+	server.multicastLock.Unlock()
+
+	if ok {
+		sender.Stop()
+	}
+}
+
+func nextIP(ip net.IP) net.IP {
+	next := make(net.IP, len(ip))
+	copy(next, ip)
+	for i := len(next) - 1; i >= 0; i-- {
+		next[i]++
+		if next[i] != 0 {
+			break
+		}
+	}
+	return next
+}
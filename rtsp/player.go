@@ -1,6 +1,7 @@
 package rtsp
 
 import (
+	"sync/atomic"
 	"time"
 )
 
@@ -10,6 +11,8 @@ type Player interface {
 	QueueRTP(pack *RTPPack) Player
 	Start()
 	Stop()
+	// Pause drops RTP until resumed, without tearing down
+	Pause(paused bool)
 	// status
 	ID() string
 	Path() string
@@ -23,6 +26,12 @@ type _Player struct {
 	*Session
 	Pusher Pusher
 	queue  chan *RTPPack
+	paused int32
+	rtcp   *rtcpSender
+	// rewriter of RTP if players are kept when pusher is replaced, nil if disabled
+	rewriter *rtpRewriter
+	// sdpRaw negotiated with player, pusher replacing must have the same media
+	sdpRaw string
 }
 
 // NewPlayer of network session
@@ -31,9 +40,23 @@ func NewPlayer(session *Session, pusher Pusher) Player {
 		Session: session,
 		Pusher:  pusher,
 		queue:   make(chan *RTPPack, config.Player.SendQueueLength),
+		rtcp:    newRTCPSender(pusher.SDPRaw()),
+		sdpRaw:  pusher.SDPRaw(),
 	}
-	session.StopHandles = append(session.StopHandles, func() {
-		pusher.RemovePlayer(player)
+	if config.RTSP.KeepPlayers != 0 {
+		player.rewriter = newRTPRewriter(pusher.SDPRaw())
+	}
+	session.RTPHandles = append(session.RTPHandles, player.handleRTCP)
+	session.addStopHandle(func() {
+		// pusher may be replaced since then, even while removing
+		for pusher := player.getPusher(); ; {
+			pusher.RemovePlayer(player)
+			current := player.getPusher()
+			if current == pusher {
+				break
+			}
+			pusher = current
+		}
 		close(player.queue)
 	})
 	return player
@@ -68,6 +91,14 @@ func (player *_Player) QueueRTP(pack *RTPPack) Player {
 		log.Debug("player queue enter nil pack, drop it")
 		return player
 	}
+	if atomic.LoadInt32(&player.paused) != 0 {
+		return player
+	}
+	if player.rewriter != nil {
+		if pack = player.rewriter.rewrite(pack, time.Now()); pack == nil {
+			return player
+		}
+	}
 	select {
 	case player.queue <- pack:
 	default:
@@ -95,6 +126,16 @@ func (player *_Player) Start() {
 		if err := player.Session.SendRTP(pack); err != nil {
 			log.Error(err)
 		}
+		now := time.Now()
+		player.rtcp.sent(pack, now)
+		for _, sr := range player.rtcp.reports(player, now) {
+			if !player.Session.rtcpReady(sr) {
+				continue
+			}
+			if err := player.Session.SendRTP(sr); err != nil {
+				log.Error(err)
+			}
+		}
 		elapsed := time.Now().Sub(timer)
 		if elapsed >= 30*time.Second {
 			log.Debugf("Send a package.type:%d\n", pack.Type)
@@ -103,6 +144,65 @@ func (player *_Player) Start() {
 	}
 }
 
+// handleRTCP of player, RR is counted
+func (player *_Player) handleRTCP(pack *RTPPack) {
+	player.rtcp.handle(pack, time.Now())
+}
+
+// senderReport of pusher, SSRC and timestamp are mapped if RTP is rewritten
+func (player *_Player) senderReport(track int, ssrc uint32, now time.Time) (ntp uint64, rtp uint32, ok bool) {
+	source, ok := player.getPusher().(rtcpSource)
+	if !ok {
+		return 0, 0, false
+	}
+	if player.rewriter == nil {
+		return source.senderReport(track, ssrc, now)
+	}
+	srcSSRC, tsOffset, ok := player.rewriter.source(track, ssrc)
+	if !ok {
+		return 0, 0, false
+	}
+	ntp, rtp, ok = source.senderReport(track, srcSSRC, now)
+	return ntp, rtp + tsOffset, ok
+}
+
+// getPusher of player, switched when pusher is replaced
+func (player *_Player) getPusher() Pusher {
+	player.Session.pusherLock.RLock()
+	pusher := player.Pusher
+	player.Session.pusherLock.RUnlock()
+	return pusher
+}
+
+// switchPusher of player kept when pusher is replaced, RTP continues with the old sequence.
+// false if player is stoped, never attached to the new pusher then
+func (player *_Player) switchPusher(pusher Pusher, to playersHandover) bool {
+	player.Session.pusherLock.Lock()
+	defer player.Session.pusherLock.Unlock()
+
+	if player.getStoped() {
+		return false
+	}
+	player.rewriter.switchSource()
+	player.Pusher = pusher
+	player.Session.Pusher = pusher
+	to.attachPlayer(player)
+	return true
+}
+
+// RTCPStats of tracks sent to player
+func (player *_Player) RTCPStats() []RTCPStats {
+	return player.rtcp.Stats()
+}
+
+func (player *_Player) Pause(paused bool) {
+	if paused {
+		atomic.StoreInt32(&player.paused, 1)
+	} else {
+		atomic.StoreInt32(&player.paused, 0)
+	}
+}
+
 func (player *_Player) Stop() {
 	player.Session.Stop()
 }
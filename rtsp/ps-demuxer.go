@@ -0,0 +1,283 @@
+package rtsp
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// PS stream types in PSM, see GB28181 appendix C
+const (
+	PSStreamTypeMPEG4 byte = 0x10
+	PSStreamTypeAAC   byte = 0x0f
+	PSStreamTypeH264  byte = 0x1b
+	PSStreamTypeH265  byte = 0x24
+	PSStreamTypeSVAC  byte = 0x80
+	PSStreamTypeG711A byte = 0x90
+	PSStreamTypeG711U byte = 0x91
+	PSStreamTypeG7221 byte = 0x92
+	PSStreamTypeG7231 byte = 0x93
+	PSStreamTypeG729  byte = 0x99
+)
+
+var psStartCode = []byte{0x00, 0x00, 0x01}
+
+// PSFrame of elementary stream demuxed from PS
+type PSFrame struct {
+	Video      bool
+	StreamType byte
+	// PTS in 90kHz, 33 bits
+	PTS  uint64
+	Data []byte
+}
+
+// PSDemuxer of MPEG-PS carried by RTP, one PS pack may be split into many RTP packets with same timestamp
+type PSDemuxer struct {
+	// stream_id <-> stream_type from PSM
+	streamTypes map[byte]byte
+	buffer      *bytes.Buffer
+	timestamp   uint32
+	sequence    uint16
+	started     bool
+	discard     bool
+}
+
+// NewPSDemuxer returns
+func NewPSDemuxer() *PSDemuxer {
+	return &PSDemuxer{
+		streamTypes: make(map[byte]byte),
+		buffer:      &bytes.Buffer{},
+	}
+}
+
+// InputRTP of PS payload, returns frames of PS packs completed
+func (demuxer *PSDemuxer) InputRTP(info *RTPInfo) (frames []*PSFrame, err error) {
+	if demuxer.started && info.SequenceNumber != demuxer.sequence+1 {
+		// packet lost, the pack being received is broken
+		log.Debugf("PS demuxer RTP sequence jump %d -> %d", demuxer.sequence, info.SequenceNumber)
+		if demuxer.buffer.Len() > 0 {
+			demuxer.buffer = &bytes.Buffer{}
+			demuxer.discard = true
+		}
+	}
+	demuxer.started = true
+	demuxer.sequence = info.SequenceNumber
+
+	if demuxer.discard {
+		if info.Timestamp == demuxer.timestamp {
+			return
+		}
+		demuxer.discard = false
+	}
+
+	if demuxer.buffer.Len() > 0 && info.Timestamp != demuxer.timestamp {
+		frames, err = demuxer.flush()
+	}
+	demuxer.timestamp = info.Timestamp
+	demuxer.buffer.Write(info.Payload)
+	if info.Marker {
+		more, _err := demuxer.flush()
+		frames = append(frames, more...)
+		if nil != _err {
+			err = _err
+		}
+	}
+
+	return
+}
+
+func (demuxer *PSDemuxer) flush() ([]*PSFrame, error) {
+	data := demuxer.buffer.Bytes()
+	// frames refer to data, so never reuse the buffer
+	demuxer.buffer = &bytes.Buffer{}
+
+	return demuxer.parse(data, demuxer.timestamp)
+}
+
+// parse PS packs in data, video PES are joined to one frame
+func (demuxer *PSDemuxer) parse(data []byte, timestamp uint32) (frames []*PSFrame, err error) {
+	var video *PSFrame
+
+	for len(data) >= 4 {
+		if !bytes.HasPrefix(data, psStartCode) {
+			next := bytes.Index(data[1:], psStartCode)
+			if next < 0 {
+				break
+			}
+			data = data[1+next:]
+			continue
+		}
+
+		streamID := data[3]
+		switch streamID {
+		case 0xba: // pack header
+			headerLen := 12 // MPEG-1
+			if len(data) > 4 && data[4]&0xc0 == 0x40 {
+				// MPEG-2
+				if len(data) < 14 {
+					return frames, ErrorPSMalformed
+				}
+				headerLen = 14 + int(data[13]&0x07)
+			}
+			if len(data) < headerLen {
+				return frames, ErrorPSMalformed
+			}
+			data = data[headerLen:]
+			continue
+		case 0xb9: // program end
+			data = data[4:]
+			continue
+		}
+
+		if len(data) < 6 {
+			return frames, ErrorPSMalformed
+		}
+		end := 6 + int(binary.BigEndian.Uint16(data[4:]))
+		if end == 6 {
+			// unbounded video PES, ends at next start code of pack
+			if next := bytes.Index(data[6:], []byte{0x00, 0x00, 0x01, 0xba}); next >= 0 {
+				end = 6 + next
+			} else {
+				end = len(data)
+			}
+		}
+		if end > len(data) {
+			log.Debugf("PS demuxer stream[%02x] truncated", streamID)
+			end = len(data)
+		}
+		body := data[6:end]
+		data = data[end:]
+
+		switch {
+		case streamID == 0xbc:
+			demuxer.parsePSM(body)
+		case streamID >= 0xe0 && streamID <= 0xef:
+			pts, hasPTS, payload := parsePES(body)
+			if nil == video {
+				video = &PSFrame{
+					Video:      true,
+					StreamType: demuxer.streamType(streamID, payload),
+					PTS:        uint64(timestamp),
+				}
+			}
+			if hasPTS && len(video.Data) == 0 {
+				video.PTS = pts
+			}
+			video.Data = append(video.Data, payload...)
+		case streamID >= 0xc0 && streamID <= 0xdf:
+			pts, hasPTS, payload := parsePES(body)
+			if !hasPTS {
+				pts = uint64(timestamp)
+			}
+			if len(payload) == 0 {
+				continue
+			}
+			frames = append(frames, &PSFrame{
+				Video:      false,
+				StreamType: demuxer.streamType(streamID, payload),
+				PTS:        pts,
+				Data:       payload,
+			})
+		default:
+			// system header, padding, private streams...
+		}
+	}
+
+	if nil != video && len(video.Data) > 0 {
+		frames = append([]*PSFrame{video}, frames...)
+	}
+	return
+}
+
+// parsePSM to get stream type of each elementary stream
+func (demuxer *PSDemuxer) parsePSM(body []byte) {
+	if len(body) < 4 {
+		return
+	}
+	offset := 4 + int(binary.BigEndian.Uint16(body[2:]))
+	if len(body) < offset+2 {
+		return
+	}
+	end := offset + 2 + int(binary.BigEndian.Uint16(body[offset:]))
+	offset += 2
+	if end > len(body) {
+		end = len(body)
+	}
+	for offset+4 <= end {
+		streamType := body[offset]
+		streamID := body[offset+1]
+		demuxer.streamTypes[streamID] = streamType
+		offset += 4 + int(binary.BigEndian.Uint16(body[offset+2:]))
+	}
+}
+
+// streamType from PSM, guess it when device never sends PSM
+func (demuxer *PSDemuxer) streamType(streamID byte, payload []byte) byte {
+	if streamType, ok := demuxer.streamTypes[streamID]; ok {
+		return streamType
+	}
+	if streamID >= 0xc0 && streamID <= 0xdf {
+		return PSStreamTypeG711A
+	}
+	for _, nalu := range splitNALUs(payload) {
+		switch nalu[0] {
+		case 0x40, 0x42, 0x44, 0x46, 0x4e:
+			// VPS, SPS, PPS, AUD, SEI of H.265
+			return PSStreamTypeH265
+		}
+		break
+	}
+	return PSStreamTypeH264
+}
+
+// parsePES of MPEG-2, body starts after PES_packet_length
+func parsePES(body []byte) (pts uint64, hasPTS bool, payload []byte) {
+	if len(body) < 3 || body[0]&0xc0 != 0x80 {
+		return 0, false, body
+	}
+	headerLen := 3 + int(body[2])
+	if headerLen > len(body) {
+		return 0, false, nil
+	}
+	if body[1]&0x80 != 0 && headerLen >= 8 {
+		p := body[3:8]
+		pts = uint64(p[0]>>1&0x07)<<30 |
+			uint64(p[1])<<22 |
+			uint64(p[2]>>1)<<15 |
+			uint64(p[3])<<7 |
+			uint64(p[4]>>1)
+		hasPTS = true
+	}
+	return pts, hasPTS, body[headerLen:]
+}
+
+// splitNALUs of Annex B byte stream
+func splitNALUs(data []byte) (nalus [][]byte) {
+	start := bytes.Index(data, psStartCode)
+	if start < 0 {
+		if len(data) > 0 {
+			nalus = append(nalus, data)
+		}
+		return
+	}
+	start += 3
+	for start < len(data) {
+		next := bytes.Index(data[start:], psStartCode)
+		end := len(data)
+		if next >= 0 {
+			end = start + next
+		}
+		nalu := data[start:end]
+		// trailing zero of 4 bytes start code
+		for next >= 0 && len(nalu) > 0 && nalu[len(nalu)-1] == 0 {
+			nalu = nalu[:len(nalu)-1]
+		}
+		if len(nalu) > 0 {
+			nalus = append(nalus, nalu)
+		}
+		if next < 0 {
+			break
+		}
+		start = end + 3
+	}
+	return
+}
@@ -0,0 +1,180 @@
+package rtsp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func psPackHeader() []byte {
+	// MPEG-2 pack header without stuffing
+	return []byte{0x00, 0x00, 0x01, 0xba, 0x44, 0x00, 0x04, 0x00, 0x04, 0x01, 0x01, 0x89, 0xc3, 0xf8}
+}
+
+func psPSM(streams ...byte) []byte {
+	esMap := make([]byte, 0)
+	for i := 0; i+1 < len(streams); i += 2 {
+		esMap = append(esMap, streams[i], streams[i+1], 0x00, 0x00)
+	}
+	body := []byte{0xe0, 0xff, 0x00, 0x00}
+	body = append(body, byte(len(esMap)>>8), byte(len(esMap)))
+	body = append(body, esMap...)
+	body = append(body, 0x00, 0x00, 0x00, 0x00) // CRC
+	header := []byte{0x00, 0x00, 0x01, 0xbc, 0x00, 0x00}
+	binary.BigEndian.PutUint16(header[4:], uint16(len(body)))
+	return append(header, body...)
+}
+
+func psPES(streamID byte, pts uint64, payload []byte) []byte {
+	body := []byte{0x80, 0x80, 0x05,
+		0x21 | byte(pts>>29)&0x0e,
+		byte(pts >> 22),
+		byte(pts>>14) | 0x01,
+		byte(pts >> 7),
+		byte(pts<<1) | 0x01,
+	}
+	body = append(body, payload...)
+	header := []byte{0x00, 0x00, 0x01, streamID, 0x00, 0x00}
+	binary.BigEndian.PutUint16(header[4:], uint16(len(body)))
+	return append(header, body...)
+}
+
+func psJoin(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+func TestPSDemuxerInputRTP(t *testing.T) {
+	h264 := []byte{0x00, 0x00, 0x00, 0x01, 0x67, 0x42, 0x00, 0x00, 0x00, 0x01, 0x65, 0x88, 0x84}
+	h265 := []byte{0x00, 0x00, 0x00, 0x01, 0x40, 0x01, 0x0c, 0x00, 0x00, 0x01, 0x26, 0x01, 0xaf}
+	g711 := []byte{0xd5, 0xd5, 0xd5, 0xd5}
+	pack := psJoin(psPackHeader(), psPSM(PSStreamTypeH264, 0xe0, PSStreamTypeG711U, 0xc0),
+		psPES(0xe0, 3600, h264[:7]), psPES(0xe0, 3600, h264[7:]), psPES(0xc0, 3000, g711))
+
+	type frame struct {
+		video      bool
+		streamType byte
+		pts        uint64
+		data       []byte
+	}
+	tests := []struct {
+		name    string
+		packets []*RTPInfo
+		frames  []frame
+	}{
+		{
+			name: "one pack with marker",
+			packets: []*RTPInfo{
+				{SequenceNumber: 1, Timestamp: 3600, Marker: true, Payload: pack},
+			},
+			frames: []frame{
+				{true, PSStreamTypeH264, 3600, h264},
+				{false, PSStreamTypeG711U, 3000, g711},
+			},
+		},
+		{
+			name: "pack split into packets of same timestamp",
+			packets: []*RTPInfo{
+				{SequenceNumber: 1, Timestamp: 3600, Payload: pack[:20]},
+				{SequenceNumber: 2, Timestamp: 3600, Payload: pack[20:50]},
+				{SequenceNumber: 3, Timestamp: 3600, Marker: true, Payload: pack[50:]},
+			},
+			frames: []frame{
+				{true, PSStreamTypeH264, 3600, h264},
+				{false, PSStreamTypeG711U, 3000, g711},
+			},
+		},
+		{
+			name: "pack without marker flushed by next timestamp",
+			packets: []*RTPInfo{
+				{SequenceNumber: 1, Timestamp: 3600, Payload: pack},
+				{SequenceNumber: 2, Timestamp: 7200, Payload: psPackHeader()},
+			},
+			frames: []frame{
+				{true, PSStreamTypeH264, 3600, h264},
+				{false, PSStreamTypeG711U, 3000, g711},
+			},
+		},
+		{
+			name: "pack with lost packet discarded",
+			packets: []*RTPInfo{
+				{SequenceNumber: 1, Timestamp: 3600, Payload: pack[:20]},
+				{SequenceNumber: 3, Timestamp: 3600, Marker: true, Payload: pack[50:]},
+				{SequenceNumber: 4, Timestamp: 7200, Marker: true, Payload: psJoin(psPackHeader(), psPES(0xc0, 6600, g711))},
+			},
+			frames: []frame{
+				// PSM is lost with the pack
+				{false, PSStreamTypeG711A, 6600, g711},
+			},
+		},
+		{
+			name: "stream types guessed without PSM",
+			packets: []*RTPInfo{
+				{SequenceNumber: 1, Timestamp: 3600, Marker: true, Payload: psJoin(psPackHeader(), psPES(0xe0, 3600, h265), psPES(0xc0, 3000, g711))},
+			},
+			frames: []frame{
+				{true, PSStreamTypeH265, 3600, h265},
+				{false, PSStreamTypeG711A, 3000, g711},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			demuxer := NewPSDemuxer()
+			frames := make([]frame, 0)
+			for _, packet := range test.packets {
+				_frames, err := demuxer.InputRTP(packet)
+				assert.Nil(t, err)
+				for _, f := range _frames {
+					frames = append(frames, frame{f.Video, f.StreamType, f.PTS, f.Data})
+				}
+			}
+			assert.Equal(t, test.frames, frames)
+		})
+	}
+}
+
+func TestSplitNALUs(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  []byte
+		nalus [][]byte
+	}{
+		{
+			name:  "3 bytes start code",
+			data:  []byte{0x00, 0x00, 0x01, 0x67, 0x42, 0x00, 0x00, 0x01, 0x68, 0xce},
+			nalus: [][]byte{{0x67, 0x42}, {0x68, 0xce}},
+		},
+		{
+			name:  "4 bytes start code",
+			data:  []byte{0x00, 0x00, 0x00, 0x01, 0x67, 0x42, 0x00, 0x00, 0x00, 0x01, 0x65, 0x88},
+			nalus: [][]byte{{0x67, 0x42}, {0x65, 0x88}},
+		},
+		{
+			name:  "trailing zero of last NALU kept",
+			data:  []byte{0x00, 0x00, 0x01, 0x65, 0x88, 0x00},
+			nalus: [][]byte{{0x65, 0x88, 0x00}},
+		},
+		{
+			name:  "empty NALU skipped",
+			data:  []byte{0x00, 0x00, 0x01, 0x00, 0x00, 0x01, 0x41, 0x9a},
+			nalus: [][]byte{{0x41, 0x9a}},
+		},
+		{
+			name:  "no start code",
+			data:  []byte{0x41, 0x9a},
+			nalus: [][]byte{{0x41, 0x9a}},
+		},
+		{
+			name:  "empty",
+			data:  []byte{},
+			nalus: nil,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.nalus, splitNALUs(test.data))
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package rtsp
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// psMaxPESPayload keeps PES_packet_length in 16 bits
+const psMaxPESPayload = 65000
+
+// psMuxRate in 50 bytes/s, informative only
+const psMuxRate = 6106
+
+// PSMuxer packs elementary stream frames into MPEG-PS, one pack for each frame.
+// System header and PSM are sent before every video key frame, see GB28181 appendix C
+type PSMuxer struct {
+	videoType byte
+	audioType byte
+}
+
+// NewPSMuxer of stream types, 0 if no such stream
+func NewPSMuxer(videoType byte, audioType byte) *PSMuxer {
+	return &PSMuxer{
+		videoType: videoType,
+		audioType: audioType,
+	}
+}
+
+// Mux frame to a PS pack
+func (muxer *PSMuxer) Mux(frame *PSFrame) []byte {
+	buffer := &bytes.Buffer{}
+	muxer.writePackHeader(buffer, frame.PTS)
+	if frame.Video {
+		if IsKeyFrame(frame) {
+			muxer.writeSystemHeader(buffer)
+			muxer.writePSM(buffer)
+		}
+		muxer.writePES(buffer, 0xe0, frame.PTS, frame.Data)
+	} else {
+		if 0 == muxer.videoType {
+			// audio only, PSM should be sent too
+			muxer.writePSM(buffer)
+		}
+		muxer.writePES(buffer, 0xc0, frame.PTS, frame.Data)
+	}
+	return buffer.Bytes()
+}
+
+func (muxer *PSMuxer) writePackHeader(buffer *bytes.Buffer, scr uint64) {
+	buffer.Write([]byte{
+		0x00, 0x00, 0x01, 0xba,
+		byte(0x44 | (scr>>27)&0x38 | (scr>>28)&0x03),
+		byte(scr >> 20),
+		byte(0x04 | (scr>>12)&0xf8 | (scr>>13)&0x03),
+		byte(scr >> 5),
+		byte(0x04 | (scr<<3)&0xf8),
+		0x01,
+		byte(psMuxRate >> 14),
+		byte((psMuxRate >> 6) & 0xff),
+		byte((psMuxRate<<2 | 0x03) & 0xff),
+		// no stuffing
+		0xf8,
+	})
+}
+
+func (muxer *PSMuxer) writeSystemHeader(buffer *bytes.Buffer) {
+	streams := []byte{}
+	audioBound, videoBound := byte(0), byte(0)
+	if 0 != muxer.videoType {
+		// buffer bound scale 1024, size 400
+		streams = append(streams, 0xe0, 0xe1, 0x90)
+		videoBound = 1
+	}
+	if 0 != muxer.audioType {
+		// buffer bound scale 128, size 32
+		streams = append(streams, 0xc0, 0xc0, 0x20)
+		audioBound = 1
+	}
+	header := []byte{
+		0x00, 0x00, 0x01, 0xbb,
+		0x00, byte(6 + len(streams)),
+		byte(0x80 | psMuxRate>>15),
+		byte(psMuxRate >> 7),
+		byte((psMuxRate<<1 | 0x01) & 0xff),
+		audioBound << 2,
+		0xe0 | videoBound,
+		0xff,
+	}
+	buffer.Write(header)
+	buffer.Write(streams)
+}
+
+func (muxer *PSMuxer) writePSM(buffer *bytes.Buffer) {
+	streams := []byte{}
+	if 0 != muxer.videoType {
+		streams = append(streams, muxer.videoType, 0xe0, 0x00, 0x00)
+	}
+	if 0 != muxer.audioType {
+		streams = append(streams, muxer.audioType, 0xc0, 0x00, 0x00)
+	}
+	psm := []byte{
+		0x00, 0x00, 0x01, 0xbc,
+		0x00, byte(10 + len(streams)),
+		// current_next_indicator, version 0
+		0xe0, 0xff,
+		// program_stream_info_length
+		0x00, 0x00,
+		byte(len(streams) >> 8), byte(len(streams)),
+	}
+	psm = append(psm, streams...)
+	crc := make([]byte, 4)
+	binary.BigEndian.PutUint32(crc, crc32MPEG2(psm))
+	buffer.Write(psm)
+	buffer.Write(crc)
+}
+
+// writePES of stream, data is split to many PES if too large, PTS is only in the first one
+func (muxer *PSMuxer) writePES(buffer *bytes.Buffer, streamID byte, pts uint64, data []byte) {
+	for first := true; first || len(data) > 0; first = false {
+		size := len(data)
+		if size > psMaxPESPayload {
+			size = psMaxPESPayload
+		}
+		header := []byte{0x00, 0x00, 0x01, streamID, 0x00, 0x00, 0x80, 0x00, 0x00}
+		if first {
+			header[7] = 0x80
+			header[8] = 0x05
+			header = append(header,
+				byte(0x21|(pts>>29)&0x0e),
+				byte(pts>>22),
+				byte(0x01|(pts>>14)&0xfe),
+				byte(pts>>7),
+				byte(0x01|(pts<<1)&0xfe),
+			)
+		}
+		binary.BigEndian.PutUint16(header[4:], uint16(len(header)-6+size))
+		buffer.Write(header)
+		buffer.Write(data[:size])
+		data = data[size:]
+	}
+}
+
+// IsKeyFrame of H.264 or H.265 frame in Annex B
+func IsKeyFrame(frame *PSFrame) bool {
+	if !frame.Video {
+		return false
+	}
+	for _, nalu := range splitNALUs(frame.Data) {
+		if isKeyFrameNALU(frame.StreamType, nalu) {
+			return true
+		}
+	}
+	return false
+}
+
+// crc32MPEG2 of PSI section
+func crc32MPEG2(data []byte) uint32 {
+	crc := uint32(0xffffffff)
+	for _, b := range data {
+		crc ^= uint32(b) << 24
+		for i := 0; i < 8; i++ {
+			if crc&0x80000000 != 0 {
+				crc = crc<<1 ^ 0x04c11db7
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
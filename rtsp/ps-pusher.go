@@ -0,0 +1,736 @@
+package rtsp
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// psTrack of elementary stream repacketized from PS
+type psTrack struct {
+	streamType byte
+	codec      string
+	clockRate  int
+	channels   int
+	fmtp       string
+	control    string
+	packetizer *RTPPacketizer
+}
+
+// PSPusher receives MPEG-PS over RTP(UDP or RFC4571 TCP), like the stream of GB28181 device,
+// and serves it as standard RTP to players
+type PSPusher struct {
+	*defaultPusher
+	_ID       string
+	path      string
+	source    string
+	transType TransType
+	startAt   time.Time
+	// network
+	udpConn     *net.UDPConn
+	tcpListener *net.TCPListener
+	tcpConn     net.Conn
+	mux         *RTPMux
+	port        int
+	ssrc        uint32
+	// media
+	demuxer       *PSDemuxer
+	handleLock    sync.Mutex
+	video         *psTrack
+	audio         *psTrack
+	parameterSets map[byte][]byte
+	lastReceiveAt time.Time
+	// SDP, ready after first video key frame
+	sdpLock        sync.RWMutex
+	_SDPRaw        string
+	readyChannel   chan int
+	gopCacheEnable bool
+	gopCache       []*RTPPack
+	gopCacheLock   sync.RWMutex
+	// data flow
+	queue   chan *RTPPack
+	control PlayControl
+	// stop
+	onDemand    bool
+	stoped      bool
+	stopLock    sync.Mutex
+	stopChannel chan int
+	StopHandles []func()
+}
+
+// NewPSPusher listen on port to receive PS, port 0 to choose one in port range of config.
+// TCP transport listens for device connecting, call Connect before Start to connect device instead.
+func NewPSPusher(server *Server, ID string, path string, source string, transType TransType, port int) (_ *PSPusher, err error) {
+	pusher := newPSPusher(server, ID, path, source, transType)
+
+	switch transType {
+	case TRANS_TYPE_UDP:
+		if 0 == port {
+			pusher.udpConn, err = listenUDP()
+		} else {
+			pusher.udpConn, err = net.ListenUDP("udp", &net.UDPAddr{Port: port})
+		}
+		if nil != err {
+			return nil, err
+		}
+		if err := pusher.udpConn.SetReadBuffer(config.RTSP.NetworkBuffer); err != nil {
+			log.WithError(err).WithField("id", ID).Warn("PS pusher set read buffer")
+		}
+		pusher.port = pusher.udpConn.LocalAddr().(*net.UDPAddr).Port
+	case TRANS_TYPE_TCP:
+		if 0 == port {
+			pusher.tcpListener, err = listenTCP()
+		} else {
+			pusher.tcpListener, err = net.ListenTCP("tcp", &net.TCPAddr{Port: port})
+		}
+		if nil != err {
+			return nil, err
+		}
+		pusher.port = pusher.tcpListener.Addr().(*net.TCPAddr).Port
+	default:
+		return nil, fmt.Errorf("PS pusher unsupported transport %s", transType)
+	}
+
+	return pusher, nil
+}
+
+// NewMuxPSPusher receives PS of ssrc on the mux port of server, both UDP and TCP passive.
+// Call Connect before Start for TCP active mode
+func NewMuxPSPusher(server *Server, ID string, path string, source string, transType TransType, ssrc uint32) (*PSPusher, error) {
+	mux := server.RTPMux()
+	if nil == mux {
+		return nil, fmt.Errorf("RTP mux disabled")
+	}
+	if transType != TRANS_TYPE_UDP && transType != TRANS_TYPE_TCP {
+		return nil, fmt.Errorf("PS pusher unsupported transport %s", transType)
+	}
+	pusher := newPSPusher(server, ID, path, source, transType)
+	if err := mux.Register(ssrc, pusher); nil != err {
+		return nil, err
+	}
+	pusher.mux = mux
+	pusher.port = mux.Port()
+	pusher.ssrc = ssrc
+
+	return pusher, nil
+}
+
+func newPSPusher(server *Server, ID string, path string, source string, transType TransType) *PSPusher {
+	pusher := &PSPusher{
+		defaultPusher: newDefaultPusher(server),
+		_ID:           ID,
+		path:          path,
+		source:        source,
+		transType:     transType,
+
+		demuxer:       NewPSDemuxer(),
+		parameterSets: make(map[byte][]byte),
+		lastReceiveAt: time.Now(),
+
+		readyChannel:   make(chan int),
+		gopCacheEnable: config.RTSP.GopCacheEnable != 0,
+
+		queue:       make(chan *RTPPack, config.Player.SendQueueLength),
+		stopChannel: make(chan int),
+	}
+	pusher.AddOnStopHandle(pusher.removeFromServer)
+
+	return pusher
+}
+
+func (pusher *PSPusher) removeFromServer() {
+	// never remove the pusher replaced this one
+	if _pusher, ok := pusher.server.GetPushers().Get(pusher.Path()); ok && _pusher.(Pusher) == Pusher(pusher) {
+		pusher.server.RemovePusher(pusher.Path())
+	}
+}
+
+// SetOnDemand to stop when the last player leaves
+func (pusher *PSPusher) SetOnDemand(onDemand bool) {
+	pusher.onDemand = onDemand
+}
+
+// SetPlayControl of source, players' PLAY and PAUSE will be passed to it
+func (pusher *PSPusher) SetPlayControl(control PlayControl) {
+	pusher.control = control
+}
+
+// ControlPlay of source by player, the position is decided by device
+func (pusher *PSPusher) ControlPlay(player Player, rangeValue string, scale string) (string, error) {
+	if nil == pusher.control {
+		return rangeValue, nil
+	}
+	return rangeValue, pusher.control.Play(rangeValue, scale)
+}
+
+// ControlPause of source by player
+func (pusher *PSPusher) ControlPause(player Player) error {
+	if nil == pusher.control {
+		return nil
+	}
+	return pusher.control.Pause()
+}
+
+// Port receiving PS
+func (pusher *PSPusher) Port() int {
+	return pusher.port
+}
+
+// SetSSRC to accept, 0 for any. Pusher on mux port moves to the new SSRC, 0 is not allowed
+func (pusher *PSPusher) SetSSRC(ssrc uint32) error {
+	pusher.handleLock.Lock()
+	defer pusher.handleLock.Unlock()
+
+	if nil != pusher.mux && ssrc != pusher.ssrc {
+		if 0 == ssrc {
+			return fmt.Errorf("PS pusher on mux port without SSRC")
+		}
+		if err := pusher.mux.Register(ssrc, pusher); nil != err {
+			return err
+		}
+		pusher.mux.Unregister(pusher.ssrc, pusher)
+	}
+	pusher.ssrc = ssrc
+	return nil
+}
+
+// SSRC accepted, 0 for any
+func (pusher *PSPusher) SSRC() uint32 {
+	pusher.handleLock.Lock()
+	defer pusher.handleLock.Unlock()
+
+	return pusher.ssrc
+}
+
+// Connect to device in TCP active mode
+func (pusher *PSPusher) Connect(addr string) (err error) {
+	if pusher.transType != TRANS_TYPE_TCP {
+		return fmt.Errorf("PS pusher connect on transport %s", pusher.transType)
+	}
+	pusher.tcpConn, err = net.DialTimeout("tcp", addr, 5*time.Second)
+	if nil != err {
+		return
+	}
+	if nil != pusher.tcpListener {
+		pusher.tcpListener.Close()
+		pusher.tcpListener = nil
+	}
+	return
+}
+
+// ID of PS pusher
+func (pusher *PSPusher) ID() string {
+	return pusher._ID
+}
+
+// Path of PS pusher for RTSP serve
+func (pusher *PSPusher) Path() string {
+	return pusher.path
+}
+
+// Source of PS pusher
+func (pusher *PSPusher) Source() string {
+	return pusher.source
+}
+
+// TransType of PS receiving
+func (pusher *PSPusher) TransType() string {
+	return pusher.transType.String()
+}
+
+// StartAt of PS pusher
+func (pusher *PSPusher) StartAt() time.Time {
+	return pusher.startAt
+}
+
+// Mode of PS pusher
+func (pusher *PSPusher) Mode() PusherMode {
+	return PusherModePS
+}
+
+// Start receiving and brocast to players
+func (pusher *PSPusher) Start() {
+	pusher.startAt = time.Now()
+	if nil != pusher.udpConn {
+		go pusher.readUDPLoop()
+	} else if nil != pusher.tcpListener || nil != pusher.tcpConn {
+		go pusher.readTCPLoop()
+	}
+	// otherwise RTP comes from mux
+	go pusher.checkTimeoutLoop()
+
+	for {
+		select {
+		case pack := <-pusher.queue:
+			pusher.defaultPusher.BroadcastRTP(pack)
+		case <-pusher.stopChannel:
+			return
+		}
+	}
+}
+
+func (pusher *PSPusher) readUDPLoop() {
+	buf := make([]byte, 65536)
+	for {
+		n, err := pusher.udpConn.Read(buf)
+		if nil != err {
+			if !pusher.Stoped() {
+				log.WithError(err).WithField("id", pusher.ID()).Error("PS pusher read udp")
+				pusher.Stop()
+			}
+			return
+		}
+		pusher.HandleRTP(buf[:n])
+	}
+}
+
+func (pusher *PSPusher) readTCPLoop() {
+	if nil == pusher.tcpConn {
+		conn, err := pusher.tcpListener.Accept()
+		pusher.tcpListener.Close()
+		if nil != err {
+			if !pusher.Stoped() {
+				log.WithError(err).WithField("id", pusher.ID()).Error("PS pusher accept tcp")
+				pusher.Stop()
+			}
+			return
+		}
+		pusher.stopLock.Lock()
+		pusher.tcpConn = conn
+		pusher.stopLock.Unlock()
+		if pusher.Stoped() {
+			conn.Close()
+			return
+		}
+	}
+
+	// RFC4571, 2 bytes length before each RTP packet
+	reader := bufio.NewReaderSize(pusher.tcpConn, config.RTSP.NetworkBuffer)
+	header := make([]byte, 2)
+	buf := make([]byte, 65536)
+	for {
+		if _, err := io.ReadFull(reader, header); nil != err {
+			break
+		}
+		length := int(binary.BigEndian.Uint16(header))
+		if _, err := io.ReadFull(reader, buf[:length]); nil != err {
+			break
+		}
+		pusher.HandleRTP(buf[:length])
+	}
+	if !pusher.Stoped() {
+		log.WithField("id", pusher.ID()).Info("PS pusher tcp connection closed")
+		pusher.Stop()
+	}
+}
+
+func (pusher *PSPusher) checkTimeoutLoop() {
+	timeout := time.Duration(config.RTP.ReceiveTimeout) * time.Second
+	noPlayerTimeout := time.Duration(config.RTSP.NoConnectionTimeout) * time.Second
+	noPlayerSince := time.Now()
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			pusher.handleLock.Lock()
+			idle := now.Sub(pusher.lastReceiveAt)
+			pusher.handleLock.Unlock()
+			if idle > timeout {
+				log.WithField("id", pusher.ID()).Warnf("PS pusher no data in %v", timeout)
+				pusher.Stop()
+				return
+			}
+			// on-demand pusher of DESCRIBE never played is not stopped by RemovePlayer
+			if !pusher.onDemand || pusher.GetPlayers().Len() > 0 {
+				noPlayerSince = now
+			} else if now.Sub(noPlayerSince) > noPlayerTimeout {
+				log.WithField("id", pusher.ID()).Infof("PS pusher no player in %v", noPlayerTimeout)
+				pusher.Stop()
+				return
+			}
+		case <-pusher.stopChannel:
+			return
+		}
+	}
+}
+
+// HandleRTP packet carrying PS, data is not referred after return
+func (pusher *PSPusher) HandleRTP(data []byte) {
+	pusher.handleLock.Lock()
+	defer pusher.handleLock.Unlock()
+
+	pusher.lastReceiveAt = time.Now()
+	pusher.inBytes += uint(len(data))
+	info := ParseRTP(data)
+	if nil == info {
+		return
+	}
+	if 0 != pusher.ssrc && info.SSRC != pusher.ssrc {
+		log.WithField("id", pusher.ID()).Debugf("PS pusher drop RTP of SSRC[%d]", info.SSRC)
+		return
+	}
+
+	frames, err := pusher.demuxer.InputRTP(info)
+	if nil != err {
+		log.WithError(err).WithField("id", pusher.ID()).Debug("PS demux")
+	}
+	for _, frame := range frames {
+		if !frame.Video {
+			// audio in the same pack with first key frame should be in SDP
+			pusher.setupAudio(frame)
+		}
+	}
+	for _, frame := range frames {
+		if frame.Video {
+			pusher.handleVideo(frame)
+		} else {
+			pusher.handleAudio(frame)
+		}
+	}
+}
+
+func (pusher *PSPusher) handleVideo(frame *PSFrame) {
+	if nil == pusher.video {
+		switch frame.StreamType {
+		case PSStreamTypeH264:
+			pusher.video = &psTrack{codec: "H264"}
+		case PSStreamTypeH265:
+			pusher.video = &psTrack{codec: "H265"}
+		default:
+			log.WithField("id", pusher.ID()).Warnf("PS pusher video stream type[%02x] unsupported", frame.StreamType)
+			pusher.video = &psTrack{}
+		}
+		pusher.video.streamType = frame.StreamType
+		pusher.video.clockRate = 90000
+		pusher.video.control = "streamid=0"
+		pusher.video.packetizer = NewRTPPacketizer(RTP_TYPE_VIDEO, 96)
+	}
+	if pusher.video.codec == "" {
+		return
+	}
+
+	nalus := make([][]byte, 0)
+	keyFrame := false
+	for _, nalu := range splitNALUs(frame.Data) {
+		if "H264" == pusher.video.codec {
+			switch nalType := nalu[0] & 0x1f; nalType {
+			case 9: // AUD
+				continue
+			case 7, 8:
+				pusher.parameterSets[nalType] = nalu
+			case 5:
+				keyFrame = true
+			}
+		} else {
+			switch nalType := (nalu[0] >> 1) & 0x3f; {
+			case nalType == 35: // AUD
+				continue
+			case nalType >= 32 && nalType <= 34:
+				pusher.parameterSets[nalType] = nalu
+			case nalType >= 16 && nalType <= 21:
+				keyFrame = true
+			}
+		}
+		nalus = append(nalus, nalu)
+	}
+	if len(nalus) == 0 {
+		return
+	}
+
+	if !pusher.ready() {
+		if !keyFrame || !pusher.makeSDP() {
+			// players never decode without key frame
+			return
+		}
+	}
+
+	var packs []*RTPPack
+	if "H264" == pusher.video.codec {
+		packs = pusher.video.packetizer.PackH264(nalus, uint32(frame.PTS))
+	} else {
+		packs = pusher.video.packetizer.PackH265(nalus, uint32(frame.PTS))
+	}
+	if keyFrame && len(packs) > 0 {
+		// players kept from the replaced pusher resume from here
+		packs[0].keyFrame = true
+	}
+	if pusher.gopCacheEnable {
+		pusher.gopCacheLock.Lock()
+		if keyFrame {
+			pusher.gopCache = make([]*RTPPack, 0)
+		}
+		pusher.gopCache = append(pusher.gopCache, packs...)
+		pusher.gopCacheLock.Unlock()
+	}
+	for _, pack := range packs {
+		pusher.QueueRTP(pack)
+	}
+}
+
+// setupAudio track before SDP made
+func (pusher *PSPusher) setupAudio(frame *PSFrame) {
+	if nil == pusher.audio && !pusher.ready() {
+		pusher.audio = &psTrack{
+			streamType: frame.StreamType,
+			control:    "streamid=1",
+			channels:   1,
+		}
+		switch frame.StreamType {
+		case PSStreamTypeG711A:
+			pusher.audio.codec = "PCMA"
+			pusher.audio.clockRate = 8000
+			pusher.audio.packetizer = NewRTPPacketizer(RTP_TYPE_AUDIO, 8)
+		case PSStreamTypeG711U:
+			pusher.audio.codec = "PCMU"
+			pusher.audio.clockRate = 8000
+			pusher.audio.packetizer = NewRTPPacketizer(RTP_TYPE_AUDIO, 0)
+		case PSStreamTypeAAC:
+			header, ok := parseADTS(frame.Data)
+			if !ok {
+				log.WithField("id", pusher.ID()).Warn("PS pusher AAC without ADTS")
+				break
+			}
+			pusher.audio.codec = "MPEG4-GENERIC"
+			pusher.audio.clockRate = header.sampleRate()
+			pusher.audio.channels = header.channels
+			pusher.audio.fmtp = fmt.Sprintf(
+				"streamtype=5;profile-level-id=1;mode=AAC-hbr;sizelength=13;indexlength=3;indexdeltalength=3;config=%s",
+				hex.EncodeToString(header.audioSpecificConfig()))
+			pusher.audio.packetizer = NewRTPPacketizer(RTP_TYPE_AUDIO, 97)
+		default:
+			log.WithField("id", pusher.ID()).Warnf("PS pusher audio stream type[%02x] unsupported", frame.StreamType)
+		}
+	}
+}
+
+func (pusher *PSPusher) handleAudio(frame *PSFrame) {
+	// SDP may has been sent to players without audio
+	if nil == pusher.audio || pusher.audio.codec == "" || !pusher.ready() {
+		return
+	}
+
+	timestamp := uint32(frame.PTS * uint64(pusher.audio.clockRate) / 90000)
+	var packs []*RTPPack
+	if "MPEG4-GENERIC" == pusher.audio.codec {
+		packs = pusher.audio.packetizer.PackAAC(frame.Data, timestamp)
+	} else {
+		packs = pusher.audio.packetizer.PackG711(frame.Data, timestamp)
+	}
+	for _, pack := range packs {
+		pusher.QueueRTP(pack)
+	}
+}
+
+// makeSDP when parameter sets are all received, and become ready
+func (pusher *PSPusher) makeSDP() bool {
+	video := pusher.video
+	fmtp := ""
+	if "H264" == video.codec {
+		sps, pps := pusher.parameterSets[7], pusher.parameterSets[8]
+		if nil == sps || nil == pps || len(sps) < 4 {
+			return false
+		}
+		fmtp = fmt.Sprintf("packetization-mode=1;profile-level-id=%s;sprop-parameter-sets=%s,%s",
+			hex.EncodeToString(sps[1:4]),
+			base64.StdEncoding.EncodeToString(sps),
+			base64.StdEncoding.EncodeToString(pps))
+	} else {
+		vps, sps, pps := pusher.parameterSets[32], pusher.parameterSets[33], pusher.parameterSets[34]
+		if nil == vps || nil == sps || nil == pps {
+			return false
+		}
+		fmtp = fmt.Sprintf("sprop-vps=%s;sprop-sps=%s;sprop-pps=%s",
+			base64.StdEncoding.EncodeToString(vps),
+			base64.StdEncoding.EncodeToString(sps),
+			base64.StdEncoding.EncodeToString(pps))
+	}
+	video.fmtp = fmtp
+
+	lines := []string{
+		"v=0",
+		"o=- 0 0 IN IP4 0.0.0.0",
+		fmt.Sprintf("s=%s", pusher.path),
+		"c=IN IP4 0.0.0.0",
+		"t=0 0",
+		"a=control:*",
+	}
+	for _, track := range []*psTrack{pusher.video, pusher.audio} {
+		if nil == track || "" == track.codec {
+			continue
+		}
+		media := "video"
+		rtpmap := fmt.Sprintf("%s/%d", track.codec, track.clockRate)
+		if track.packetizer.Type == RTP_TYPE_AUDIO {
+			media = "audio"
+			if track.channels > 1 {
+				rtpmap = fmt.Sprintf("%s/%d", rtpmap, track.channels)
+			}
+		}
+		pt := track.packetizer.PayloadType
+		lines = append(lines,
+			fmt.Sprintf("m=%s 0 RTP/AVP %d", media, pt),
+			fmt.Sprintf("a=rtpmap:%d %s", pt, rtpmap))
+		if "" != track.fmtp {
+			lines = append(lines, fmt.Sprintf("a=fmtp:%d %s", pt, track.fmtp))
+		}
+		lines = append(lines, fmt.Sprintf("a=control:%s", track.control))
+	}
+
+	pusher.sdpLock.Lock()
+	pusher._SDPRaw = strings.Join(lines, "\r\n") + "\r\n"
+	pusher.sdpLock.Unlock()
+	close(pusher.readyChannel)
+	log.WithField("id", pusher.ID()).Infof("PS pusher ready, SDP:\n%s", pusher._SDPRaw)
+
+	return true
+}
+
+func (pusher *PSPusher) ready() bool {
+	select {
+	case <-pusher.readyChannel:
+		return true
+	default:
+		return false
+	}
+}
+
+// WaitReady for SDP, players can only be served after it
+func (pusher *PSPusher) WaitReady(timeout time.Duration) error {
+	select {
+	case <-pusher.readyChannel:
+		return nil
+	case <-pusher.stopChannel:
+		return ErrorStoped
+	case <-time.After(timeout):
+		return ErrorTimeout
+	}
+}
+
+// QueueRTP to brocast
+func (pusher *PSPusher) QueueRTP(pack *RTPPack) {
+	select {
+	case pusher.queue <- pack:
+	default:
+		log.WithField("id", pusher.ID()).Warn("pusher drop packet")
+	}
+}
+
+// AddPlayer and send cached GOP first
+func (pusher *PSPusher) AddPlayer(player Player) error {
+	if pusher.gopCacheEnable {
+		pusher.gopCacheLock.RLock()
+		for _, pack := range pusher.gopCache {
+			player.QueueRTP(pack)
+			pusher.AddOutputBytes(pack.Buffer.Len())
+		}
+		pusher.gopCacheLock.RUnlock()
+	}
+
+	return pusher.defaultPusher.AddPlayer(player)
+}
+
+// RemovePlayer, on-demand pusher stops when it is the last one
+func (pusher *PSPusher) RemovePlayer(player Player) {
+	pusher.playersLocker.Lock()
+	pusher.players = pusher.players.Delete(player.ID())
+	left := pusher.players.Len()
+	pusher.playersLocker.Unlock()
+
+	log.Infof("player %s end, now player size[%d]\n", player.ID(), left)
+	if pusher.onDemand && 0 == left {
+		pusher.Stop()
+	}
+}
+
+// Stoped state of PS pusher
+func (pusher *PSPusher) Stoped() bool {
+	pusher.stopLock.Lock()
+	stoped := pusher.stoped
+	pusher.stopLock.Unlock()
+
+	return stoped
+}
+
+// Stop receiving and players
+func (pusher *PSPusher) Stop() {
+	pusher.stopLock.Lock()
+	if pusher.stoped {
+		pusher.stopLock.Unlock()
+		return
+	}
+	pusher.stoped = true
+	close(pusher.stopChannel)
+	if nil != pusher.udpConn {
+		pusher.udpConn.Close()
+	}
+	if nil != pusher.tcpListener {
+		pusher.tcpListener.Close()
+	}
+	if nil != pusher.tcpConn {
+		pusher.tcpConn.Close()
+	}
+	if nil != pusher.mux {
+		pusher.mux.Unregister(pusher.SSRC(), pusher)
+	}
+	pusher.stopLock.Unlock()
+	log.WithField("id", pusher.ID()).Info("PS pusher stop")
+
+	pusher.server.keepPlayers(pusher)
+	pusher.ClearPlayer()
+	for _, h := range pusher.StopHandles {
+		h()
+	}
+}
+
+// AddOnStopHandle of PS pusher
+func (pusher *PSPusher) AddOnStopHandle(handle func()) {
+	pusher.StopHandles = append(pusher.StopHandles, handle)
+}
+
+// AControl of PS pusher
+func (pusher *PSPusher) AControl() []string {
+	if !pusher.ready() || nil == pusher.audio || "" == pusher.audio.codec {
+		return []string{}
+	}
+	return []string{pusher.audio.control}
+}
+
+// VControl of PS pusher
+func (pusher *PSPusher) VControl() string {
+	if !pusher.ready() {
+		return ""
+	}
+	return pusher.video.control
+}
+
+// ACodec of PS pusher
+func (pusher *PSPusher) ACodec() []string {
+	if !pusher.ready() || nil == pusher.audio || "" == pusher.audio.codec {
+		return []string{}
+	}
+	return []string{pusher.audio.codec}
+}
+
+// VCodec of PS pusher
+func (pusher *PSPusher) VCodec() string {
+	if !pusher.ready() {
+		return ""
+	}
+	return pusher.video.codec
+}
+
+// SDPRaw of PS pusher, empty before ready
+func (pusher *PSPusher) SDPRaw() string {
+	pusher.sdpLock.RLock()
+	defer pusher.sdpLock.RUnlock()
+
+	return pusher._SDPRaw
+}
@@ -0,0 +1,349 @@
+package rtsp
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// psSenderConnectTimeout of TCP connecting or waiting for remote connecting
+const psSenderConnectTimeout = 10 * time.Second
+
+// PSSender is a player of pusher, it sends the stream as MPEG-PS over RTP(UDP or RFC4571 TCP) to remote,
+// like GB28181 cascade to superior platform. Audio sender sends audio only, as PS or raw G.711 RTP
+type PSSender struct {
+	_ID       string
+	pusher    Pusher
+	transType TransType
+	remote    string
+	startAt   time.Time
+	inBytes   uint
+	outBytes  uint
+	// network
+	udpConn     *net.UDPConn
+	udpAddr     *net.UDPAddr
+	tcpListener *net.TCPListener
+	tcpConn     net.Conn
+	port        int
+	// media
+	video        *RTPDepacketizer
+	audio        *RTPDepacketizer
+	muxer        *PSMuxer
+	packetizer   *RTPPacketizer
+	keyFrameSent bool
+	// raw G.711 RTP instead of PS
+	raw   bool
+	queue chan *RTPPack
+	// stop
+	stoped      bool
+	stopLock    sync.Mutex
+	stopChannel chan int
+	StopHandles []func()
+}
+
+// NewPSSender of pusher to remote address with SSRC.
+// TCP transport connects remote, or listens for remote connecting if remote is empty.
+func NewPSSender(pusher Pusher, ID string, transType TransType, remote string, ssrc uint32) (_ *PSSender, err error) {
+	sdpInfos := ParseSDP(pusher.SDPRaw())
+	var video, audio *RTPDepacketizer
+	if codec := pusher.VCodec(); codec != "" {
+		if video = NewRTPDepacketizer(codec, sdpInfos["video"]); 0 == video.StreamType() {
+			video = nil
+		}
+	}
+	if codecs := pusher.ACodec(); len(codecs) > 0 && codecs[0] != "" {
+		if audio = NewRTPDepacketizer(codecs[0], sdpInfos["audio"]); 0 == audio.StreamType() {
+			audio = nil
+		}
+	}
+	if nil == video && nil == audio {
+		return nil, fmt.Errorf("PS sender unsupported codec %s %v", pusher.VCodec(), pusher.ACodec())
+	}
+
+	sender, err := newPSSender(ID, transType, remote, RTP_TYPE_VIDEO, 96, ssrc)
+	if nil != err {
+		return nil, err
+	}
+	sender.pusher = pusher
+	sender.video = video
+	sender.audio = audio
+	sender.muxer = NewPSMuxer(streamTypeOf(video), streamTypeOf(audio))
+	return sender, nil
+}
+
+// NewAudioSender of G.711 codec to remote address with SSRC, payload type 96 for PS or 8/0 for raw PCMA/PCMU.
+// Audio comes from pusher if it is not nil, or QueueRTP by the caller
+func NewAudioSender(pusher Pusher, codec string, ID string, transType TransType, remote string, ssrc uint32, payloadType int) (_ *PSSender, err error) {
+	audio := NewRTPDepacketizer(codec, nil)
+	switch audio.StreamType() {
+	case PSStreamTypeG711A, PSStreamTypeG711U:
+	default:
+		return nil, fmt.Errorf("audio sender unsupported codec %s", codec)
+	}
+
+	sender, err := newPSSender(ID, transType, remote, RTP_TYPE_AUDIO, payloadType, ssrc)
+	if nil != err {
+		return nil, err
+	}
+	sender.pusher = pusher
+	sender.audio = audio
+	sender.muxer = NewPSMuxer(0, audio.StreamType())
+	sender.raw = payloadType != 96
+	return sender, nil
+}
+
+func streamTypeOf(depacketizer *RTPDepacketizer) byte {
+	if nil == depacketizer {
+		return 0
+	}
+	return depacketizer.StreamType()
+}
+
+func newPSSender(ID string, transType TransType, remote string, rtpType RTPType, payloadType int, ssrc uint32) (_ *PSSender, err error) {
+	sender := &PSSender{
+		_ID:         ID,
+		transType:   transType,
+		remote:      remote,
+		packetizer:  NewRTPPacketizer(rtpType, payloadType),
+		queue:       make(chan *RTPPack, config.Player.SendQueueLength),
+		stopChannel: make(chan int),
+	}
+	sender.packetizer.SSRC = ssrc
+
+	switch transType {
+	case TRANS_TYPE_UDP:
+		if sender.udpAddr, err = net.ResolveUDPAddr("udp", remote); nil != err {
+			return nil, err
+		}
+		if sender.udpConn, err = net.ListenUDP("udp", &net.UDPAddr{}); nil != err {
+			return nil, err
+		}
+		sender.port = sender.udpConn.LocalAddr().(*net.UDPAddr).Port
+	case TRANS_TYPE_TCP:
+		if remote == "" {
+			if sender.tcpListener, err = net.ListenTCP("tcp", &net.TCPAddr{}); nil != err {
+				return nil, err
+			}
+			sender.port = sender.tcpListener.Addr().(*net.TCPAddr).Port
+		}
+	default:
+		return nil, fmt.Errorf("PS sender unsupported transport %s", transType)
+	}
+
+	return sender, nil
+}
+
+// Port of local, 0 when TCP connects remote
+func (sender *PSSender) Port() int {
+	return sender.port
+}
+
+// ID of PS sender
+func (sender *PSSender) ID() string {
+	return sender._ID
+}
+
+// Path of pusher, empty if audio is queued by the caller
+func (sender *PSSender) Path() string {
+	if nil == sender.pusher {
+		return ""
+	}
+	return sender.pusher.Path()
+}
+
+// TransType of PS sending
+func (sender *PSSender) TransType() TransType {
+	return sender.transType
+}
+
+// InBytes from pusher
+func (sender *PSSender) InBytes() uint {
+	return sender.inBytes
+}
+
+// OutBytes to remote
+func (sender *PSSender) OutBytes() uint {
+	return sender.outBytes
+}
+
+// StartAt of PS sender
+func (sender *PSSender) StartAt() time.Time {
+	return sender.startAt
+}
+
+// QueueRTP from pusher
+func (sender *PSSender) QueueRTP(pack *RTPPack) Player {
+	if pack == nil {
+		return sender
+	}
+	select {
+	case sender.queue <- pack:
+	default:
+		log.WithField("id", sender.ID()).Debug("PS sender queue full, drop it")
+	}
+	return sender
+}
+
+// Pause of PS sender is not supported, it is controlled by SIP
+func (sender *PSSender) Pause(paused bool) {
+}
+
+// Start sending until stop, it is called by Pusher.AddPlayer
+func (sender *PSSender) Start() {
+	sender.startAt = time.Now()
+	if sender.transType == TRANS_TYPE_TCP {
+		if err := sender.connect(); nil != err {
+			if !sender.Stoped() {
+				log.WithError(err).WithField("id", sender.ID()).Error("PS sender connect")
+				sender.Stop()
+			}
+			return
+		}
+	}
+	log.WithField("id", sender.ID()).Infof("PS sender of %s start to %s", sender.Path(), sender.remote)
+
+	for {
+		select {
+		case pack := <-sender.queue:
+			if err := sender.handleRTP(pack); nil != err {
+				if !sender.Stoped() {
+					log.WithError(err).WithField("id", sender.ID()).Error("PS sender send")
+					sender.Stop()
+				}
+				return
+			}
+		case <-sender.stopChannel:
+			return
+		}
+	}
+}
+
+func (sender *PSSender) connect() (err error) {
+	var conn net.Conn
+	if nil != sender.tcpListener {
+		sender.tcpListener.SetDeadline(time.Now().Add(psSenderConnectTimeout))
+		conn, err = sender.tcpListener.Accept()
+		sender.tcpListener.Close()
+	} else {
+		conn, err = net.DialTimeout("tcp", sender.remote, psSenderConnectTimeout)
+	}
+	if nil != err {
+		return
+	}
+	sender.stopLock.Lock()
+	sender.tcpConn = conn
+	sender.remote = conn.RemoteAddr().String()
+	stoped := sender.stoped
+	sender.stopLock.Unlock()
+	if stoped {
+		conn.Close()
+		return ErrorStoped
+	}
+	return
+}
+
+func (sender *PSSender) handleRTP(pack *RTPPack) error {
+	sender.inBytes += uint(pack.Buffer.Len())
+	info := ParseRTP(pack.Buffer.Bytes())
+	if nil == info {
+		return nil
+	}
+	if sender.raw {
+		if pack.Type != RTP_TYPE_AUDIO || pack.Channel != 0 {
+			return nil
+		}
+		return sender.sendPacks(sender.packetizer.PackG711(info.Payload, info.Timestamp))
+	}
+
+	var frames []*PSFrame
+	switch {
+	case pack.Type == RTP_TYPE_VIDEO && nil != sender.video:
+		frames = sender.video.InputRTP(info)
+	case pack.Type == RTP_TYPE_AUDIO && pack.Channel == 0 && nil != sender.audio:
+		frames = sender.audio.InputRTP(info)
+	}
+
+	for _, frame := range frames {
+		if nil != sender.video && !sender.keyFrameSent {
+			// receiver never decodes without key frame
+			if !IsKeyFrame(frame) {
+				continue
+			}
+			sender.keyFrameSent = true
+		}
+		if err := sender.sendPacks(sender.packetizer.PackPS(sender.muxer.Mux(frame), uint32(frame.PTS))); nil != err {
+			return err
+		}
+	}
+	return nil
+}
+
+func (sender *PSSender) sendPacks(packs []*RTPPack) error {
+	for _, rtp := range packs {
+		if err := sender.send(rtp.Buffer.Bytes()); nil != err {
+			return err
+		}
+	}
+	return nil
+}
+
+func (sender *PSSender) send(rtp []byte) (err error) {
+	if nil != sender.udpConn {
+		_, err = sender.udpConn.WriteToUDP(rtp, sender.udpAddr)
+	} else {
+		// RFC4571, 2 bytes length before each RTP packet
+		buf := make([]byte, 2+len(rtp))
+		binary.BigEndian.PutUint16(buf, uint16(len(rtp)))
+		copy(buf[2:], rtp)
+		_, err = sender.tcpConn.Write(buf)
+	}
+	if nil == err {
+		sender.outBytes += uint(len(rtp))
+	}
+	return
+}
+
+// Stoped or not
+func (sender *PSSender) Stoped() bool {
+	sender.stopLock.Lock()
+	stoped := sender.stoped
+	sender.stopLock.Unlock()
+
+	return stoped
+}
+
+// Stop sending and remove from pusher
+func (sender *PSSender) Stop() {
+	sender.stopLock.Lock()
+	if sender.stoped {
+		sender.stopLock.Unlock()
+		return
+	}
+	sender.stoped = true
+	close(sender.stopChannel)
+	if nil != sender.udpConn {
+		sender.udpConn.Close()
+	}
+	if nil != sender.tcpListener {
+		sender.tcpListener.Close()
+	}
+	if nil != sender.tcpConn {
+		sender.tcpConn.Close()
+	}
+	sender.stopLock.Unlock()
+	log.WithField("id", sender.ID()).Info("PS sender stop")
+
+	if nil != sender.pusher {
+		sender.pusher.RemovePlayer(sender)
+	}
+	for _, h := range sender.StopHandles {
+		h()
+	}
+}
+
+// AddOnStopHandle of PS sender
+func (sender *PSSender) AddOnStopHandle(handle func()) {
+	sender.StopHandles = append(sender.StopHandles, handle)
+}
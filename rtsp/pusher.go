@@ -2,6 +2,7 @@ package rtsp
 
 import (
 	"fmt"
+	"math/rand"
 	"strings"
 	"sync"
 	"time"
@@ -18,8 +19,24 @@ const (
 	PusherModePush PusherMode = iota
 	PusherModePull
 	PusherModeVOD
+	// MPEG-PS over RTP, like GB28181 device
+	PusherModePS
 )
 
+func (mode PusherMode) String() string {
+	switch mode {
+	case PusherModePush:
+		return "push"
+	case PusherModePull:
+		return "pull"
+	case PusherModeVOD:
+		return "vod"
+	case PusherModePS:
+		return "ps"
+	}
+	return "unknow"
+}
+
 // Pusher of RTSP server
 type Pusher interface {
 	// Info
@@ -52,6 +69,20 @@ type Pusher interface {
 	SDPRaw() string
 }
 
+// PlayControl of pusher source, like record playback of device
+type PlayControl interface {
+	// Play with Range and Scale header value of RTSP PLAY, empty if absent
+	Play(rangeValue string, scale string) error
+	Pause() error
+}
+
+// ControllablePusher follows PLAY and PAUSE of player
+type ControllablePusher interface {
+	// ControlPlay returns Range of the actual position, empty if unknown
+	ControlPlay(player Player, rangeValue string, scale string) (string, error)
+	ControlPause(player Player) error
+}
+
 type _Pusher struct {
 	*Session
 	*RTSPClient
@@ -63,6 +94,7 @@ type _Pusher struct {
 
 	spsppsInSTAPaPack bool
 	queue             chan *RTPPack
+	rtcp              *rtcpReceiver
 }
 
 func (pusher *_Pusher) String() string {
@@ -197,7 +229,7 @@ func (pusher *_Pusher) AddOnStopHandle(handle func()) {
 	if nil != pusher.RTSPClient {
 		pusher.RTSPClient.StopHandles = append(pusher.RTSPClient.StopHandles, handle)
 	} else if nil != pusher.Session {
-		pusher.Session.StopHandles = append(pusher.Session.StopHandles, handle)
+		pusher.Session.addStopHandle(handle)
 	}
 }
 
@@ -212,11 +244,13 @@ func NewClientPusher(client *RTSPClient) Pusher {
 		gopCache:       make([]*RTPPack, 0),
 
 		queue: make(chan *RTPPack, config.Player.SendQueueLength),
+		rtcp:  newRTCPReceiver(rand.Uint32()),
 	}
 	client.RTPHandles = append(client.RTPHandles, pusher.QueueRTP)
 	pusher.AddOnStopHandle(func() {
+		pusher.Server().keepPlayers(pusher)
 		pusher.ClearPlayer()
-		pusher.Server().RemovePusher(pusher.Path())
+		pusher.removeFromServer()
 	})
 
 	return pusher
@@ -233,16 +267,25 @@ func NewPusher(session *Session) Pusher {
 		gopCache:       make([]*RTPPack, 0),
 
 		queue: make(chan *RTPPack, config.Player.SendQueueLength),
+		rtcp:  newRTCPReceiver(rand.Uint32()),
 	}
 	session.RTPHandles = append(session.RTPHandles, pusher.QueueRTP)
 	pusher.AddOnStopHandle(func() {
+		pusher.Server().keepPlayers(pusher)
 		pusher.ClearPlayer()
-		pusher.Server().RemovePusher(pusher.Path())
+		pusher.removeFromServer()
 	})
 
 	return pusher
 }
 
+func (pusher *_Pusher) removeFromServer() {
+	// never remove the pusher replaced this one
+	if _pusher, ok := pusher.Server().GetPushers().Get(pusher.Path()); ok && _pusher.(Pusher) == Pusher(pusher) {
+		pusher.Server().RemovePusher(pusher.Path())
+	}
+}
+
 func (pusher *_Pusher) QueueRTP(pack *RTPPack) {
 	select {
 	case pusher.queue <- pack:
@@ -253,6 +296,7 @@ func (pusher *_Pusher) QueueRTP(pack *RTPPack) {
 
 func (pusher *_Pusher) Start() {
 	go pusher.CheckNoConnection()
+	pusher.rtcp.setSDP(pusher.SDPRaw())
 	for !pusher.Stoped() {
 		pack := <-pusher.queue
 		if pack == nil {
@@ -262,9 +306,24 @@ func (pusher *_Pusher) Start() {
 			continue
 		}
 
+		now := time.Now()
+		pusher.rtcp.handle(pack, now)
+		for _, rr := range pusher.rtcp.reports(now) {
+			pusher.sendRTCP(rr)
+		}
+		if pack.Type == RTP_TYPE_AUDIOCONTROL || pack.Type == RTP_TYPE_VIDEOCONTROL {
+			// RTCP of source ends here, players get SR of their own
+			continue
+		}
+
+		if (pusher.gopCacheEnable || config.RTSP.KeepPlayers != 0) && pack.Type == RTP_TYPE_VIDEO {
+			if rtp := ParseRTP(pack.Buffer.Bytes()); rtp != nil {
+				pack.keyFrame = pusher.shouldSequenceStart(rtp)
+			}
+		}
 		if pusher.gopCacheEnable && pack.Type == RTP_TYPE_VIDEO {
 			pusher.gopCacheLock.Lock()
-			if rtp := ParseRTP(pack.Buffer.Bytes()); rtp != nil && pusher.shouldSequenceStart(rtp) {
+			if pack.keyFrame {
 				pusher.gopCache = make([]*RTPPack, 0)
 			}
 			pusher.gopCache = append(pusher.gopCache, pack)
@@ -274,6 +333,41 @@ func (pusher *_Pusher) Start() {
 	}
 }
 
+// sendRTCP to source
+func (pusher *_Pusher) sendRTCP(pack *RTPPack) {
+	var err error
+	if session := pusher.Session; session != nil {
+		if session.TransType == TRANS_TYPE_UDP {
+			if session.UDPServer != nil {
+				err = session.UDPServer.SendRTCP(pack)
+			}
+		} else if session.rtcpReady(pack) {
+			err = session.SendRTP(pack)
+		}
+	} else if client := pusher.RTSPClient; client != nil {
+		if client.TransType == TRANS_TYPE_UDP {
+			if client.UDPServer != nil {
+				err = client.UDPServer.SendRTCP(pack)
+			}
+		} else {
+			err = client.SendRTCP(pack)
+		}
+	}
+	if err != nil {
+		log.WithError(err).WithField("id", pusher.ID()).Warn("pusher send RTCP")
+	}
+}
+
+// senderReport of source for SR to players
+func (pusher *_Pusher) senderReport(track int, ssrc uint32, now time.Time) (uint64, uint32, bool) {
+	return pusher.rtcp.senderReport(track, ssrc, now)
+}
+
+// RTCPStats of tracks received from source
+func (pusher *_Pusher) RTCPStats() []RTCPStats {
+	return pusher.rtcp.Stats()
+}
+
 func (pusher *_Pusher) Stop() {
 	if pusher.Session != nil {
 		pusher.Session.Stop()
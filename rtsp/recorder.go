@@ -94,6 +94,10 @@ func (recorder *_Recorder) QueueRTP(pack *RTPPack) Player {
 	return recorder
 }
 
+// Pause of recorder is not supported, record never pauses
+func (recorder *_Recorder) Pause(paused bool) {
+}
+
 const BlockHeaderLen = 20
 
 var _20byteDummy = []byte{
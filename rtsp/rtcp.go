@@ -0,0 +1,635 @@
+package rtsp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// RTCP packet types
+const (
+	RTCP_SR   = 200
+	RTCP_RR   = 201
+	RTCP_SDES = 202
+)
+
+// rtcpInterval of SR and RR sent by server
+const rtcpInterval = 5 * time.Second
+
+// rtcpCNAME in SDES of reports sent by server
+const rtcpCNAME = "EasyDarwin"
+
+// rtcpSRKeep of SR sent, RR referring to an older one is not used for RTT
+const rtcpSRKeep = 3 * rtcpInterval
+
+// track index of RTCP statistics, video and two audio channels
+const (
+	rtcpTrackVideo = iota
+	rtcpTrackAudio
+	rtcpTrackNum = rtcpTrackAudio + 2
+)
+
+func rtcpTrack(t RTPType, channel int) int {
+	switch t {
+	case RTP_TYPE_VIDEO, RTP_TYPE_VIDEOCONTROL:
+		return rtcpTrackVideo
+	case RTP_TYPE_AUDIO, RTP_TYPE_AUDIOCONTROL:
+		if channel >= 0 && channel < rtcpTrackNum-rtcpTrackAudio {
+			return rtcpTrackAudio + channel
+		}
+	}
+	return -1
+}
+
+func rtcpTrackName(track int) string {
+	if track == rtcpTrackVideo {
+		return "video"
+	}
+	return fmt.Sprintf("audio%d", track-rtcpTrackAudio)
+}
+
+func rtcpTrackType(track int) (RTPType, int) {
+	if track == rtcpTrackVideo {
+		return RTP_TYPE_VIDEOCONTROL, 0
+	}
+	return RTP_TYPE_AUDIOCONTROL, track - rtcpTrackAudio
+}
+
+// rtcpClockRates of tracks in SDP, 90000 for video and 8000 for audio if absent
+func rtcpClockRates(sdpRaw string) (rates [rtcpTrackNum]uint32) {
+	for i := range rates {
+		rates[i] = 8000
+	}
+	rates[rtcpTrackVideo] = 90000
+	sdpMap := ParseSDP(sdpRaw)
+	if info, ok := sdpMap["video"]; ok && info.TimeScale > 0 {
+		rates[rtcpTrackVideo] = uint32(info.TimeScale)
+	}
+	if info, ok := sdpMap["audio"]; ok && info.TimeScale > 0 {
+		for i := rtcpTrackAudio; i < rtcpTrackNum; i++ {
+			rates[i] = uint32(info.TimeScale)
+		}
+	}
+	return
+}
+
+// RTCPReportBlock of SR and RR
+type RTCPReportBlock struct {
+	SSRC         uint32
+	FractionLost uint8
+	// TotalLost is signed 24 bits
+	TotalLost  uint32
+	HighestSeq uint32
+	Jitter     uint32
+	LSR        uint32
+	DLSR       uint32
+}
+
+// RTCPPacket of SR or RR
+type RTCPPacket struct {
+	Type        uint8
+	SSRC        uint32
+	NTPTime     uint64
+	RTPTime     uint32
+	PacketCount uint32
+	OctetCount  uint32
+	Reports     []RTCPReportBlock
+}
+
+// ParseRTCP SR and RR in compound packet, others are skipped
+func ParseRTCP(data []byte) []*RTCPPacket {
+	packets := make([]*RTCPPacket, 0)
+	for len(data) >= 8 && data[0]>>6 == 2 {
+		count := int(data[0] & 0x1f)
+		length := (int(binary.BigEndian.Uint16(data[2:])) + 1) * 4
+		if length > len(data) {
+			break
+		}
+		packet := &RTCPPacket{
+			Type: data[1],
+			SSRC: binary.BigEndian.Uint32(data[4:]),
+		}
+		body := data[8:length]
+		data = data[length:]
+
+		switch packet.Type {
+		case RTCP_SR:
+			if len(body) < 20 {
+				continue
+			}
+			packet.NTPTime = binary.BigEndian.Uint64(body)
+			packet.RTPTime = binary.BigEndian.Uint32(body[8:])
+			packet.PacketCount = binary.BigEndian.Uint32(body[12:])
+			packet.OctetCount = binary.BigEndian.Uint32(body[16:])
+			body = body[20:]
+		case RTCP_RR:
+		default:
+			continue
+		}
+		for i := 0; i < count && len(body) >= 24; i++ {
+			packet.Reports = append(packet.Reports, RTCPReportBlock{
+				SSRC:         binary.BigEndian.Uint32(body),
+				FractionLost: body[4],
+				TotalLost:    binary.BigEndian.Uint32(body[4:]) & 0xffffff,
+				HighestSeq:   binary.BigEndian.Uint32(body[8:]),
+				Jitter:       binary.BigEndian.Uint32(body[12:]),
+				LSR:          binary.BigEndian.Uint32(body[16:]),
+				DLSR:         binary.BigEndian.Uint32(body[20:]),
+			})
+			body = body[24:]
+		}
+		packets = append(packets, packet)
+	}
+	return packets
+}
+
+// Bytes of SR or RR, followed by SDES with CNAME as a compound packet
+func (packet *RTCPPacket) Bytes() []byte {
+	length := 8 + 24*len(packet.Reports)
+	if packet.Type == RTCP_SR {
+		length += 20
+	}
+	b := make([]byte, length)
+	b[0] = 2<<6 | byte(len(packet.Reports))
+	b[1] = packet.Type
+	binary.BigEndian.PutUint16(b[2:], uint16(length/4-1))
+	binary.BigEndian.PutUint32(b[4:], packet.SSRC)
+	offset := 8
+	if packet.Type == RTCP_SR {
+		binary.BigEndian.PutUint64(b[8:], packet.NTPTime)
+		binary.BigEndian.PutUint32(b[16:], packet.RTPTime)
+		binary.BigEndian.PutUint32(b[20:], packet.PacketCount)
+		binary.BigEndian.PutUint32(b[24:], packet.OctetCount)
+		offset += 20
+	}
+	for _, report := range packet.Reports {
+		binary.BigEndian.PutUint32(b[offset:], report.SSRC)
+		binary.BigEndian.PutUint32(b[offset+4:], uint32(report.FractionLost)<<24|report.TotalLost&0xffffff)
+		binary.BigEndian.PutUint32(b[offset+8:], report.HighestSeq)
+		binary.BigEndian.PutUint32(b[offset+12:], report.Jitter)
+		binary.BigEndian.PutUint32(b[offset+16:], report.LSR)
+		binary.BigEndian.PutUint32(b[offset+20:], report.DLSR)
+		offset += 24
+	}
+	return append(b, rtcpSDES(packet.SSRC, rtcpCNAME)...)
+}
+
+// rtcpSDES with CNAME item of SSRC
+func rtcpSDES(ssrc uint32, cname string) []byte {
+	// header, SSRC, CNAME type and length, CNAME, and null items padded to 32 bits
+	length := (8 + 2 + len(cname) + 4) / 4 * 4
+	b := make([]byte, length)
+	b[0] = 2<<6 | 1
+	b[1] = RTCP_SDES
+	binary.BigEndian.PutUint16(b[2:], uint16(length/4-1))
+	binary.BigEndian.PutUint32(b[4:], ssrc)
+	b[8] = 1
+	b[9] = byte(len(cname))
+	copy(b[10:], cname)
+	return b
+}
+
+func rtcpPack(track int, packet *RTCPPacket) *RTPPack {
+	t, channel := rtcpTrackType(track)
+	return &RTPPack{
+		Type:    t,
+		Channel: channel,
+		Buffer:  bytes.NewBuffer(packet.Bytes()),
+	}
+}
+
+// toNTP timestamp of time, seconds since 1900 in 32.32 fixed point
+func toNTP(t time.Time) uint64 {
+	return uint64(t.Unix()+2208988800)<<32 | uint64(t.Nanosecond())<<32/uint64(time.Second)
+}
+
+// durationToNTP in 32.32 fixed point
+func durationToNTP(d time.Duration) uint64 {
+	return uint64(d/time.Second)<<32 | uint64(d%time.Second)<<32/uint64(time.Second)
+}
+
+// ntpMiddle 32 bits of NTP timestamp, used by LSR
+func ntpMiddle(ntp uint64) uint32 {
+	return uint32(ntp >> 16)
+}
+
+// ntpShortDuration of 16.16 fixed point, like DLSR
+func ntpShortDuration(short uint32) time.Duration {
+	return time.Duration(uint64(short) * uint64(time.Second) >> 16)
+}
+
+// rtcpRTT of report block received at now, 0 if no SR has been received by peer.
+// LSR must be of SR with NTP of local clock
+func rtcpRTT(report *RTCPReportBlock, now time.Time) time.Duration {
+	if report.LSR == 0 {
+		return 0
+	}
+	rtt := ntpMiddle(toNTP(now)) - report.LSR - report.DLSR
+	if int32(rtt) < 0 {
+		return 0
+	}
+	return ntpShortDuration(rtt)
+}
+
+// RTCPStats of a track
+type RTCPStats struct {
+	Track       string
+	SSRC        uint32
+	PacketsLost int64
+	// FractionLost in last report interval, 0 to 1
+	FractionLost float64
+	Jitter       time.Duration
+	RTT          time.Duration
+	// LastSR time received from source or sent to player, zero if none
+	LastSR time.Time
+}
+
+// rtpReceiver statistics of track, RFC 3550 A.1 and A.8
+type rtpReceiver struct {
+	clockRate     uint32
+	ssrc          uint32
+	startAt       time.Time
+	baseSeq       uint16
+	maxSeq        uint16
+	cycles        uint32
+	received      uint32
+	expectedPrior uint32
+	receivedPrior uint32
+	transit       uint32
+	jitter        float64
+	fractionLost  uint8
+	rtt           time.Duration
+	// last SR of source
+	lastSRNTP uint64
+	lastSRRTP uint32
+	lastSRAt  time.Time
+}
+
+func (r *rtpReceiver) update(seq uint16, timestamp uint32, ssrc uint32, arrival time.Time) {
+	if r.startAt.IsZero() || ssrc != r.ssrc {
+		// new source
+		*r = rtpReceiver{
+			clockRate: r.clockRate,
+			ssrc:      ssrc,
+			startAt:   arrival,
+			baseSeq:   seq,
+			maxSeq:    seq,
+			transit:   -timestamp,
+		}
+	}
+	if delta := seq - r.maxSeq; delta != 0 && delta < 0x8000 {
+		if seq < r.maxSeq {
+			r.cycles += 1 << 16
+		}
+		r.maxSeq = seq
+	}
+	r.received++
+
+	arrivalTimestamp := uint32(uint64(arrival.Sub(r.startAt)) * uint64(r.clockRate) / uint64(time.Second))
+	transit := arrivalTimestamp - timestamp
+	d := int32(transit - r.transit)
+	if d < 0 {
+		d = -d
+	}
+	r.transit = transit
+	r.jitter += (float64(d) - r.jitter) / 16
+}
+
+func (r *rtpReceiver) extendedMax() uint32 {
+	return r.cycles + uint32(r.maxSeq)
+}
+
+func (r *rtpReceiver) lost() int64 {
+	expected := int64(r.extendedMax()) - int64(r.baseSeq) + 1
+	return expected - int64(r.received)
+}
+
+func (r *rtpReceiver) report(now time.Time) RTCPReportBlock {
+	expected := r.extendedMax() - uint32(r.baseSeq) + 1
+	expectedInterval := expected - r.expectedPrior
+	receivedInterval := r.received - r.receivedPrior
+	r.expectedPrior = expected
+	r.receivedPrior = r.received
+	lostInterval := int64(expectedInterval) - int64(receivedInterval)
+	r.fractionLost = 0
+	if expectedInterval != 0 && lostInterval > 0 {
+		r.fractionLost = uint8(lostInterval << 8 / int64(expectedInterval))
+	}
+
+	report := RTCPReportBlock{
+		SSRC:         r.ssrc,
+		FractionLost: r.fractionLost,
+		TotalLost:    uint32(r.lost()) & 0xffffff,
+		HighestSeq:   r.extendedMax(),
+		Jitter:       uint32(r.jitter),
+	}
+	if !r.lastSRAt.IsZero() {
+		report.LSR = ntpMiddle(r.lastSRNTP)
+		report.DLSR = uint32(uint64(now.Sub(r.lastSRAt)) << 16 / uint64(time.Second))
+	}
+	return report
+}
+
+func (r *rtpReceiver) stats(track int) RTCPStats {
+	return RTCPStats{
+		Track:        rtcpTrackName(track),
+		SSRC:         r.ssrc,
+		PacketsLost:  r.lost(),
+		FractionLost: float64(r.fractionLost) / 256,
+		Jitter:       time.Duration(r.jitter * float64(time.Second) / float64(r.clockRate)),
+		RTT:          r.rtt,
+		LastSR:       r.lastSRAt,
+	}
+}
+
+// rtcpReceiver of pusher, RTP and SR of source are counted by track and RR is sent back
+type rtcpReceiver struct {
+	ssrc       uint32
+	clockRates [rtcpTrackNum]uint32
+	tracks     [rtcpTrackNum]*rtpReceiver
+	lastReport time.Time
+	lock       sync.Mutex
+}
+
+func newRTCPReceiver(ssrc uint32) *rtcpReceiver {
+	return &rtcpReceiver{
+		ssrc:       ssrc,
+		clockRates: rtcpClockRates(""),
+	}
+}
+
+// setSDP for clock rates of tracks
+func (receiver *rtcpReceiver) setSDP(sdpRaw string) {
+	receiver.lock.Lock()
+	defer receiver.lock.Unlock()
+	receiver.clockRates = rtcpClockRates(sdpRaw)
+}
+
+// handle RTP and RTCP of source
+func (receiver *rtcpReceiver) handle(pack *RTPPack, arrival time.Time) {
+	track := rtcpTrack(pack.Type, pack.Channel)
+	if track < 0 {
+		return
+	}
+	receiver.lock.Lock()
+	defer receiver.lock.Unlock()
+
+	switch pack.Type {
+	case RTP_TYPE_VIDEO, RTP_TYPE_AUDIO:
+		data := pack.Buffer.Bytes()
+		if len(data) < RTP_FIXED_HEADER_LENGTH {
+			return
+		}
+		if receiver.tracks[track] == nil {
+			receiver.tracks[track] = &rtpReceiver{clockRate: receiver.clockRates[track]}
+		}
+		receiver.tracks[track].update(
+			binary.BigEndian.Uint16(data[2:]),
+			binary.BigEndian.Uint32(data[4:]),
+			binary.BigEndian.Uint32(data[8:]),
+			arrival,
+		)
+	default:
+		r := receiver.tracks[track]
+		if r == nil {
+			return
+		}
+		for _, packet := range ParseRTCP(pack.Buffer.Bytes()) {
+			if packet.Type == RTCP_SR && packet.SSRC == r.ssrc {
+				r.lastSRNTP = packet.NTPTime
+				r.lastSRRTP = packet.RTPTime
+				r.lastSRAt = arrival
+			}
+			for i := range packet.Reports {
+				if packet.Reports[i].SSRC == receiver.ssrc {
+					r.rtt = rtcpRTT(&packet.Reports[i], arrival)
+				}
+			}
+		}
+	}
+}
+
+// reports of tracks if it is time to send RR
+func (receiver *rtcpReceiver) reports(now time.Time) []*RTPPack {
+	receiver.lock.Lock()
+	defer receiver.lock.Unlock()
+
+	if now.Sub(receiver.lastReport) < rtcpInterval {
+		return nil
+	}
+	receiver.lastReport = now
+	packs := make([]*RTPPack, 0)
+	for track, r := range receiver.tracks {
+		if r == nil {
+			continue
+		}
+		packs = append(packs, rtcpPack(track, &RTCPPacket{
+			Type:    RTCP_RR,
+			SSRC:    receiver.ssrc,
+			Reports: []RTCPReportBlock{r.report(now)},
+		}))
+	}
+	return packs
+}
+
+// senderReport of source, NTP and RTP timestamp of track at now
+func (receiver *rtcpReceiver) senderReport(track int, ssrc uint32, now time.Time) (ntp uint64, rtp uint32, ok bool) {
+	receiver.lock.Lock()
+	defer receiver.lock.Unlock()
+
+	r := receiver.tracks[track]
+	if r == nil || r.ssrc != ssrc || r.lastSRAt.IsZero() {
+		return 0, 0, false
+	}
+	elapsed := now.Sub(r.lastSRAt)
+	ntp = r.lastSRNTP + durationToNTP(elapsed)
+	rtp = r.lastSRRTP + uint32(uint64(elapsed)*uint64(r.clockRate)/uint64(time.Second))
+	return ntp, rtp, true
+}
+
+// Stats of tracks received
+func (receiver *rtcpReceiver) Stats() []RTCPStats {
+	receiver.lock.Lock()
+	defer receiver.lock.Unlock()
+
+	stats := make([]RTCPStats, 0)
+	for track, r := range receiver.tracks {
+		if r != nil {
+			stats = append(stats, r.stats(track))
+		}
+	}
+	return stats
+}
+
+// rtpSender statistics of track sent to player
+type rtpSender struct {
+	clockRate  uint32
+	ssrc       uint32
+	timestamp  uint32
+	sentAt     time.Time
+	packets    uint32
+	octets     uint32
+	lastReport time.Time
+	lastSR     time.Time
+	// SR sent at local time, key is LSR of it which may be NTP of source clock
+	srSentAt map[uint32]time.Time
+	// RR of player
+	report   RTCPReportBlock
+	reportAt time.Time
+	rtt      time.Duration
+}
+
+func (s *rtpSender) stats(track int) RTCPStats {
+	stats := RTCPStats{
+		Track:        rtcpTrackName(track),
+		SSRC:         s.ssrc,
+		PacketsLost:  int64(int32(s.report.TotalLost<<8) >> 8),
+		FractionLost: float64(s.report.FractionLost) / 256,
+		Jitter:       time.Duration(uint64(s.report.Jitter) * uint64(time.Second) / uint64(s.clockRate)),
+		RTT:          s.rtt,
+		LastSR:       s.lastSR,
+	}
+	return stats
+}
+
+// rtcpSource of SR mapping NTP to RTP timestamp, usually the pusher
+type rtcpSource interface {
+	senderReport(track int, ssrc uint32, now time.Time) (ntp uint64, rtp uint32, ok bool)
+}
+
+// rtcpSender of player, SR is sent with SSRC of source and RR of player is counted
+type rtcpSender struct {
+	clockRates [rtcpTrackNum]uint32
+	tracks     [rtcpTrackNum]*rtpSender
+	lock       sync.Mutex
+}
+
+func newRTCPSender(sdpRaw string) *rtcpSender {
+	return &rtcpSender{
+		clockRates: rtcpClockRates(sdpRaw),
+	}
+}
+
+// sent RTP to player
+func (sender *rtcpSender) sent(pack *RTPPack, now time.Time) {
+	if pack.Type != RTP_TYPE_VIDEO && pack.Type != RTP_TYPE_AUDIO {
+		return
+	}
+	track := rtcpTrack(pack.Type, pack.Channel)
+	data := pack.Buffer.Bytes()
+	if track < 0 || len(data) < RTP_FIXED_HEADER_LENGTH {
+		return
+	}
+	sender.lock.Lock()
+	defer sender.lock.Unlock()
+
+	s := sender.tracks[track]
+	ssrc := binary.BigEndian.Uint32(data[8:])
+	if s == nil || s.ssrc != ssrc {
+		s = &rtpSender{clockRate: sender.clockRates[track], ssrc: ssrc, lastReport: now, srSentAt: make(map[uint32]time.Time)}
+		sender.tracks[track] = s
+	}
+	s.timestamp = binary.BigEndian.Uint32(data[4:])
+	s.sentAt = now
+	s.packets++
+	s.octets += uint32(len(data) - RTP_FIXED_HEADER_LENGTH)
+}
+
+// reports of tracks if it is time to send SR, NTP is mapped by source if it is possible
+func (sender *rtcpSender) reports(source rtcpSource, now time.Time) []*RTPPack {
+	sender.lock.Lock()
+	defer sender.lock.Unlock()
+
+	var packs []*RTPPack
+	for track, s := range sender.tracks {
+		if s == nil || now.Sub(s.lastReport) < rtcpInterval {
+			continue
+		}
+		s.lastReport = now
+		s.lastSR = now
+		var ntp uint64
+		var rtp uint32
+		ok := false
+		if source != nil {
+			ntp, rtp, ok = source.senderReport(track, s.ssrc, now)
+		}
+		if !ok {
+			ntp = toNTP(now)
+			rtp = s.timestamp + uint32(uint64(now.Sub(s.sentAt))*uint64(s.clockRate)/uint64(time.Second))
+		}
+		for lsr, sentAt := range s.srSentAt {
+			if now.Sub(sentAt) > rtcpSRKeep {
+				delete(s.srSentAt, lsr)
+			}
+		}
+		s.srSentAt[ntpMiddle(ntp)] = now
+		packs = append(packs, rtcpPack(track, &RTCPPacket{
+			Type:        RTCP_SR,
+			SSRC:        s.ssrc,
+			NTPTime:     ntp,
+			RTPTime:     rtp,
+			PacketCount: s.packets,
+			OctetCount:  s.octets,
+		}))
+	}
+	return packs
+}
+
+// handle RTCP of player
+func (sender *rtcpSender) handle(pack *RTPPack, arrival time.Time) {
+	if pack.Type != RTP_TYPE_VIDEOCONTROL && pack.Type != RTP_TYPE_AUDIOCONTROL {
+		return
+	}
+	sender.lock.Lock()
+	defer sender.lock.Unlock()
+
+	for _, packet := range ParseRTCP(pack.Buffer.Bytes()) {
+		for i := range packet.Reports {
+			report := &packet.Reports[i]
+			for _, s := range sender.tracks {
+				if s != nil && s.ssrc == report.SSRC {
+					s.report = *report
+					s.reportAt = arrival
+					// LSR may be of source clock, RTT is by local time SR sent
+					if sentAt, ok := s.srSentAt[report.LSR]; ok && report.LSR != 0 {
+						if rtt := arrival.Sub(sentAt) - ntpShortDuration(report.DLSR); rtt > 0 {
+							s.rtt = rtt
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// Stats of tracks sent
+func (sender *rtcpSender) Stats() []RTCPStats {
+	sender.lock.Lock()
+	defer sender.lock.Unlock()
+
+	stats := make([]RTCPStats, 0)
+	for track, s := range sender.tracks {
+		if s != nil {
+			stats = append(stats, s.stats(track))
+		}
+	}
+	return stats
+}
+
+// rtcpReady of session to send RTCP, interleaved channel of TCP must be set up
+func (session *Session) rtcpReady(pack *RTPPack) bool {
+	if session.TransType != TRANS_TYPE_TCP {
+		return true
+	}
+	if pack.Type == RTP_TYPE_VIDEOCONTROL {
+		return session.vRTPControlChannel >= 0
+	}
+	return pack.Channel < len(session.aRTPControlChannel) && session.aRTPControlChannel[pack.Channel] >= 0
+}
+
+// RTCPReporter of pusher and player with RTCP statistics
+type RTCPReporter interface {
+	RTCPStats() []RTCPStats
+}
@@ -0,0 +1,133 @@
+package rtsp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRTCPPacketBytes(t *testing.T) {
+	report := RTCPReportBlock{
+		SSRC:         0x11223344,
+		FractionLost: 25,
+		TotalLost:    0xfffffe, // -2 in 24 bits
+		HighestSeq:   0x00010203,
+		Jitter:       120,
+		LSR:          0x12345678,
+		DLSR:         0x00008000,
+	}
+	tests := []struct {
+		name   string
+		packet *RTCPPacket
+	}{
+		{
+			name: "SR without report",
+			packet: &RTCPPacket{Type: RTCP_SR, SSRC: 0x01020304,
+				NTPTime: 0xe2b7f5c180000000, RTPTime: 90000, PacketCount: 10, OctetCount: 12000},
+		},
+		{
+			name: "SR with report",
+			packet: &RTCPPacket{Type: RTCP_SR, SSRC: 0x01020304,
+				NTPTime: 0xe2b7f5c180000000, RTPTime: 90000, PacketCount: 10, OctetCount: 12000,
+				Reports: []RTCPReportBlock{report}},
+		},
+		{
+			name:   "RR with reports",
+			packet: &RTCPPacket{Type: RTCP_RR, SSRC: 0x01020304, Reports: []RTCPReportBlock{report, {SSRC: 1}}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// SDES following is skipped
+			packets := ParseRTCP(test.packet.Bytes())
+			assert.Equal(t, []*RTCPPacket{test.packet}, packets)
+		})
+	}
+}
+
+func TestParseRTCP(t *testing.T) {
+	sr := (&RTCPPacket{Type: RTCP_SR, SSRC: 1, NTPTime: 2, RTPTime: 3}).Bytes()
+	rr := (&RTCPPacket{Type: RTCP_RR, SSRC: 4, Reports: []RTCPReportBlock{{SSRC: 5}}}).Bytes()
+	bye := []byte{0x81, 203, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01}
+	version1 := append([]byte{}, rr...)
+	version1[0] = 1<<6 | 1
+
+	tests := []struct {
+		name  string
+		data  []byte
+		types []uint8
+	}{
+		{name: "compound", data: bytes.Join([][]byte{sr, bye, rr}, nil), types: []uint8{RTCP_SR, RTCP_RR}},
+		{name: "truncated", data: rr[:20], types: []uint8{}},
+		{name: "SR too short", data: []byte{0x80, RTCP_SR, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01}, types: []uint8{}},
+		{name: "wrong version", data: version1, types: []uint8{}},
+		{name: "empty", data: []byte{}, types: []uint8{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			types := make([]uint8, 0)
+			for _, packet := range ParseRTCP(test.data) {
+				types = append(types, packet.Type)
+			}
+			assert.Equal(t, test.types, types)
+		})
+	}
+}
+
+// rtcpSkewedSource of SR with NTP of source clock
+type rtcpSkewedSource struct {
+	skew time.Duration
+}
+
+func (source rtcpSkewedSource) senderReport(track int, ssrc uint32, now time.Time) (uint64, uint32, bool) {
+	return toNTP(now.Add(source.skew)), 0, true
+}
+
+func TestRTCPSenderRTT(t *testing.T) {
+	tests := []struct {
+		name   string
+		source rtcpSource
+		// LSR of RR is changed if not 0
+		lsr uint32
+		rtt time.Duration
+	}{
+		{name: "local NTP", source: nil, rtt: 40 * time.Millisecond},
+		{name: "source NTP", source: rtcpSkewedSource{}, rtt: 40 * time.Millisecond},
+		{name: "source NTP of skewed clock", source: rtcpSkewedSource{skew: -time.Hour}, rtt: 40 * time.Millisecond},
+		{name: "unknown LSR", source: nil, lsr: 0x12345678, rtt: 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			now := time.Now()
+			sender := newRTCPSender("")
+			rtp := make([]byte, RTP_FIXED_HEADER_LENGTH+4)
+			rtp[0] = 0x80
+			binary.BigEndian.PutUint32(rtp[8:], 0xaabbccdd)
+			sender.sent(&RTPPack{Type: RTP_TYPE_VIDEO, Buffer: bytes.NewBuffer(rtp)}, now)
+
+			now = now.Add(rtcpInterval)
+			packs := sender.reports(test.source, now)
+			assert.Equal(t, 1, len(packs))
+			sr := ParseRTCP(packs[0].Buffer.Bytes())[0]
+
+			lsr := ntpMiddle(sr.NTPTime)
+			if test.lsr != 0 {
+				lsr = test.lsr
+			}
+			rr := &RTCPPacket{Type: RTCP_RR, SSRC: 1, Reports: []RTCPReportBlock{{
+				SSRC: 0xaabbccdd,
+				LSR:  lsr,
+				// 10ms delay of player
+				DLSR: uint32(durationToNTP(10*time.Millisecond) >> 16),
+			}}}
+			sender.handle(&RTPPack{Type: RTP_TYPE_VIDEOCONTROL, Buffer: bytes.NewBuffer(rr.Bytes())}, now.Add(50*time.Millisecond))
+
+			stats := sender.Stats()
+			assert.Equal(t, 1, len(stats))
+			assert.InDelta(t, float64(test.rtt), float64(stats[0].RTT), float64(time.Millisecond))
+		})
+	}
+}
@@ -0,0 +1,300 @@
+package rtsp
+
+import (
+	"bytes"
+	"strings"
+)
+
+// RTPDepacketizer rebuilds frames from RTP packets of H.264, H.265, G.711 and AAC,
+// video frames are access units in Annex B
+type RTPDepacketizer struct {
+	streamType byte
+	clockRate  int
+	// AAC
+	config      []byte
+	sizeLength  int
+	indexLength int
+	// video
+	parameterSets [][]byte
+	nalus         [][]byte
+	fu            []byte
+	timestamp     uint32
+	sequence      uint16
+	started       bool
+	lost          bool
+}
+
+// NewRTPDepacketizer of codec name in SDP rtpmap, info gives parameter sets and AAC config if not nil
+func NewRTPDepacketizer(codec string, info *SDPInfo) *RTPDepacketizer {
+	depacketizer := &RTPDepacketizer{
+		clockRate:   90000,
+		sizeLength:  13,
+		indexLength: 3,
+	}
+	switch strings.ToUpper(codec) {
+	case "H264":
+		depacketizer.streamType = PSStreamTypeH264
+	case "H265", "HEVC":
+		depacketizer.streamType = PSStreamTypeH265
+	case "PCMA":
+		depacketizer.streamType = PSStreamTypeG711A
+		depacketizer.clockRate = 8000
+	case "PCMU":
+		depacketizer.streamType = PSStreamTypeG711U
+		depacketizer.clockRate = 8000
+	case "MPEG4-GENERIC":
+		depacketizer.streamType = PSStreamTypeAAC
+	}
+	if nil != info {
+		if info.TimeScale > 0 {
+			depacketizer.clockRate = info.TimeScale
+		}
+		if info.SizeLength > 0 {
+			depacketizer.sizeLength = info.SizeLength
+			depacketizer.indexLength = info.IndexLength
+		}
+		depacketizer.config = info.Config
+		for _, nalu := range info.SpropParameterSets {
+			if len(nalu) > 0 {
+				depacketizer.parameterSets = append(depacketizer.parameterSets, nalu)
+			}
+		}
+	}
+	return depacketizer
+}
+
+// StreamType of PS, 0 if codec unsupported
+func (depacketizer *RTPDepacketizer) StreamType() byte {
+	if PSStreamTypeAAC == depacketizer.streamType && len(depacketizer.config) < 2 {
+		// ADTS can not be made without AudioSpecificConfig
+		return 0
+	}
+	return depacketizer.streamType
+}
+
+// InputRTP returns frames completed, PTS is in 90kHz
+func (depacketizer *RTPDepacketizer) InputRTP(info *RTPInfo) (frames []*PSFrame) {
+	switch depacketizer.streamType {
+	case PSStreamTypeH264, PSStreamTypeH265:
+		return depacketizer.inputVideo(info)
+	case PSStreamTypeG711A, PSStreamTypeG711U:
+		return []*PSFrame{depacketizer.frame(false, info.Timestamp, info.Payload)}
+	case PSStreamTypeAAC:
+		return depacketizer.inputAAC(info)
+	}
+	return
+}
+
+func (depacketizer *RTPDepacketizer) frame(video bool, timestamp uint32, data []byte) *PSFrame {
+	return &PSFrame{
+		Video:      video,
+		StreamType: depacketizer.streamType,
+		PTS:        uint64(timestamp) * 90000 / uint64(depacketizer.clockRate),
+		Data:       data,
+	}
+}
+
+func (depacketizer *RTPDepacketizer) inputVideo(info *RTPInfo) (frames []*PSFrame) {
+	if depacketizer.started && info.SequenceNumber != depacketizer.sequence+1 {
+		// packet lost, drop the access unit
+		depacketizer.lost = true
+		depacketizer.fu = nil
+	}
+	depacketizer.started = true
+	depacketizer.sequence = info.SequenceNumber
+
+	if info.Timestamp != depacketizer.timestamp {
+		// marker may be lost
+		if frame := depacketizer.flush(); nil != frame {
+			frames = append(frames, frame)
+		}
+		depacketizer.lost = false
+		depacketizer.fu = nil
+		depacketizer.timestamp = info.Timestamp
+	}
+
+	if !depacketizer.lost {
+		if PSStreamTypeH264 == depacketizer.streamType {
+			depacketizer.inputH264(info.Payload)
+		} else {
+			depacketizer.inputH265(info.Payload)
+		}
+	}
+
+	if info.Marker {
+		if frame := depacketizer.flush(); nil != frame {
+			frames = append(frames, frame)
+		}
+		depacketizer.lost = false
+	}
+	return
+}
+
+// inputH264 of RFC6184 single NAL unit, STAP-A and FU-A
+func (depacketizer *RTPDepacketizer) inputH264(payload []byte) {
+	if len(payload) < 1 {
+		return
+	}
+	switch nalType := payload[0] & 0x1f; nalType {
+	case 24: // STAP-A
+		depacketizer.aggregate(payload[1:])
+	case 28: // FU-A
+		if len(payload) < 2 {
+			return
+		}
+		header := payload[1]
+		if header&0x80 != 0 {
+			depacketizer.fu = append([]byte{payload[0]&0xe0 | header&0x1f}, payload[2:]...)
+		} else if nil != depacketizer.fu {
+			depacketizer.fu = append(depacketizer.fu, payload[2:]...)
+		}
+		if header&0x40 != 0 && nil != depacketizer.fu {
+			depacketizer.nalus = append(depacketizer.nalus, depacketizer.fu)
+			depacketizer.fu = nil
+		}
+	default:
+		depacketizer.nalus = append(depacketizer.nalus, payload)
+	}
+}
+
+// inputH265 of RFC7798 single NAL unit, AP and FU
+func (depacketizer *RTPDepacketizer) inputH265(payload []byte) {
+	if len(payload) < 2 {
+		return
+	}
+	switch nalType := (payload[0] >> 1) & 0x3f; nalType {
+	case 48: // AP
+		depacketizer.aggregate(payload[2:])
+	case 49: // FU
+		if len(payload) < 3 {
+			return
+		}
+		header := payload[2]
+		if header&0x80 != 0 {
+			depacketizer.fu = append([]byte{payload[0]&0x81 | (header&0x3f)<<1, payload[1]}, payload[3:]...)
+		} else if nil != depacketizer.fu {
+			depacketizer.fu = append(depacketizer.fu, payload[3:]...)
+		}
+		if header&0x40 != 0 && nil != depacketizer.fu {
+			depacketizer.nalus = append(depacketizer.nalus, depacketizer.fu)
+			depacketizer.fu = nil
+		}
+	default:
+		depacketizer.nalus = append(depacketizer.nalus, payload)
+	}
+}
+
+// aggregate NAL units with 2 bytes size before each
+func (depacketizer *RTPDepacketizer) aggregate(data []byte) {
+	for len(data) > 2 {
+		size := int(data[0])<<8 | int(data[1])
+		if size == 0 || size > len(data)-2 {
+			return
+		}
+		depacketizer.nalus = append(depacketizer.nalus, data[2:2+size])
+		data = data[2+size:]
+	}
+}
+
+// flush access unit, parameter sets are added before key frame without them
+func (depacketizer *RTPDepacketizer) flush() *PSFrame {
+	nalus := depacketizer.nalus
+	depacketizer.nalus = nil
+	if len(nalus) == 0 || depacketizer.lost {
+		return nil
+	}
+
+	keyFrame, hasParameterSets := false, false
+	for _, nalu := range nalus {
+		keyFrame = keyFrame || isKeyFrameNALU(depacketizer.streamType, nalu)
+		hasParameterSets = hasParameterSets || isParameterSet(depacketizer.streamType, nalu)
+	}
+	if hasParameterSets {
+		// in-band parameter sets are newer than SDP
+		depacketizer.parameterSets = nil
+		for _, nalu := range nalus {
+			if isParameterSet(depacketizer.streamType, nalu) {
+				depacketizer.parameterSets = append(depacketizer.parameterSets, append([]byte{}, nalu...))
+			}
+		}
+	} else if keyFrame {
+		nalus = append(append([][]byte{}, depacketizer.parameterSets...), nalus...)
+	}
+
+	buffer := &bytes.Buffer{}
+	for _, nalu := range nalus {
+		buffer.Write([]byte{0x00, 0x00, 0x00, 0x01})
+		buffer.Write(nalu)
+	}
+	return depacketizer.frame(true, depacketizer.timestamp, buffer.Bytes())
+}
+
+// inputAAC of RFC3640 AAC-hbr, ADTS header is added to each AU
+func (depacketizer *RTPDepacketizer) inputAAC(info *RTPInfo) (frames []*PSFrame) {
+	payload := info.Payload
+	if len(payload) < 2 || len(depacketizer.config) < 2 {
+		return
+	}
+	headersBits := int(payload[0])<<8 | int(payload[1])
+	headersLength := (headersBits + 7) / 8
+	auHeaderBits := depacketizer.sizeLength + depacketizer.indexLength
+	if auHeaderBits <= 0 || len(payload) < 2+headersLength {
+		return
+	}
+	headers := payload[2 : 2+headersLength]
+	data := payload[2+headersLength:]
+
+	// AudioSpecificConfig: profile(5) sampleRateIndex(4) channels(4)
+	profile := int(depacketizer.config[0]>>3) - 1
+	sampleRateIndex := int(depacketizer.config[0]&0x07)<<1 | int(depacketizer.config[1]>>7)
+	channels := int(depacketizer.config[1]>>3) & 0x0f
+
+	timestamp := info.Timestamp
+	for bit := 0; bit+auHeaderBits <= headersBits; bit += auHeaderBits {
+		size := readBits(headers, bit, depacketizer.sizeLength)
+		if size > len(data) {
+			break
+		}
+		frameLength := size + 7
+		adts := []byte{
+			0xff, 0xf1,
+			byte(profile<<6 | sampleRateIndex<<2 | channels>>2),
+			byte((channels&0x03)<<6 | frameLength>>11),
+			byte(frameLength >> 3),
+			byte((frameLength&0x07)<<5 | 0x1f),
+			0xfc,
+		}
+		frames = append(frames, depacketizer.frame(false, timestamp, append(adts, data[:size]...)))
+		data = data[size:]
+		timestamp += 1024
+	}
+	return
+}
+
+func readBits(data []byte, offset int, count int) (value int) {
+	for i := 0; i < count; i++ {
+		index := (offset + i) / 8
+		if index >= len(data) {
+			return
+		}
+		value = value<<1 | int(data[index]>>(7-uint((offset+i)%8)))&0x01
+	}
+	return
+}
+
+func isParameterSet(streamType byte, nalu []byte) bool {
+	if PSStreamTypeH264 == streamType {
+		nalType := nalu[0] & 0x1f
+		return nalType == 7 || nalType == 8
+	}
+	nalType := (nalu[0] >> 1) & 0x3f
+	return nalType >= 32 && nalType <= 34
+}
+
+func isKeyFrameNALU(streamType byte, nalu []byte) bool {
+	if PSStreamTypeH264 == streamType {
+		return nalu[0]&0x1f == 5
+	}
+	nalType := (nalu[0] >> 1) & 0x3f
+	return nalType >= 16 && nalType <= 21
+}
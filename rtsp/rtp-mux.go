@@ -0,0 +1,247 @@
+package rtsp
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RTPHandler of RTP packets demultiplexed by SSRC, data is not referred after return
+type RTPHandler interface {
+	HandleRTP(data []byte)
+}
+
+// RTPMux receives RTP of all streams on a single UDP and TCP port,
+// and dispatches packets to handlers by SSRC
+type RTPMux struct {
+	port        int
+	udpConn     *net.UDPConn
+	tcpListener *net.TCPListener
+	handlers    map[uint32]RTPHandler
+	lock        sync.RWMutex
+	stoped      bool
+}
+
+// NewRTPMux listening on port of both UDP and TCP(RFC4571)
+func NewRTPMux(port int) (mux *RTPMux, err error) {
+	mux = &RTPMux{
+		port:     port,
+		handlers: make(map[uint32]RTPHandler),
+	}
+	if mux.udpConn, err = net.ListenUDP("udp", &net.UDPAddr{Port: port}); nil != err {
+		return nil, err
+	}
+	if err := mux.udpConn.SetReadBuffer(config.RTSP.NetworkBuffer); err != nil {
+		log.WithError(err).Warn("RTP mux set read buffer")
+	}
+	if mux.tcpListener, err = net.ListenTCP("tcp", &net.TCPAddr{Port: port}); nil != err {
+		mux.udpConn.Close()
+		return nil, err
+	}
+	return mux, nil
+}
+
+// Port of RTP mux
+func (mux *RTPMux) Port() int {
+	return mux.port
+}
+
+// Start receiving
+func (mux *RTPMux) Start() {
+	log.Infof("RTP mux start on[%d]", mux.port)
+	go mux.acceptTCPLoop()
+	mux.readUDPLoop()
+}
+
+// Stop receiving, handlers are kept by their owners
+func (mux *RTPMux) Stop() {
+	mux.lock.Lock()
+	mux.stoped = true
+	mux.lock.Unlock()
+
+	mux.udpConn.Close()
+	mux.tcpListener.Close()
+	log.Infof("RTP mux stop on[%d]", mux.port)
+}
+
+// Register handler of SSRC, fails if SSRC is taken by another one
+func (mux *RTPMux) Register(ssrc uint32, handler RTPHandler) error {
+	mux.lock.Lock()
+	defer mux.lock.Unlock()
+
+	if old, ok := mux.handlers[ssrc]; ok && old != handler {
+		return fmt.Errorf("SSRC[%d] is in use", ssrc)
+	}
+	mux.handlers[ssrc] = handler
+	return nil
+}
+
+// Unregister SSRC only if it is still of handler
+func (mux *RTPMux) Unregister(ssrc uint32, handler RTPHandler) {
+	mux.lock.Lock()
+	if mux.handlers[ssrc] == handler {
+		delete(mux.handlers, ssrc)
+	}
+	mux.lock.Unlock()
+}
+
+func (mux *RTPMux) getHandler(data []byte) (uint32, RTPHandler) {
+	if len(data) < RTP_FIXED_HEADER_LENGTH || data[0]>>6 != 2 {
+		return 0, nil
+	}
+	ssrc := binary.BigEndian.Uint32(data[8:])
+
+	mux.lock.RLock()
+	handler := mux.handlers[ssrc]
+	mux.lock.RUnlock()
+
+	return ssrc, handler
+}
+
+func (mux *RTPMux) isStoped() bool {
+	mux.lock.RLock()
+	defer mux.lock.RUnlock()
+
+	return mux.stoped
+}
+
+// retryDelay of read errors, doubled from 5ms to 1s like net/http
+func retryDelay(delay time.Duration) time.Duration {
+	if delay == 0 {
+		return 5 * time.Millisecond
+	}
+	if delay *= 2; delay > time.Second {
+		return time.Second
+	}
+	return delay
+}
+
+// readUDPLoop until mux is stoped or closed, other errors are retried after a delay
+func (mux *RTPMux) readUDPLoop() {
+	buf := make([]byte, 65536)
+	var tempDelay time.Duration
+	for {
+		n, addr, err := mux.udpConn.ReadFromUDP(buf)
+		if nil != err {
+			if mux.isStoped() || strings.Contains(err.Error(), "use of closed network connection") {
+				return
+			}
+			tempDelay = retryDelay(tempDelay)
+			log.WithError(err).Errorf("RTP mux read udp, retrying in %v", tempDelay)
+			time.Sleep(tempDelay)
+			continue
+		}
+		tempDelay = 0
+		ssrc, handler := mux.getHandler(buf[:n])
+		if nil == handler {
+			log.Debugf("RTP mux drop packet of SSRC[%d] from %s", ssrc, addr)
+			continue
+		}
+		handler.HandleRTP(buf[:n])
+	}
+}
+
+func (mux *RTPMux) acceptTCPLoop() {
+	for {
+		conn, err := mux.tcpListener.Accept()
+		if nil != err {
+			if !mux.isStoped() {
+				log.WithError(err).Error("RTP mux accept tcp")
+			}
+			return
+		}
+		go mux.readTCPLoop(conn)
+	}
+}
+
+// readTCPLoop of one stream, the connection is closed once its SSRC is unregistered
+func (mux *RTPMux) readTCPLoop(conn net.Conn) {
+	defer conn.Close()
+
+	// RFC4571, 2 bytes length before each RTP packet
+	reader := bufio.NewReaderSize(conn, config.RTSP.NetworkBuffer)
+	header := make([]byte, 2)
+	buf := make([]byte, 65536)
+	timeout := time.Duration(config.RTP.ReceiveTimeout) * time.Second
+	matched := false
+	for {
+		// connection never sending RTP of a registered SSRC must not be kept
+		if !matched && timeout > 0 {
+			conn.SetReadDeadline(time.Now().Add(timeout))
+		}
+		if _, err := io.ReadFull(reader, header); nil != err {
+			if !matched {
+				log.Infof("RTP mux close tcp from %s without SSRC registered, %v", conn.RemoteAddr(), err)
+			}
+			return
+		}
+		length := int(binary.BigEndian.Uint16(header))
+		if _, err := io.ReadFull(reader, buf[:length]); nil != err {
+			return
+		}
+		ssrc, handler := mux.getHandler(buf[:length])
+		if nil == handler {
+			log.Infof("RTP mux close tcp of SSRC[%d] from %s", ssrc, conn.RemoteAddr())
+			return
+		}
+		if !matched {
+			// receive timeout of stream is checked by its handler since then
+			matched = true
+			conn.SetReadDeadline(time.Time{})
+		}
+		handler.HandleRTP(buf[:length])
+	}
+}
+
+// portRange of RTP listening, ports are chosen in turn
+type portRange struct {
+	lock sync.Mutex
+	next int
+}
+
+var rtpPorts = &portRange{}
+
+// listen in port range of config, or port chosen by system without range
+func (ports *portRange) listen(listen func(port int) error) error {
+	min, max := config.RTP.PortMin, config.RTP.PortMax
+	if min <= 0 || max < min {
+		return listen(0)
+	}
+
+	ports.lock.Lock()
+	defer ports.lock.Unlock()
+	for i := 0; i <= max-min; i++ {
+		if ports.next < min || ports.next > max {
+			ports.next = min
+		}
+		port := ports.next
+		ports.next++
+		if err := listen(port); nil == err {
+			return nil
+		}
+	}
+	return fmt.Errorf("no port available in [%d, %d]", min, max)
+}
+
+// listenUDP on port of range
+func listenUDP() (conn *net.UDPConn, err error) {
+	err = rtpPorts.listen(func(port int) (err error) {
+		conn, err = net.ListenUDP("udp", &net.UDPAddr{Port: port})
+		return
+	})
+	return
+}
+
+// listenTCP on port of range
+func listenTCP() (listener *net.TCPListener, err error) {
+	err = rtpPorts.listen(func(port int) (err error) {
+		listener, err = net.ListenTCP("tcp", &net.TCPAddr{Port: port})
+		return
+	})
+	return
+}
@@ -0,0 +1,203 @@
+package rtsp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/rand"
+)
+
+// RTPPacketizer packs elementary stream frames into RTP packets
+type RTPPacketizer struct {
+	Type        RTPType
+	PayloadType int
+	SSRC        uint32
+	sequence    uint16
+	maxSize     int
+}
+
+// NewRTPPacketizer returns
+func NewRTPPacketizer(rtpType RTPType, payloadType int) *RTPPacketizer {
+	maxSize := config.RTP.MaxSize
+	if maxSize <= RTP_FIXED_HEADER_LENGTH+3 {
+		maxSize = 1200
+	}
+	return &RTPPacketizer{
+		Type:        rtpType,
+		PayloadType: payloadType,
+		SSRC:        rand.Uint32(),
+		sequence:    uint16(rand.Uint32()),
+		maxSize:     maxSize,
+	}
+}
+
+func (p *RTPPacketizer) packet(timestamp uint32, marker bool, payloads ...[]byte) *RTPPack {
+	header := make([]byte, RTP_FIXED_HEADER_LENGTH)
+	header[0] = 0x80
+	header[1] = byte(p.PayloadType & 0x7f)
+	if marker {
+		header[1] |= 0x80
+	}
+	binary.BigEndian.PutUint16(header[2:], p.sequence)
+	binary.BigEndian.PutUint32(header[4:], timestamp)
+	binary.BigEndian.PutUint32(header[8:], p.SSRC)
+	p.sequence++
+
+	buffer := bytes.NewBuffer(header)
+	for _, payload := range payloads {
+		buffer.Write(payload)
+	}
+	return &RTPPack{
+		Type:   p.Type,
+		Buffer: buffer,
+	}
+}
+
+// PackH264 NAL units of one access unit, RFC6184 single NAL unit and FU-A
+func (p *RTPPacketizer) PackH264(nalus [][]byte, timestamp uint32) (packs []*RTPPack) {
+	maxPayload := p.maxSize - RTP_FIXED_HEADER_LENGTH
+	for i, nalu := range nalus {
+		last := i == len(nalus)-1
+		if len(nalu) <= maxPayload {
+			packs = append(packs, p.packet(timestamp, last, nalu))
+			continue
+		}
+		indicator := nalu[0]&0xe0 | 28
+		nalType := nalu[0] & 0x1f
+		data := nalu[1:]
+		for start := true; len(data) > 0; start = false {
+			size := maxPayload - 2
+			if size > len(data) {
+				size = len(data)
+			}
+			header := nalType
+			if start {
+				header |= 0x80
+			}
+			end := size == len(data)
+			if end {
+				header |= 0x40
+			}
+			packs = append(packs, p.packet(timestamp, last && end, []byte{indicator, header}, data[:size]))
+			data = data[size:]
+		}
+	}
+	return
+}
+
+// PackH265 NAL units of one access unit, RFC7798 single NAL unit and FU
+func (p *RTPPacketizer) PackH265(nalus [][]byte, timestamp uint32) (packs []*RTPPack) {
+	maxPayload := p.maxSize - RTP_FIXED_HEADER_LENGTH
+	for i, nalu := range nalus {
+		last := i == len(nalus)-1
+		if len(nalu) <= maxPayload || len(nalu) < 3 {
+			packs = append(packs, p.packet(timestamp, last, nalu))
+			continue
+		}
+		payloadHeader := []byte{nalu[0]&0x81 | 49<<1, nalu[1]}
+		nalType := (nalu[0] >> 1) & 0x3f
+		data := nalu[2:]
+		for start := true; len(data) > 0; start = false {
+			size := maxPayload - 3
+			if size > len(data) {
+				size = len(data)
+			}
+			header := nalType
+			if start {
+				header |= 0x80
+			}
+			end := size == len(data)
+			if end {
+				header |= 0x40
+			}
+			packs = append(packs, p.packet(timestamp, last && end, payloadHeader, []byte{header}, data[:size]))
+			data = data[size:]
+		}
+	}
+	return
+}
+
+// PackPS pack of MPEG-PS, marker is set on the last packet
+func (p *RTPPacketizer) PackPS(data []byte, timestamp uint32) (packs []*RTPPack) {
+	maxPayload := p.maxSize - RTP_FIXED_HEADER_LENGTH
+	for len(data) > 0 {
+		size := maxPayload
+		if size > len(data) {
+			size = len(data)
+		}
+		packs = append(packs, p.packet(timestamp, size == len(data), data[:size]))
+		data = data[size:]
+	}
+	return
+}
+
+// PackG711 samples, one byte per sample
+func (p *RTPPacketizer) PackG711(data []byte, timestamp uint32) (packs []*RTPPack) {
+	maxPayload := p.maxSize - RTP_FIXED_HEADER_LENGTH
+	for len(data) > 0 {
+		size := maxPayload
+		if size > len(data) {
+			size = len(data)
+		}
+		packs = append(packs, p.packet(timestamp, false, data[:size]))
+		timestamp += uint32(size)
+		data = data[size:]
+	}
+	return
+}
+
+// PackAAC frames with ADTS header, RFC3640 AAC-hbr one AU per packet
+func (p *RTPPacketizer) PackAAC(data []byte, timestamp uint32) (packs []*RTPPack) {
+	for len(data) >= 7 {
+		header, ok := parseADTS(data)
+		if !ok || header.frameLength > len(data) {
+			break
+		}
+		au := data[header.headerLength:header.frameLength]
+		auHeader := []byte{0x00, 0x10, byte(len(au) >> 5), byte(len(au) << 3)}
+		packs = append(packs, p.packet(timestamp, true, auHeader, au))
+		timestamp += 1024
+		data = data[header.frameLength:]
+	}
+	return
+}
+
+type adtsHeader struct {
+	profile         int
+	sampleRateIndex int
+	channels        int
+	headerLength    int
+	frameLength     int
+}
+
+var aacSampleRates = []int{96000, 88200, 64000, 48000, 44100, 32000, 24000, 22050, 16000, 12000, 11025, 8000, 7350}
+
+func parseADTS(data []byte) (header adtsHeader, ok bool) {
+	if len(data) < 7 || data[0] != 0xff || data[1]&0xf0 != 0xf0 {
+		return
+	}
+	header.profile = int(data[2]>>6) + 1
+	header.sampleRateIndex = int(data[2]>>2) & 0x0f
+	header.channels = int(data[2]&0x01)<<2 | int(data[3]>>6)
+	header.headerLength = 7
+	if data[1]&0x01 == 0 {
+		// CRC
+		header.headerLength = 9
+	}
+	header.frameLength = int(data[3]&0x03)<<11 | int(data[4])<<3 | int(data[5]>>5)
+	if header.sampleRateIndex >= len(aacSampleRates) || header.frameLength < header.headerLength {
+		return
+	}
+	ok = true
+	return
+}
+
+// sampleRate of ADTS
+func (header adtsHeader) sampleRate() int {
+	return aacSampleRates[header.sampleRateIndex]
+}
+
+// audioSpecificConfig for SDP fmtp
+func (header adtsHeader) audioSpecificConfig() []byte {
+	asc := header.profile<<11 | header.sampleRateIndex<<7 | header.channels<<3
+	return []byte{byte(asc >> 8), byte(asc)}
+}
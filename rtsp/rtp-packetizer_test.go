@@ -0,0 +1,129 @@
+package rtsp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRTPPacketizerPackH264(t *testing.T) {
+	tests := []struct {
+		name    string
+		nalus   [][]byte
+		payload [][]byte
+		marker  []bool
+	}{
+		{
+			name:    "single NAL units",
+			nalus:   [][]byte{{0x67, 0x42, 0x00}, {0x68, 0xce}},
+			payload: [][]byte{{0x67, 0x42, 0x00}, {0x68, 0xce}},
+			marker:  []bool{false, true},
+		},
+		{
+			name:  "FU-A",
+			nalus: [][]byte{{0x65, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10}},
+			payload: [][]byte{
+				{0x7c, 0x85, 1, 2, 3, 4},
+				{0x7c, 0x05, 5, 6, 7, 8},
+				{0x7c, 0x45, 9, 10},
+			},
+			marker: []bool{false, false, true},
+		},
+		{
+			name:  "FU-A followed by single NAL unit",
+			nalus: [][]byte{{0x41, 1, 2, 3, 4, 5, 6}, {0x41, 7}},
+			payload: [][]byte{
+				{0x5c, 0x81, 1, 2, 3, 4},
+				{0x5c, 0x41, 5, 6},
+				{0x41, 7},
+			},
+			marker: []bool{false, false, true},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			packetizer := NewRTPPacketizer(RTP_TYPE_VIDEO, 96)
+			packetizer.maxSize = RTP_FIXED_HEADER_LENGTH + 6
+			sequence := packetizer.sequence
+
+			packs := packetizer.PackH264(test.nalus, 3600)
+			assert.Equal(t, len(test.payload), len(packs))
+			for i, pack := range packs {
+				info := ParseRTP(pack.Buffer.Bytes())
+				assert.Equal(t, test.payload[i], info.Payload)
+				assert.Equal(t, test.marker[i], info.Marker)
+				assert.Equal(t, 96, info.PayloadType)
+				assert.Equal(t, sequence+uint16(i), info.SequenceNumber)
+				assert.Equal(t, uint32(3600), info.Timestamp)
+				assert.Equal(t, RTP_TYPE_VIDEO, pack.Type)
+			}
+		})
+	}
+}
+
+func TestRTPPacketizerPackH265(t *testing.T) {
+	tests := []struct {
+		name    string
+		nalus   [][]byte
+		payload [][]byte
+		marker  []bool
+	}{
+		{
+			name:    "single NAL units",
+			nalus:   [][]byte{{0x40, 0x01, 0x0c}, {0x42, 0x01}},
+			payload: [][]byte{{0x40, 0x01, 0x0c}, {0x42, 0x01}},
+			marker:  []bool{false, true},
+		},
+		{
+			name:  "FU",
+			nalus: [][]byte{{0x26, 0x01, 1, 2, 3, 4, 5, 6, 7}},
+			payload: [][]byte{
+				{0x62, 0x01, 0x93, 1, 2, 3},
+				{0x62, 0x01, 0x13, 4, 5, 6},
+				{0x62, 0x01, 0x53, 7},
+			},
+			marker: []bool{false, false, true},
+		},
+		{
+			name:  "FU keeps layer and temporal ID",
+			nalus: [][]byte{{0x03, 0x02, 1, 2, 3, 4, 5}},
+			payload: [][]byte{
+				{0x63, 0x02, 0x81, 1, 2, 3},
+				{0x63, 0x02, 0x41, 4, 5},
+			},
+			marker: []bool{false, true},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			packetizer := NewRTPPacketizer(RTP_TYPE_VIDEO, 96)
+			packetizer.maxSize = RTP_FIXED_HEADER_LENGTH + 6
+			sequence := packetizer.sequence
+
+			packs := packetizer.PackH265(test.nalus, 3600)
+			assert.Equal(t, len(test.payload), len(packs))
+			for i, pack := range packs {
+				info := ParseRTP(pack.Buffer.Bytes())
+				assert.Equal(t, test.payload[i], info.Payload)
+				assert.Equal(t, test.marker[i], info.Marker)
+				assert.Equal(t, sequence+uint16(i), info.SequenceNumber)
+			}
+		})
+	}
+}
+
+func TestRTPPacketizerPackPS(t *testing.T) {
+	packetizer := NewRTPPacketizer(RTP_TYPE_VIDEO, 96)
+	packetizer.maxSize = RTP_FIXED_HEADER_LENGTH + 4
+	data := []byte{0x00, 0x00, 0x01, 0xba, 1, 2, 3, 4, 5, 6}
+
+	packs := packetizer.PackPS(data, 3600)
+	payload := make([]byte, 0)
+	for i, pack := range packs {
+		info := ParseRTP(pack.Buffer.Bytes())
+		assert.Equal(t, i == len(packs)-1, info.Marker)
+		payload = append(payload, info.Payload...)
+	}
+	assert.Equal(t, 3, len(packs))
+	assert.Equal(t, data, payload)
+}
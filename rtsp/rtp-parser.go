@@ -15,6 +15,8 @@ type RTPPack struct {
 	Type    RTPType
 	Buffer  *bytes.Buffer
 	Channel int // Mostly audio channel index, can be video channel index
+	// keyFrame of video marked by pusher, where GOP starts
+	keyFrame bool
 }
 
 type RTPInfo struct {
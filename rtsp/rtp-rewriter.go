@@ -0,0 +1,123 @@
+package rtsp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sync"
+	"time"
+)
+
+// rtpRewriteTrack of player, SSRC of the first source is kept and
+// sequence and timestamp of later sources are shifted by offsets
+type rtpRewriteTrack struct {
+	ssrc      uint32
+	srcSSRC   uint32
+	seqOffset uint16
+	tsOffset  uint32
+	// last RTP sent, rewritten
+	lastSeq uint16
+	lastTS  uint32
+	lastAt  time.Time
+}
+
+// rtpRewriter of player, RTP of pushers replacing each other looks like one stream
+type rtpRewriter struct {
+	clockRates [rtcpTrackNum]uint32
+	tracks     [rtcpTrackNum]*rtpRewriteTrack
+	// source switched, tracks are rebased on the next packet
+	switched     [rtcpTrackNum]bool
+	waitKeyFrame bool
+	lock         sync.Mutex
+}
+
+func newRTPRewriter(sdpRaw string) *rtpRewriter {
+	return &rtpRewriter{
+		clockRates: rtcpClockRates(sdpRaw),
+	}
+}
+
+// switchSource to a new pusher, video is dropped until the next key frame
+func (rewriter *rtpRewriter) switchSource() {
+	rewriter.lock.Lock()
+	defer rewriter.lock.Unlock()
+
+	for track := range rewriter.switched {
+		rewriter.switched[track] = rewriter.tracks[track] != nil
+	}
+	rewriter.waitKeyFrame = rewriter.tracks[rtcpTrackVideo] != nil
+}
+
+// rewrite RTP of pusher, nil if it should be dropped.
+// pack is shared by players, so it is copied if any field changes
+func (rewriter *rtpRewriter) rewrite(pack *RTPPack, now time.Time) *RTPPack {
+	if pack.Type != RTP_TYPE_VIDEO && pack.Type != RTP_TYPE_AUDIO {
+		return pack
+	}
+	track := rtcpTrack(pack.Type, pack.Channel)
+	data := pack.Buffer.Bytes()
+	if track < 0 || len(data) < RTP_FIXED_HEADER_LENGTH {
+		return pack
+	}
+	seq := binary.BigEndian.Uint16(data[2:])
+	timestamp := binary.BigEndian.Uint32(data[4:])
+	ssrc := binary.BigEndian.Uint32(data[8:])
+
+	rewriter.lock.Lock()
+	defer rewriter.lock.Unlock()
+
+	t := rewriter.tracks[track]
+	if t == nil {
+		t = &rtpRewriteTrack{ssrc: ssrc, srcSSRC: ssrc}
+		rewriter.tracks[track] = t
+	} else if t.srcSSRC != ssrc || rewriter.switched[track] {
+		if track == rtcpTrackVideo && rewriter.waitKeyFrame {
+			if !pack.keyFrame {
+				return nil
+			}
+			rewriter.waitKeyFrame = false
+		}
+		// continue from the last one sent, with wall clock elapsed as timestamp
+		delta := uint32(uint64(now.Sub(t.lastAt)) * uint64(rewriter.clockRates[track]) / uint64(time.Second))
+		if delta == 0 {
+			delta = 1
+		}
+		t.seqOffset = t.lastSeq + 1 - seq
+		t.tsOffset = t.lastTS + delta - timestamp
+		t.srcSSRC = ssrc
+		rewriter.switched[track] = false
+		log.Debugf("rtp of track %s rebased, seq offset %d, timestamp offset %d", rtcpTrackName(track), t.seqOffset, t.tsOffset)
+	}
+	t.lastSeq = seq + t.seqOffset
+	t.lastTS = timestamp + t.tsOffset
+	t.lastAt = now
+	if t.ssrc == ssrc && t.seqOffset == 0 && t.tsOffset == 0 {
+		return pack
+	}
+
+	buffer := make([]byte, len(data))
+	copy(buffer, data)
+	binary.BigEndian.PutUint16(buffer[2:], t.lastSeq)
+	binary.BigEndian.PutUint32(buffer[4:], t.lastTS)
+	binary.BigEndian.PutUint32(buffer[8:], t.ssrc)
+	return &RTPPack{
+		Type:     pack.Type,
+		Buffer:   bytes.NewBuffer(buffer),
+		Channel:  pack.Channel,
+		keyFrame: pack.keyFrame,
+	}
+}
+
+// source SSRC and timestamp offset of track with rewritten SSRC
+func (rewriter *rtpRewriter) source(track int, ssrc uint32) (srcSSRC uint32, tsOffset uint32, ok bool) {
+	rewriter.lock.Lock()
+	defer rewriter.lock.Unlock()
+
+	t := rewriter.tracks[track]
+	if t == nil {
+		return ssrc, 0, true
+	}
+	if t.ssrc != ssrc {
+		return 0, 0, false
+	}
+	return t.srcSSRC, t.tsOffset, true
+}
@@ -0,0 +1,112 @@
+package rtsp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func rewriterRTP(ssrc uint32, seq uint16, timestamp uint32, keyFrame bool) *RTPPack {
+	data := make([]byte, RTP_FIXED_HEADER_LENGTH+4)
+	data[0] = 0x80
+	binary.BigEndian.PutUint16(data[2:], seq)
+	binary.BigEndian.PutUint32(data[4:], timestamp)
+	binary.BigEndian.PutUint32(data[8:], ssrc)
+	return &RTPPack{Type: RTP_TYPE_VIDEO, Buffer: bytes.NewBuffer(data), keyFrame: keyFrame}
+}
+
+func TestRTPRewriterRewrite(t *testing.T) {
+	type in struct {
+		// switchSource before the packet
+		switched  bool
+		ssrc      uint32
+		seq       uint16
+		timestamp uint32
+		keyFrame  bool
+		at        time.Duration
+	}
+	type out struct {
+		dropped   bool
+		seq       uint16
+		timestamp uint32
+	}
+	tests := []struct {
+		name    string
+		packets []in
+		outs    []out
+	}{
+		{
+			name:    "first source as it is",
+			packets: []in{{ssrc: 1, seq: 100, timestamp: 1000, keyFrame: true}, {ssrc: 1, seq: 101, timestamp: 4600, at: 40 * time.Millisecond}},
+			outs:    []out{{seq: 100, timestamp: 1000}, {seq: 101, timestamp: 4600}},
+		},
+		{
+			name: "switched source continues with wall clock",
+			packets: []in{
+				{ssrc: 1, seq: 100, timestamp: 1000, keyFrame: true},
+				{switched: true, ssrc: 2, seq: 5000, timestamp: 777, keyFrame: true, at: 40 * time.Millisecond},
+				{ssrc: 2, seq: 5001, timestamp: 4377, at: 80 * time.Millisecond},
+			},
+			outs: []out{{seq: 100, timestamp: 1000}, {seq: 101, timestamp: 4600}, {seq: 102, timestamp: 8200}},
+		},
+		{
+			name: "switched source waits key frame",
+			packets: []in{
+				{ssrc: 1, seq: 100, timestamp: 1000, keyFrame: true},
+				{switched: true, ssrc: 2, seq: 5000, timestamp: 777, at: 40 * time.Millisecond},
+				{ssrc: 2, seq: 5001, timestamp: 4377, keyFrame: true, at: 80 * time.Millisecond},
+			},
+			outs: []out{{seq: 100, timestamp: 1000}, {dropped: true}, {seq: 101, timestamp: 8200}},
+		},
+		{
+			name: "new SSRC without switch rebased at once",
+			packets: []in{
+				{ssrc: 1, seq: 100, timestamp: 1000, keyFrame: true},
+				{ssrc: 2, seq: 5000, timestamp: 777, at: 40 * time.Millisecond},
+			},
+			outs: []out{{seq: 100, timestamp: 1000}, {seq: 101, timestamp: 4600}},
+		},
+		{
+			name: "rewritten sequence and timestamp wrap around",
+			packets: []in{
+				{ssrc: 1, seq: 65535, timestamp: 0xffffff00, keyFrame: true},
+				{switched: true, ssrc: 2, seq: 10, timestamp: 50, keyFrame: true, at: 40 * time.Millisecond},
+				{ssrc: 2, seq: 11, timestamp: 3650, at: 80 * time.Millisecond},
+			},
+			outs: []out{{seq: 65535, timestamp: 0xffffff00}, {seq: 0, timestamp: 3344}, {seq: 1, timestamp: 6944}},
+		},
+		{
+			name: "source sequence and timestamp wrap around",
+			packets: []in{
+				{ssrc: 1, seq: 100, timestamp: 1000, keyFrame: true},
+				{switched: true, ssrc: 2, seq: 65535, timestamp: 0xffffffff, keyFrame: true, at: 40 * time.Millisecond},
+				{ssrc: 2, seq: 0, timestamp: 3599, at: 80 * time.Millisecond},
+			},
+			outs: []out{{seq: 100, timestamp: 1000}, {seq: 101, timestamp: 4600}, {seq: 102, timestamp: 8200}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rewriter := newRTPRewriter("")
+			start := time.Now()
+			for i, packet := range test.packets {
+				if packet.switched {
+					rewriter.switchSource()
+				}
+				pack := rewriter.rewrite(rewriterRTP(packet.ssrc, packet.seq, packet.timestamp, packet.keyFrame), start.Add(packet.at))
+				if test.outs[i].dropped {
+					assert.Nil(t, pack)
+					continue
+				}
+				info := ParseRTP(pack.Buffer.Bytes())
+				assert.Equal(t, test.outs[i].seq, info.SequenceNumber)
+				assert.Equal(t, test.outs[i].timestamp, info.Timestamp)
+				assert.Equal(t, uint32(1), info.SSRC)
+				assert.Equal(t, packet.keyFrame, pack.keyFrame)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package rtsp
 import (
 	"bufio"
 	"bytes"
+	"crypto/tls"
 	"encoding/binary"
 	"fmt"
 	"io"
@@ -10,6 +11,7 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/pixelbender/go-sdp/sdp"
@@ -27,6 +29,7 @@ type RTSPClient struct {
 	Session              string
 	Seq                  int
 	connRW               *bufio.ReadWriter
+	connWLock            sync.Mutex
 	InBytes              uint
 	OutBytes             uint
 	TransType            TransType
@@ -44,6 +47,10 @@ type RTSPClient struct {
 
 	Agent    string
 	authLine string
+	// heartbeat with GET_PARAMETER if supported by server, or OPTIONS
+	getParameterSupported bool
+	// timeout of session in seconds advertised by server
+	sessionTimeout int
 
 	//tcp channels
 	aRTPChannel        []int
@@ -188,7 +195,8 @@ func (client *RTSPClient) requestStream(timeout time.Duration) (err error) {
 	if err != nil {
 		return err
 	}
-	if strings.ToLower(l.Scheme) != "rtsp" {
+	scheme := strings.ToLower(l.Scheme)
+	if scheme != "rtsp" && scheme != "rtsps" {
 		err = fmt.Errorf("RTSP url is invalid")
 		return err
 	}
@@ -198,9 +206,17 @@ func (client *RTSPClient) requestStream(timeout time.Duration) (err error) {
 	}
 	port := l.Port()
 	if len(port) == 0 {
-		port = "554"
+		port = defaultPort(scheme)
+	}
+	var conn net.Conn
+	if scheme == "rtsps" {
+		conn, err = tls.DialWithDialer(&net.Dialer{Timeout: timeout}, "tcp", l.Hostname()+":"+port, &tls.Config{
+			ServerName:         l.Hostname(),
+			InsecureSkipVerify: config.RTSP.TLSSkipVerify != 0,
+		})
+	} else {
+		conn, err = net.DialTimeout("tcp", l.Hostname()+":"+port, timeout)
 	}
-	conn, err := net.DialTimeout("tcp", l.Hostname()+":"+port, timeout)
 	if err != nil {
 		// handle error
 		return err
@@ -236,6 +252,10 @@ func (client *RTSPClient) requestStream(timeout time.Duration) (err error) {
 			return err
 		}
 	}
+	if resp != nil {
+		public, _ := resp.Header["Public"].(string)
+		client.getParameterSupported = strings.Contains(strings.ToUpper(public), "GET_PARAMETER")
+	}
 
 	// A DESCRIBE request includes an RTSP URL (rtsp://...), and the type of reply data that can be handled. This reply includes the presentation description,
 	// typically in Session Description Protocol (SDP) format. Among other things, the presentation description lists the media streams controlled with the aggregate URL.
@@ -272,7 +292,7 @@ func (client *RTSPClient) requestStream(timeout time.Duration) (err error) {
 			client.VControl = media.Attributes.Get("control")
 			client.VCodec = media.Formats[0].Name
 			var _url = ""
-			if strings.Index(strings.ToLower(client.VControl), "rtsp://") == 0 {
+			if isAbsoluteURL(client.VControl) {
 				_url = client.VControl
 			} else {
 				_url = strings.TrimRight(client.URL, "/") + "/" + strings.TrimLeft(client.VControl, "/")
@@ -302,7 +322,7 @@ func (client *RTSPClient) requestStream(timeout time.Duration) (err error) {
 			if err != nil {
 				return err
 			}
-			session, _ = resp.Header["Session"].(string)
+			session, client.sessionTimeout = parseSession(resp)
 		case "audio":
 			if client.aChannelNum >= 2 {
 				log.Error("Session[%s] more than 2 channel, please look into it", session)
@@ -314,7 +334,7 @@ func (client *RTSPClient) requestStream(timeout time.Duration) (err error) {
 			AControl := client.AControl[client.aChannelNum]
 			// ACodec := client.ACodec[client.aChannelNum]
 			var _url = ""
-			if strings.Index(strings.ToLower(AControl), "rtsp://") == 0 {
+			if isAbsoluteURL(AControl) {
 				_url = AControl
 			} else {
 				_url = strings.TrimRight(client.URL, "/") + "/" + strings.TrimLeft(AControl, "/")
@@ -346,7 +366,7 @@ func (client *RTSPClient) requestStream(timeout time.Duration) (err error) {
 			if err != nil {
 				return err
 			}
-			session, _ = resp.Header["Session"].(string)
+			session, client.sessionTimeout = parseSession(resp)
 			// Setup success
 			client.aChannelNum++
 		}
@@ -359,22 +379,50 @@ func (client *RTSPClient) requestStream(timeout time.Duration) (err error) {
 	if err != nil {
 		return err
 	}
+	client.Session = session
 	return nil
 }
 
+// parseSession ID and timeout in seconds of Session header, timeout is 0 if absent
+func parseSession(resp *Response) (string, int) {
+	value, _ := resp.Header["Session"].(string)
+	params := strings.Split(value, ";")
+	timeout := 0
+	for _, param := range params[1:] {
+		param = strings.TrimSpace(param)
+		if strings.HasPrefix(strings.ToLower(param), "timeout=") {
+			timeout, _ = strconv.Atoi(param[len("timeout="):])
+		}
+	}
+	return strings.TrimSpace(params[0]), timeout
+}
+
+// heartbeat with GET_PARAMETER or OPTIONS, response is read in stream loop
+func (client *RTSPClient) heartbeat() error {
+	headers := make(map[string]string)
+	if client.getParameterSupported {
+		return client.RequestNoResp("GET_PARAMETER", headers)
+	}
+	headers["Require"] = "implicit-play"
+	// An OPTIONS request returns the request types the server will accept.
+	return client.RequestNoResp("OPTIONS", headers)
+}
+
 func (client *RTSPClient) startStream() {
 	defer client.Stop()
 	
 	startTime := time.Now()
 	loggerTime := time.Now().Add(-10 * time.Second)
+	interval := time.Duration(client.OptionIntervalMillis) * time.Millisecond
+	if interval <= 0 && client.sessionTimeout > 0 {
+		// keep session alive within timeout advertised by server
+		interval = time.Duration(client.sessionTimeout) * time.Second / 2
+	}
 	for !client.Stoped {
-		if client.OptionIntervalMillis > 0 {
-			if time.Since(startTime) > time.Duration(client.OptionIntervalMillis)*time.Millisecond {
+		if interval > 0 {
+			if time.Since(startTime) > interval {
 				startTime = time.Now()
-				headers := make(map[string]string)
-				headers["Require"] = "implicit-play"
-				// An OPTIONS request returns the request types the server will accept.
-				if err := client.RequestNoResp("OPTIONS", headers); err != nil {
+				if err := client.heartbeat(); err != nil {
 					// ignore...
 				}
 			}
@@ -554,6 +602,27 @@ func (client *RTSPClient) Stop() {
 	}
 }
 
+// SendRTCP to server interleaved in RTSP connection
+func (client *RTSPClient) SendRTCP(pack *RTPPack) error {
+	channel := client.vRTPControlChannel
+	if pack.Type == RTP_TYPE_AUDIOCONTROL {
+		channel = client.aRTPControlChannel[pack.Channel]
+	}
+	header := []byte{0x24, byte(channel), 0, 0}
+	binary.BigEndian.PutUint16(header[2:], uint16(pack.Buffer.Len()))
+
+	client.connWLock.Lock()
+	defer client.connWLock.Unlock()
+	if _, err := client.connRW.Write(header); err != nil {
+		return err
+	}
+	if _, err := client.connRW.Write(pack.Buffer.Bytes()); err != nil {
+		return err
+	}
+	client.OutBytes += uint(pack.Buffer.Len()) + 4
+	return client.connRW.Flush()
+}
+
 func (client *RTSPClient) RequestWithPath(method string, path string, headers map[string]string, needResp bool) (resp *Response, err error) {
 	headers["User-Agent"] = client.Agent
 	if len(headers["Authorization"]) == 0 {
@@ -578,11 +647,15 @@ func (client *RTSPClient) RequestWithPath(method string, path string, headers ma
 	builder.WriteString(fmt.Sprintf("\r\n"))
 	s := builder.String()
 	log.Debugf("[OUT]>>>\n%s", s)
+	client.connWLock.Lock()
 	_, err = client.connRW.WriteString(s)
+	if err == nil {
+		err = client.connRW.Flush()
+	}
+	client.connWLock.Unlock()
 	if err != nil {
 		return
 	}
-	client.connRW.Flush()
 
 	if !needResp {
 		return nil, nil
@@ -3,8 +3,12 @@ package rtsp
 import (
 	"fmt"
 	"net"
+	"os"
+	"os/signal"
 	"sync"
+	"syscall"
 
+	"github.com/EasyDarwin/EasyDarwin/tls"
 	"github.com/benbjohnson/immutable"
 )
 
@@ -13,20 +17,35 @@ type OnGetPusherHandle func(_ *Server, _ *Session, path string, _ Pusher) Pusher
 
 // Server of RTSP
 type Server struct {
-	Listener       net.Listener
-	TCPListener    *net.TCPListener
-	TCPPort        int
-	Stoped         bool
-	players        map[string]Player
-	playersLock    sync.RWMutex
-	addPusherCh    chan Pusher
-	removePusherCh chan Pusher
+	Listener    net.Listener
+	TCPListener *net.TCPListener
+	TCPPort     int
+	// RTSPS served with the same pushers, disabled if TLSPort is 0
+	TLSListener   net.Listener
+	TLSPort       int
+	certificate   *tls.Certificate
+	reloadChannel chan os.Signal
+	streamSecret  string
+	Stoped        bool
+	players       map[string]Player
+	playersLock   sync.RWMutex
 	// Hooks
 	onGetPusherHandles []OnGetPusherHandle
 	// Pushers
 	pushers              *immutable.Map
 	getPushers           chan *immutable.Map
 	pusherCommandChannel chan func()
+	// RTP of all PS pushers on a single port, nil if disabled
+	rtpMux *RTPMux
+	// RTSP over HTTP GET waiting for POST, key is x-sessioncookie
+	tunnels     map[string]*tunnelConn
+	tunnelsLock sync.Mutex
+	// multicast sender of pushers, key is pusher ID
+	multicastSenders map[string]*MulticastSender
+	multicastLock    sync.Mutex
+	// players kept of stoped pushers, key is path
+	keptPlayers     map[string]*keptPlayers
+	keptPlayersLock sync.Mutex
 }
 
 // Instance of RTSP server
@@ -34,10 +53,12 @@ var Instance *Server
 
 func initServer() error {
 	Instance = &Server{
-		Stoped:         true,
-		TCPPort:        config.RTSP.Port,
-		addPusherCh:    make(chan Pusher),
-		removePusherCh: make(chan Pusher),
+		Stoped:           true,
+		TCPPort:          config.RTSP.Port,
+		TLSPort:          config.RTSP.TLSPort,
+		tunnels:          make(map[string]*tunnelConn),
+		multicastSenders: make(map[string]*MulticastSender),
+		keptPlayers:      make(map[string]*keptPlayers),
 		// pushers will init when start to make sure a clean start
 	}
 
@@ -49,19 +70,9 @@ func GetServer() *Server {
 	return Instance
 }
 
-func (server *Server) pusherHooks() {
-	for {
-		select {
-		case _, ok := <-server.addPusherCh:
-			if !ok {
-				return
-			}
-		case _, ok := <-server.removePusherCh:
-			if !ok {
-				return
-			}
-		}
-	}
+// RTPMux of server, nil if mux port is disabled
+func (server *Server) RTPMux() *RTPMux {
+	return server.rtpMux
 }
 
 func (server *Server) pusherLoop() {
@@ -106,7 +117,9 @@ func (server *Server) finishPushers() {
 	server.pusherCommandChannel <- server._finishPushers
 }
 
-func (server *Server) Start() (err error) {
+// Start RTSP server, and RTSPS with cert and key files if TLS port is configured.
+// streamSecret is the key of signed stream URL
+func (server *Server) Start(certFile string, keyFile string, streamSecret string) (err error) {
 	addr, err := net.ResolveTCPAddr("tcp", fmt.Sprintf(":%d", server.TCPPort))
 	if err != nil {
 		return
@@ -115,16 +128,67 @@ func (server *Server) Start() (err error) {
 	if err != nil {
 		return
 	}
+	server.streamSecret = streamSecret
 
-	go server.pusherHooks()
 	server.initPushers()
 
+	if config.RTP.MuxPort > 0 {
+		if mux, err := NewRTPMux(config.RTP.MuxPort); nil != err {
+			log.WithError(err).Errorf("RTP mux listen on[%d]", config.RTP.MuxPort)
+		} else {
+			server.rtpMux = mux
+			go mux.Start()
+		}
+	}
+
 	server.Stoped = false
 	server.Listener = listener
+	if server.TLSPort > 0 {
+		if err := server.startTLS(certFile, keyFile); err != nil {
+			log.WithError(err).Errorf("RTSPS server listen on[%d]", server.TLSPort)
+		}
+	}
 	log.Infof("RTSP server start on[%d]", server.TCPPort)
+	server.serve(listener)
+	return
+}
+
+// startTLS listener, certificate is reloaded on SIGHUP
+func (server *Server) startTLS(certFile string, keyFile string) (err error) {
+	if certFile == "" || keyFile == "" {
+		return fmt.Errorf("RTSPS without cert or key")
+	}
+	server.certificate, err = tls.NewCertificate(certFile, keyFile)
+	if err != nil {
+		return
+	}
+	server.TLSListener, err = tls.NewListener(server.certificate, &net.TCPAddr{Port: server.TLSPort})
+	if err != nil {
+		return
+	}
+
+	server.reloadChannel = make(chan os.Signal, 1)
+	signal.Notify(server.reloadChannel, syscall.SIGHUP)
+	go func(certificate *tls.Certificate, reload chan os.Signal) {
+		for range reload {
+			if err := certificate.Reload(); err != nil {
+				log.WithError(err).Error("RTSPS reload certificate")
+				continue
+			}
+			log.Info("RTSPS certificate reloaded")
+		}
+	}(server.certificate, server.reloadChannel)
+
+	log.Infof("RTSPS server start on[%d]", server.TLSPort)
+	go server.serve(server.TLSListener)
+	return
+}
+
+// serve sessions accepted by listener until server stops
+func (server *Server) serve(listener net.Listener) {
 	networkBuffer := config.RTSP.NetworkBuffer
 	for !server.Stoped {
-		conn, err := server.Listener.Accept()
+		conn, err := listener.Accept()
 		if err != nil {
 			if !server.Stoped {
 				log.Errorf("RTSP server listen fail:[%v]", err)
@@ -143,7 +207,6 @@ func (server *Server) Start() (err error) {
 		session := NewSession(server, conn)
 		go session.Start()
 	}
-	return
 }
 
 // Stop RTSP server
@@ -154,10 +217,17 @@ func (server *Server) Stop() {
 		server.Listener.Close()
 		server.Listener = nil
 	}
+	if server.TLSListener != nil {
+		signal.Stop(server.reloadChannel)
+		close(server.reloadChannel)
+		server.TLSListener.Close()
+		server.TLSListener = nil
+	}
+	if server.rtpMux != nil {
+		server.rtpMux.Stop()
+		server.rtpMux = nil
+	}
 	server.finishPushers()
-
-	close(server.addPusherCh)
-	close(server.removePusherCh)
 }
 
 type serverAddPusherCommand struct {
@@ -184,26 +254,17 @@ func (c *serverAddPusherCommand) Do() {
 	}
 
 	if added {
+		c.server.adoptPlayers(c.pusher)
+		// registered before start, never missed by a pusher stopping at once
+		pusher, server := c.pusher, c.server
+		pusher.AddOnStopHandle(func() {
+			server.removeMulticastSender(pusher)
+			notifyHook(HookOnDone, pusherHookValues(pusher))
+		})
 		go c.pusher.Start()
 	}
 
 	c.result <- added
-
-func (server *Server) TryAttachToPusher(session *Session) (int, *Pusher) {
-	server.pushersLock.Lock()
-	attached := 0
-	var pusher *Pusher = nil
-	if _pusher, ok := server.pushers[session.Path]; ok {
-		if _pusher.RebindSession(session) {
-			session.logger.Printf("Attached to a pusher")
-			attached = 1
-			pusher = _pusher
-		} else {
-			attached = -1
-		}
-	}
-	server.pushersLock.Unlock()
-	return attached, pusher
 }
 
 // AddPusher to Server
@@ -18,6 +18,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/pixelbender/go-sdp/sdp"
@@ -71,6 +72,7 @@ const (
 	TRANS_TYPE_TCP TransType = iota
 	TRANS_TYPE_UDP
 	TRANS_TYPE_INTERNAL
+	TRANS_TYPE_MULTICAST
 )
 
 func (tt TransType) String() string {
@@ -81,6 +83,8 @@ func (tt TransType) String() string {
 		return "UDP"
 	case TRANS_TYPE_INTERNAL:
 		return "Internal"
+	case TRANS_TYPE_MULTICAST:
+		return "MULTICAST"
 	}
 	return "unknow"
 }
@@ -116,6 +120,8 @@ type Session struct {
 	OutBytes uint
 	StartAt  time.Time
 	Timeout  int
+	// activeAt in unix nanoseconds of last request or RTP/RTCP received
+	activeAt int64
 
 	Stoped     bool
 	stopedLock sync.RWMutex
@@ -129,12 +135,17 @@ type Session struct {
 	// audio double channel
 	aChannelNum int
 
+	// Pusher may be switched when it is replaced, use getPusher and setPusher
 	Pusher      Pusher
+	pusherLock  sync.RWMutex
 	Player      Player
 	UDPClient   *UDPClient
 	UDPServer   *UDPServer
+	multicast   *MulticastSender
 	RTPHandles  []func(*RTPPack)
-	StopHandles []func()
+	// StopHandles may be added while session expires, use addStopHandle
+	StopHandles     []func()
+	stopHandlesLock sync.Mutex
 }
 
 func (session *Session) String() string {
@@ -182,13 +193,20 @@ func NewSession(server *Server, conn net.Conn) *Session {
 
 // Stop the RTSP session
 func (session *Session) Stop() {
-	if session.getStoped() {
-	// TODO: more strong safe guard
+	// session may expire while it is stoped by request goroutine
+	session.stopedLock.Lock()
+	if session.Stoped {
+		session.stopedLock.Unlock()
 		return
 	}
-	session.setStoped(true)
+	session.Stoped = true
+	session.stopedLock.Unlock()
 
-	for _, h := range session.StopHandles {
+	session.stopHandlesLock.Lock()
+	handles := session.StopHandles
+	session.StopHandles = nil
+	session.stopHandlesLock.Unlock()
+	for _, h := range handles {
 		h()
 	}
 	if session.Conn != nil {
@@ -206,14 +224,53 @@ func (session *Session) Stop() {
 	}
 }
 
+// touch session as active, keep it from expiring
+func (session *Session) touch() {
+	atomic.StoreInt64(&session.activeAt, time.Now().UnixNano())
+}
+
+// active if session_timeout is checked and session is touched in it
+func (session *Session) active() bool {
+	timeout := time.Duration(config.RTSP.SessionTimeout) * time.Second
+	activeAt := time.Unix(0, atomic.LoadInt64(&session.activeAt))
+	return timeout > 0 && time.Since(activeAt) <= timeout
+}
+
+// expireLoop stops session without request or RTP/RTCP received in session_timeout
+func (session *Session) expireLoop() {
+	timeout := time.Duration(config.RTSP.SessionTimeout) * time.Second
+	if timeout <= 0 {
+		return
+	}
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for range ticker.C {
+		if session.getStoped() {
+			return
+		}
+		activeAt := time.Unix(0, atomic.LoadInt64(&session.activeAt))
+		if time.Since(activeAt) > timeout {
+			log.Warnf("[%s] expired, inactive since %v", session, activeAt)
+			session.Stop()
+			return
+		}
+	}
+}
+
 func (session *Session) Start() {
 	defer session.Stop()
+	session.touch()
+	go session.expireLoop()
 
 	buf1 := make([]byte, 1)
 	buf2 := make([]byte, 2)
 	timer := time.Unix(0, 0)
 	for !session.getStoped() {
 		if _, err := io.ReadFull(session.connRW, buf1); err != nil {
+			// RTSP connection is idle while RTCP over UDP keeps session alive, expireLoop decides
+			if ne, ok := err.(net.Error); ok && ne.Timeout() && session.active() {
+				continue
+			}
 			log.Errorf("%s:%v", session, err)
 			return
 		}
@@ -294,6 +351,7 @@ func (session *Session) Start() {
 				continue
 			}
 			session.InBytes += uint(rtpLen) + 4
+			session.touch()
 			for _, h := range session.RTPHandles {
 				h(pack)
 			}
@@ -338,53 +396,6 @@ func (session *Session) Start() {
 	}
 }
 
-func CheckAuth(authLine string, method string, sessionNonce string) error {
-	realmRex := regexp.MustCompile(`realm="(.*?)"`)
-	nonceRex := regexp.MustCompile(`nonce="(.*?)"`)
-	usernameRex := regexp.MustCompile(`username="(.*?)"`)
-	responseRex := regexp.MustCompile(`response="(.*?)"`)
-	uriRex := regexp.MustCompile(`uri="(.*?)"`)
-
-	realm := ""
-	nonce := ""
-	username := ""
-	response := ""
-	uri := ""
-	result1 := realmRex.FindStringSubmatch(authLine)
-	if len(result1) == 2 {
-		realm = result1[1]
-	} else {
-		return fmt.Errorf("CheckAuth error : no realm found")
-	}
-	result1 = nonceRex.FindStringSubmatch(authLine)
-	if len(result1) == 2 {
-		nonce = result1[1]
-	} else {
-		return fmt.Errorf("CheckAuth error : no nonce found")
-	}
-	if sessionNonce != nonce {
-		return fmt.Errorf("CheckAuth error : sessionNonce not same as nonce")
-	}
-	result1 = uriRex.FindStringSubmatch(authLine)
-	if len(result1) == 2 {
-		uri = result1[1]
-	} else {
-		return fmt.Errorf("CheckAuth error : uri not found")
-	}
-	var user models.User
-	err := db.SQLite.Where("Username = ?", username).First(&user).Error
-	if err != nil {
-		return fmt.Errorf("CheckAuth error : user not exists")
-	}
-	md5UserRealmPwd := fmt.Sprintf("%x", md5.Sum([]byte(fmt.Sprintf("%s:%s:%s", username, realm, user.Password))))
-	md5MethodURL := fmt.Sprintf("%x", md5.Sum([]byte(fmt.Sprintf("%s:%s", method, uri))))
-	myResponse := fmt.Sprintf("%x", md5.Sum([]byte(fmt.Sprintf("%s:%s:%s", md5UserRealmPwd, nonce, md5MethodURL))))
-	if myResponse != response {
-		return fmt.Errorf("CheckAuth error : response not equal")
-	}
-	return nil
-}
-
 func validate(key []byte, salt []byte, request string) string {
 	mac := hmac.New(sha512.New, key)
 	mac.Write(salt)
@@ -432,6 +443,7 @@ func (session *Session) handleRequest(req *Request) {
 	//	session.Conn.SetDeadline(time.Now().Add(time.Duration(session.Timeout) * time.Second))
 	//}
 	log.Debugf("<<<\n%s", req)
+	session.touch()
 	res := NewResponse(200, "OK", req.Header["CSeq"], session.ID, "")
 	defer func() {
 		if p := recover(); p != nil {
@@ -451,10 +463,16 @@ func (session *Session) handleRequest(req *Request) {
 		case "PLAY", "RECORD":
 			switch session.Type {
 			case SESSEION_TYPE_PLAYER:
-				if session.Pusher.HasPlayer(session.Player) {
+				if session.TransType == TRANS_TYPE_MULTICAST {
+					// sent by multicast sender of pusher instead of player
+					if session.multicast != nil && session.multicast.join(session.ID) {
+						session.hookPlayDone()
+					}
+				} else if pusher := session.getPusher(); pusher.GetPlayer(session.Player.ID()) != nil {
+					// resume after PAUSE
 					session.Player.Pause(false)
-				} else {
-					session.Pusher.AddPlayer(session.Player)
+				} else if err := pusher.AddPlayer(session.Player); err == nil {
+					session.hookPlayDone()
 				}
 				// case SESSION_TYPE_PUSHER:
 				// 	session.Server.AddPusher(session.Pusher)
@@ -465,7 +483,8 @@ func (session *Session) handleRequest(req *Request) {
 				return
 			}
 		}
-		if res.StatusCode != 200 && res.StatusCode != 401 {
+		// parameter or method not supported is not fatal
+		if res.StatusCode != 200 && res.StatusCode != 401 && res.StatusCode != 451 && res.StatusCode != 501 {
 			log.Errorf("Response request error[%d]. stop session.", res.StatusCode)
 			session.Stop()
 		}
@@ -476,9 +495,15 @@ func (session *Session) handleRequest(req *Request) {
 			authLine := req.Header["Authorization"]
 			authFailed := true
 			if authLine != "" {
-				err := CheckAuth(authLine, req.Method, session.nonce)
+				user, err := CheckAuth(authLine, req.Method, req.URL, session.nonce)
 				if err == nil {
 					authFailed = false
+					if err := session.checkPermission(user, req); err != nil {
+						log.Errorf("[%s] %v", session, err)
+						res.StatusCode = 403
+						res.Status = "Forbidden"
+						return
+					}
 				} else {
 					log.Errorf("[%s] %v", session, err)
 				}
@@ -488,14 +513,14 @@ func (session *Session) handleRequest(req *Request) {
 				res.Status = "Unauthorized"
 				nonce := fmt.Sprintf("%x", md5.Sum([]byte(shortid.MustGenerate())))
 				session.nonce = nonce
-				res.Header["WWW-Authenticate"] = fmt.Sprintf(`Digest realm="EasyDarwin", nonce="%s", algorithm="MD5"`, nonce)
+				res.Header["WWW-Authenticate"] = fmt.Sprintf(`Digest realm="%s", nonce="%s", algorithm="MD5"`, config.RTSP.Realm, nonce)
 				return
 			}
 		}
 	}
 	switch req.Method {
 	case "OPTIONS":
-		res.Header["Public"] = "DESCRIBE, SETUP, TEARDOWN, PLAY, PAUSE, OPTIONS, ANNOUNCE, RECORD"
+		res.Header["Public"] = "DESCRIBE, SETUP, TEARDOWN, PLAY, PAUSE, OPTIONS, ANNOUNCE, RECORD, GET_PARAMETER, SET_PARAMETER"
 	case "ANNOUNCE":
 		session.Type = SESSION_TYPE_PUSHER
 		session.URL = req.URL
@@ -507,18 +532,25 @@ func (session *Session) handleRequest(req *Request) {
 			return
 		}
 
-		// This is to be consistent with API server.
-		code := session.authenticate(req)
+		code := session.authorize(req, url.Path, req.Method == "ANNOUNCE")
 		if code != 200 {
 			logger.Printf("auth status is not 200 %d", code)
 			res.Status = "Unauthorized"
+			if code == 403 {
+				res.Status = "Forbidden"
+			}
 			res.StatusCode = code
 			nonce := fmt.Sprintf("%x", md5.Sum([]byte(shortid.MustGenerate())))
 			session.nonce = nonce
-			res.Header["WWW-Authenticate"] = fmt.Sprintf(`Digest realm="EasyDarwin", nonce="%s", algorithm="MD5"`, nonce)
+			res.Header["WWW-Authenticate"] = fmt.Sprintf(`Digest realm="%s", nonce="%s", algorithm="MD5"`, config.RTSP.Realm, nonce)
 			return
 		}
 		session.Path = url.Path
+		if !callHook(HookOnPublish, session.hookValues()) {
+			res.StatusCode = 403
+			res.Status = "Forbidden"
+			return
+		}
 
 		session.SDPRaw = req.Body
 		session.Sdp, err = sdp.ParseString(req.Body)
@@ -541,11 +573,12 @@ func (session *Session) handleRequest(req *Request) {
 			}
 		}
 
-		session.Pusher = NewPusher(session)
+		pusher := NewPusher(session)
+		session.setPusher(pusher)
 
-		addedToServer := session.Server.AddPusher(session.Pusher, session.closeOld)
+		addedToServer := session.Server.AddPusher(pusher, session.closeOld)
 		if !addedToServer {
-			log.Infof("reject pusher[%s]", session.Pusher.ID())
+			log.Infof("reject pusher[%s]", pusher.ID())
 			res.StatusCode = 406
 			res.Status = "Not Acceptable"
 		}
@@ -559,19 +592,26 @@ func (session *Session) handleRequest(req *Request) {
 			res.Status = "Invalid URL"
 			return
 		}
-		// This is to be consistent with API server.
-		code := session.authenticate(req)
+		code := session.authorize(req, url.Path, req.Method == "ANNOUNCE")
 		if code != 200 {
 			logger.Printf("auth status is not 200 %d", code)
 			res.Status = "Unauthorized"
+			if code == 403 {
+				res.Status = "Forbidden"
+			}
 			res.StatusCode = code
 			nonce := fmt.Sprintf("%x", md5.Sum([]byte(shortid.MustGenerate())))
 			session.nonce = nonce
-			res.Header["WWW-Authenticate"] = fmt.Sprintf(`Digest realm="EasyDarwin", nonce="%s", algorithm="MD5"`, nonce)
+			res.Header["WWW-Authenticate"] = fmt.Sprintf(`Digest realm="%s", nonce="%s", algorithm="MD5"`, config.RTSP.Realm, nonce)
 			return
 		}
 
 		session.Path = url.Path
+		if !session.hookPlay() {
+			res.StatusCode = 403
+			res.Status = "Forbidden"
+			return
+		}
 		pusher := session.Server.GetPusher(session.Path, session)
 		if pusher == nil {
 			res.StatusCode = 404
@@ -579,13 +619,20 @@ func (session *Session) handleRequest(req *Request) {
 			return
 		}
 		session.Player = NewPlayer(session, pusher)
-		session.Pusher = pusher
+		session.setPusher(pusher)
 		session.AControl = pusher.AControl()
 		session.VControl = pusher.VControl()
 		session.ACodec = pusher.ACodec()
 		session.VCodec = pusher.VCodec()
 		session.Conn.timeout = 0
-		res.SetBody(session.Pusher.SDPRaw())
+		sdpRaw := pusher.SDPRaw()
+		if session.Server.MulticastEnabled() {
+			// c= of group for clients reading SDP only, unicast ones still SETUP with their Transport
+			if sender, err := session.Server.GetMulticastSender(pusher); err == nil {
+				sdpRaw = sender.SDP(sdpRaw)
+			}
+		}
+		res.SetBody(sdpRaw)
 	case "SETUP":
 		ts := req.Header["Transport"]
 		// control字段可能是`stream=1`字样，也可能是rtsp://...字样。即control可能是url的path，也可能是整个url
@@ -607,7 +654,8 @@ func (session *Session) handleRequest(req *Request) {
 		setupPath := setupUrl.String()
 
 		// error status. SETUP without ANNOUNCE or DESCRIBE.
-		if session.Pusher == nil {
+		pusher := session.getPusher()
+		if pusher == nil {
 			res.StatusCode = 500
 			res.Status = "Error Status"
 			return
@@ -675,6 +723,41 @@ func (session *Session) handleRequest(req *Request) {
 			}
 			log.Infof("Parse SETUP req.TRANSPORT:TCP.Session.Type:%d,control:%s, AControl:%v,VControl:%s",
 				session.Type, setupPath, aPathes, vPath)
+		} else if strings.Contains(strings.ToLower(ts), "multicast") && session.Type == SESSEION_TYPE_PLAYER {
+			sender, err := session.Server.GetMulticastSender(pusher)
+			if err != nil {
+				res.StatusCode = 461
+				res.Status = "Unsupported Transport"
+				return
+			}
+			session.TransType = TRANS_TYPE_MULTICAST
+			// no need for tcp timeout.
+			session.Conn.timeout = 0
+			port := -1
+			for i, aPath := range aPathes {
+				if setupPath == aPath || aPath != "" && strings.LastIndex(setupPath, aPath) == len(setupPath)-len(aPath) {
+					port = sender.AudioPort(i)
+					break
+				}
+			}
+			if port < 0 && (setupPath == vPath || vPath != "" && strings.LastIndex(setupPath, vPath) == len(setupPath)-len(vPath)) {
+				port = sender.VideoPort()
+			}
+			if port < 0 {
+				res.StatusCode = 500
+				res.Status = fmt.Sprintf("SETUP [MULTICAST] got UnKown control:%s", setupPath)
+				log.Errorf("SETUP [MULTICAST] got UnKown control:%s", setupPath)
+				return
+			}
+			if session.multicast == nil {
+				session.multicast = sender
+				session.addStopHandle(func() {
+					sender.leave(session.ID)
+				})
+			}
+			ts = fmt.Sprintf("RTP/AVP;multicast;destination=%s;port=%d-%d;ttl=%d", sender.Group(), port, port+1, multicastTTL)
+			log.Infof("Parse SETUP req.TRANSPORT:MULTICAST.Session.Type:%d,control:%s, AControl:%v,VControl:%s",
+				session.Type, setupPath, aPathes, vPath)
 		} else if udpMatchs := mudp.FindStringSubmatch(ts); udpMatchs != nil {
 			session.TransType = TRANS_TYPE_UDP
 			// no need for tcp timeout.
@@ -699,6 +782,7 @@ func (session *Session) handleRequest(req *Request) {
 							res.Status = fmt.Sprintf("udp client setup audio error, %v", err)
 							return
 						}
+						ts = fmt.Sprintf("%s;%s", ts, udpServerPorts(session.UDPClient.AConn[session.aChannelNum], session.UDPClient.AControlConn[session.aChannelNum]))
 						session.aChannelNum++
 					} else if session.Type == SESSION_TYPE_PUSHER {
 						if err := session.UDPServer.SetupAudio(session.aChannelNum); err != nil {
@@ -737,6 +821,7 @@ func (session *Session) handleRequest(req *Request) {
 						res.Status = fmt.Sprintf("udp client setup video error, %v", err)
 						return
 					}
+					ts = fmt.Sprintf("%s;%s", ts, udpServerPorts(session.UDPClient.VConn, session.UDPClient.VControlConn))
 				}
 
 				if session.Type == SESSION_TYPE_PUSHER {
@@ -762,17 +847,35 @@ func (session *Session) handleRequest(req *Request) {
 			}
 		}
 		res.Header["Transport"] = ts
+		if config.RTSP.SessionTimeout > 0 {
+			res.Header["Session"] = fmt.Sprintf("%s;timeout=%d", session.ID, config.RTSP.SessionTimeout)
+		}
 	case "PLAY":
 		// error status. PLAY without ANNOUNCE or DESCRIBE.
-		if session.Pusher == nil {
+		pusher := session.getPusher()
+		if pusher == nil {
 			res.StatusCode = 500
 			res.Status = "Error Status"
 			return
 		}
 		res.Header["Range"] = req.Header["Range"]
+		if control, ok := pusher.(ControllablePusher); ok && session.Player != nil {
+			playRange, err := control.ControlPlay(session.Player, req.Header["Range"], req.Header["Scale"])
+			if err != nil {
+				res.StatusCode = 500
+				res.Status = fmt.Sprintf("Play control error, %v", err)
+				return
+			}
+			if playRange != "" {
+				res.Header["Range"] = playRange
+			}
+			if scale := req.Header["Scale"]; scale != "" {
+				res.Header["Scale"] = scale
+			}
+		}
 	case "RECORD":
 		// error status. RECORD without ANNOUNCE or DESCRIBE.
-		if session.Pusher == nil {
+		if session.getPusher() == nil {
 			res.StatusCode = 500
 			res.Status = "Error Status"
 			return
@@ -783,7 +886,24 @@ func (session *Session) handleRequest(req *Request) {
 			res.Status = "Error Status"
 			return
 		}
+		if control, ok := session.getPusher().(ControllablePusher); ok {
+			if err := control.ControlPause(session.Player); err != nil {
+				res.StatusCode = 500
+				res.Status = fmt.Sprintf("Pause control error, %v", err)
+				return
+			}
+		}
 		session.Player.Pause(true)
+	case "GET_PARAMETER", "SET_PARAMETER":
+		// keepalive without body, no parameter supported yet
+		if strings.TrimSpace(req.Body) != "" {
+			res.StatusCode = 451
+			res.Status = "Parameter Not Understood"
+		}
+	case "TEARDOWN":
+	default:
+		res.StatusCode = 501
+		res.Status = "Not Implemented"
 	}
 }
 
@@ -792,6 +912,10 @@ func (session *Session) SendRTP(pack *RTPPack) (err error) {
 		err = fmt.Errorf("player send rtp got nil pack")
 		return
 	}
+	if session.TransType == TRANS_TYPE_MULTICAST {
+		// sent by multicast sender of pusher
+		return
+	}
 	if session.TransType == TRANS_TYPE_UDP {
 		if session.UDPClient == nil {
 			err = fmt.Errorf("player use udp transport but udp client not found")
@@ -859,6 +983,13 @@ func (session *Session) SendRTP(pack *RTPPack) (err error) {
 	return
 }
 
+// addStopHandle called when session stops
+func (session *Session) addStopHandle(handle func()) {
+	session.stopHandlesLock.Lock()
+	session.StopHandles = append(session.StopHandles, handle)
+	session.stopHandlesLock.Unlock()
+}
+
 func (session *Session) getStoped() bool {
 	session.stopedLock.RLock()
 	isStop := session.Stoped
@@ -871,4 +1002,17 @@ func (session *Session) setStoped(stop bool) {
 	session.Stoped = stop
 	session.stopedLock.Unlock()
 	return
+}
+
+func (session *Session) getPusher() Pusher {
+	session.pusherLock.RLock()
+	pusher := session.Pusher
+	session.pusherLock.RUnlock()
+	return pusher
+}
+
+func (session *Session) setPusher(pusher Pusher) {
+	session.pusherLock.Lock()
+	session.Pusher = pusher
+	session.pusherLock.Unlock()
 }
\ No newline at end of file
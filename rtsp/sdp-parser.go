@@ -91,6 +91,9 @@ func ParseSDP(sdpRaw string) map[string]*SDPInfo {
 											val, _ := base64.StdEncoding.DecodeString(field)
 											info.SpropParameterSets = append(info.SpropParameterSets, val)
 										}
+									case "sprop-vps", "sprop-sps", "sprop-pps":
+										val, _ := base64.StdEncoding.DecodeString(val)
+										info.SpropParameterSets = append(info.SpropParameterSets, val)
 									}
 								}
 							}
@@ -1,6 +1,7 @@
 package rtsp
 
 import (
+	"bytes"
 	"fmt"
 	"net"
 	"strings"
@@ -98,6 +99,7 @@ func (c *UDPClient) SetupAudio(aChannel int) (err error) {
 	if err := c.AControlConn[aChannel].SetWriteBuffer(networkBuffer); err != nil {
 		log.Errorf("udp client audio control conn set write buffer error, %v", err)
 	}
+	go c.readControl(c.AControlConn[aChannel], RTP_TYPE_AUDIOCONTROL, aChannel)
 	return
 }
 
@@ -140,9 +142,40 @@ func (c *UDPClient) SetupVideo() (err error) {
 	if err := c.VControlConn.SetWriteBuffer(networkBuffer); err != nil {
 		log.Errorf("udp client video control conn set write buffer error, %v", err)
 	}
+	go c.readControl(c.VControlConn, RTP_TYPE_VIDEOCONTROL, 0)
 	return
 }
 
+// readControl of RTCP from player until stop, it is handled by session
+func (c *UDPClient) readControl(conn *net.UDPConn, rtpType RTPType, channel int) {
+	buf := make([]byte, 2048)
+	for !c.Stoped {
+		n, err := conn.Read(buf)
+		if err != nil {
+			if c.Stoped {
+				return
+			}
+			// like ICMP port unreachable of connected UDP
+			continue
+		}
+		pack := &RTPPack{
+			Type:    rtpType,
+			Channel: channel,
+			Buffer:  bytes.NewBuffer(append([]byte{}, buf[:n]...)),
+		}
+		c.Session.InBytes += uint(n)
+		c.Session.touch()
+		for _, h := range c.Session.RTPHandles {
+			h(pack)
+		}
+	}
+}
+
+// udpServerPorts of RTP and RTCP connections, which player sends RTCP to
+func udpServerPorts(conn *net.UDPConn, controlConn *net.UDPConn) string {
+	return fmt.Sprintf("server_port=%d-%d", conn.LocalAddr().(*net.UDPAddr).Port, controlConn.LocalAddr().(*net.UDPAddr).Port)
+}
+
 func (c *UDPClient) SendRTP(pack *RTPPack) (err error) {
 	if pack == nil {
 		err = fmt.Errorf("udp client send rtp got nil pack")
@@ -21,6 +21,9 @@ type UDPServer struct {
 	VConn        *net.UDPConn
 	VControlPort int
 	VControlConn *net.UDPConn
+	// RTCP address of source, known after RTCP received
+	AControlAddr []*net.UDPAddr
+	VControlAddr *net.UDPAddr
 
 	Stoped bool
 }
@@ -32,6 +35,7 @@ func NewUDPServerFromClient(client *RTSPClient) *UDPServer {
 		AConn:        []*net.UDPConn{nil, nil},
 		AControlPort: []int{-1, -1},
 		AControlConn: []*net.UDPConn{nil, nil},
+		AControlAddr: []*net.UDPAddr{nil, nil},
 	}
 }
 
@@ -42,6 +46,7 @@ func NewUDPServerFromSession(session *Session) *UDPServer {
 		AConn:        []*net.UDPConn{nil, nil},
 		AControlPort: []int{-1, -1},
 		AControlConn: []*net.UDPConn{nil, nil},
+		AControlAddr: []*net.UDPAddr{nil, nil},
 	}
 }
 
@@ -59,6 +64,7 @@ func (s *UDPServer) AddInputBytes(bytes int) {
 
 func (s *UDPServer) HandleRTP(pack *RTPPack) {
 	if s.Session != nil {
+		s.Session.touch()
 		for _, v := range s.Session.RTPHandles {
 			v(pack)
 		}
@@ -74,6 +80,25 @@ func (s *UDPServer) HandleRTP(pack *RTPPack) {
 	panic(fmt.Errorf("session and RTSPClient both nil"))
 }
 
+// SendRTCP to RTCP address of source, dropped if it is unknown
+func (s *UDPServer) SendRTCP(pack *RTPPack) (err error) {
+	var conn *net.UDPConn
+	var addr *net.UDPAddr
+	switch pack.Type {
+	case RTP_TYPE_AUDIOCONTROL:
+		conn, addr = s.AControlConn[pack.Channel], s.AControlAddr[pack.Channel]
+	case RTP_TYPE_VIDEOCONTROL:
+		conn, addr = s.VControlConn, s.VControlAddr
+	default:
+		return fmt.Errorf("udp server send rtcp got unkown pack type[%v]", pack.Type)
+	}
+	if conn == nil || addr == nil {
+		return nil
+	}
+	_, err = conn.WriteToUDP(pack.Buffer.Bytes(), addr)
+	return
+}
+
 func (s *UDPServer) Stop() {
 	if s.Stoped {
 		return
@@ -102,11 +127,7 @@ func (s *UDPServer) Stop() {
 }
 
 func (s *UDPServer) SetupAudio(aChannel int) (err error) {
-	addr, err := net.ResolveUDPAddr("udp", ":0")
-	if err != nil {
-		return
-	}
-	s.AConn[aChannel], err = net.ListenUDP("udp", addr)
+	s.AConn[aChannel], err = listenUDP()
 	if err != nil {
 		return
 	}
@@ -150,11 +171,7 @@ func (s *UDPServer) SetupAudio(aChannel int) (err error) {
 			}
 		}
 	}(aChannel)
-	addr, err = net.ResolveUDPAddr("udp", ":0")
-	if err != nil {
-		return
-	}
-	s.AControlConn[aChannel], err = net.ListenUDP("udp", addr)
+	s.AControlConn[aChannel], err = listenUDP()
 	if err != nil {
 		return
 	}
@@ -175,7 +192,8 @@ func (s *UDPServer) SetupAudio(aChannel int) (err error) {
 		log.Infof("udp server start listen audio control port[%d]", s.AControlPort)
 		defer log.Infof("udp server stop listen audio control port[%d]", s.AControlPort)
 		for !s.Stoped {
-			if n, _, err := s.AControlConn[aChannel].ReadFromUDP(bufUDP); err == nil {
+			if n, addr, err := s.AControlConn[aChannel].ReadFromUDP(bufUDP); err == nil {
+				s.AControlAddr[aChannel] = addr
 				//logger.Printf("Package recv from AControlConn.len:%d\n", n)
 				rtpBytes := make([]byte, n)
 				s.AddInputBytes(n)
@@ -195,11 +213,7 @@ func (s *UDPServer) SetupAudio(aChannel int) (err error) {
 }
 
 func (s *UDPServer) SetupVideo() (err error) {
-	addr, err := net.ResolveUDPAddr("udp", ":0")
-	if err != nil {
-		return
-	}
-	s.VConn, err = net.ListenUDP("udp", addr)
+	s.VConn, err = listenUDP()
 	if err != nil {
 		return
 	}
@@ -243,11 +257,7 @@ func (s *UDPServer) SetupVideo() (err error) {
 		}
 	}()
 
-	addr, err = net.ResolveUDPAddr("udp", ":0")
-	if err != nil {
-		return
-	}
-	s.VControlConn, err = net.ListenUDP("udp", addr)
+	s.VControlConn, err = listenUDP()
 	if err != nil {
 		return
 	}
@@ -268,7 +278,8 @@ func (s *UDPServer) SetupVideo() (err error) {
 		log.Infof("udp server start listen video control port[%d]", s.VControlPort)
 		defer log.Infof("udp server stop listen video control port[%d]", s.VControlPort)
 		for !s.Stoped {
-			if n, _, err := s.VControlConn.ReadFromUDP(bufUDP); err == nil {
+			if n, addr, err := s.VControlConn.ReadFromUDP(bufUDP); err == nil {
+				s.VControlAddr = addr
 				//logger.Printf("Package recv from VControlConn.len:%d\n", n)
 				rtpBytes := make([]byte, n)
 				s.AddInputBytes(n)
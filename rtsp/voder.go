@@ -3,6 +3,7 @@ package rtsp
 import (
 	"encoding/binary"
 	"fmt"
+	"math"
 	"strconv"
 	"strings"
 	"sync"
@@ -38,8 +39,16 @@ type VOD struct {
 	// base duration calc
 	baseStart    time.Time
 	baseDuration time.Duration
-	scale        int
+	scale        float64
+	pausedAt     time.Time
 	ticker       *time.Ticker
+	timerLock    sync.Mutex
+	// npt=0 of Range
+	nptStart int64
+	// seekAt in second where loops start, skip of startBlock before it is in unit of RTPTimeDuration
+	seekAt int64
+	skip   time.Duration
+	played bool
 	// send duration calc
 	sendDuration *RTPTimeDuration
 	// info
@@ -52,8 +61,9 @@ type VOD struct {
 	_AChannelNum int
 	_AControl    []string
 	_ACodec      []string
-	// message loop
+	// message loop, done once stopped
 	messageChannel chan vodCommand
+	doneChannel    chan int
 	// data flow
 	blockChannel chan *models.Block
 	queue        chan *RTPPack
@@ -76,20 +86,19 @@ func NewVOD(server *Server, ID string, path string, startBlock *models.Block) (_
 		_ID:           ID,
 		path:          path,
 
-		scale: 0,
+		scale: 1,
 
 		startBlock: startBlock,
 		state:      _VODStateInit,
+		nptStart:   startBlock.StartTime,
+		seekAt:     startBlock.StartTime,
 
 		_AControl: []string{"not set up audio 01", "not set up audio 02"},
 		_ACodec:   []string{"invalid codec", "invalid codec"},
 
 		messageChannel: make(chan vodCommand, 4),
-		stopChannel:    make(chan int, 1),
+		doneChannel:    make(chan int),
 		stopWaitGroup:  &sync.WaitGroup{},
-
-		blockChannel: make(chan *models.Block, 1),
-		queue:        make(chan *RTPPack, config.Player.SendQueueLength),
 	}
 	// Get ready SDP
 	vod._SDPRaw = startBlock.TaskExecute.SDPRaw
@@ -163,21 +172,49 @@ func (vod *VOD) Start() {
 
 	vod.state = _VODStateRun
 
-	for cmd := range vod.messageChannel {
-		cmd.Do()
+	for {
+		select {
+		case cmd := <-vod.messageChannel:
+			cmd.Do()
+		case <-vod.doneChannel:
+			return
+		}
+	}
+}
+
+// command to message loop, it is never sent after VOD stopped
+func (vod *VOD) command(cmd vodCommand) error {
+	select {
+	case vod.messageChannel <- cmd:
+		return nil
+	case <-vod.doneChannel:
+		return ErrorStoped
 	}
 }
 
 func (vod *VOD) resetTimer() {
+	vod.timerLock.Lock()
+	defer vod.timerLock.Unlock()
+
 	vod.baseStart = time.Now()
+	if !vod.pausedAt.IsZero() {
+		vod.pausedAt = vod.baseStart
+	}
 	// pre send 500 ms video data
 	vod.baseDuration = 500 * time.Millisecond // TODO: config it!
 	vod.sendDuration = nil
 	vod.handleRTPPacket = vod.findFirstVideoRTPPacket
+	if nil != vod.ticker {
+		vod.ticker.Stop()
+	}
 	vod.ticker = time.NewTicker(40 * time.Millisecond)
 }
 
+// start loops from startBlock, they may be stopped by stopLoops and started again
 func (vod *VOD) start() {
+	vod.stopChannel = make(chan int)
+	vod.blockChannel = make(chan *models.Block, 1)
+	vod.queue = make(chan *RTPPack, config.Player.SendQueueLength)
 	vod.resetTimer()
 
 	vod.stopWaitGroup.Add(3)
@@ -186,6 +223,12 @@ func (vod *VOD) start() {
 	go vod.brocastLoop()
 }
 
+// stopLoops of read, send and brocast, and wait them exit
+func (vod *VOD) stopLoops() {
+	close(vod.stopChannel)
+	vod.stopWaitGroup.Wait()
+}
+
 func (vod *VOD) readBlockLoop() {
 	defer func() {
 		close(vod.blockChannel)
@@ -279,11 +322,92 @@ func (vod *VOD) findFirstVideoRTPPacket(packet *RTPPack, info *RTPInfo) (bool, e
 	}
 	// found first video rtp, go to next state
 	vod.sendDuration = NewRTPTimeDuration(90000, info.Timestamp)
+	if vod.skip > 0 {
+		vod.handleRTPPacket = vod.skipRTPPacketBeforeSeek
+		return vod.skipRTPPacketBeforeSeek(packet, info)
+	}
 	vod.handleRTPPacket = vod.sendRTPPacketByTimestamp
 
 	return vod.sendRTPPacketByTimestamp(packet, info)
 }
 
+// skipRTPPacketBeforeSeek drops packets of startBlock before seek time,
+// and then until the first key frame, players can not decode from the middle of GOP
+func (vod *VOD) skipRTPPacketBeforeSeek(packet *RTPPack, info *RTPInfo) (bool, error) {
+	if info.PayloadType < 96 { // Not video, TODO: more sure than experince
+		return true, nil
+	}
+	if vod.sendDuration.Calc(info.Timestamp) < vod.skip {
+		return true, nil
+	}
+	if !rtpKeyFrameStart(vod._VCodec, info.Payload) {
+		return true, nil
+	}
+	// key frame after seek time reached, send from here
+	vod.sendDuration = NewRTPTimeDuration(90000, info.Timestamp)
+	vod.handleRTPPacket = vod.sendRTPPacketByTimestamp
+
+	return vod.sendRTPPacketByTimestamp(packet, info)
+}
+
+// rtpKeyFrameStart if RTP payload starts a key frame, with parameter sets or IDR.
+// It is true for codecs unknown, they are not waited
+func rtpKeyFrameStart(codec string, payload []byte) bool {
+	var streamType byte
+	switch strings.ToLower(codec) {
+	case "h264":
+		streamType = PSStreamTypeH264
+	case "h265":
+		streamType = PSStreamTypeH265
+	default:
+		return true
+	}
+	keyNALU := func(nalu []byte) bool {
+		return len(nalu) > 0 && (isParameterSet(streamType, nalu) || isKeyFrameNALU(streamType, nalu))
+	}
+	if len(payload) < 3 {
+		return false
+	}
+	if streamType == PSStreamTypeH264 {
+		switch payload[0] & 0x1f {
+		case 24: // STAP-A
+			for off := 1; off+2 <= len(payload); {
+				size := int(binary.BigEndian.Uint16(payload[off:]))
+				off += 2
+				if size == 0 || off+size > len(payload) {
+					return false
+				}
+				if keyNALU(payload[off : off+size]) {
+					return true
+				}
+				off += size
+			}
+			return false
+		case 28: // FU-A, header of NALU is rebuilt from indicator and FU header
+			return payload[1]&0x80 != 0 && keyNALU([]byte{payload[0]&0xe0 | payload[1]&0x1f})
+		}
+		return keyNALU(payload)
+	}
+	switch (payload[0] >> 1) & 0x3f {
+	case 48: // AP
+		for off := 2; off+2 <= len(payload); {
+			size := int(binary.BigEndian.Uint16(payload[off:]))
+			off += 2
+			if size == 0 || off+size > len(payload) {
+				return false
+			}
+			if keyNALU(payload[off : off+size]) {
+				return true
+			}
+			off += size
+		}
+		return false
+	case 49: // FU
+		return payload[2]&0x80 != 0 && keyNALU([]byte{(payload[2] & 0x3f) << 1})
+	}
+	return keyNALU(payload)
+}
+
 func (vod *VOD) sendRTPPacketByTimestamp(packet *RTPPack, info *RTPInfo) (bool, error) {
 	if info.PayloadType < 96 { // Not video, TODO: more sure than experince
 		vod.QueueRTP(packet)
@@ -303,11 +427,44 @@ func (vod *VOD) sendRTPPacketByTimestamp(packet *RTPPack, info *RTPInfo) (bool,
 
 func (vod *VOD) updateBaseDurationOrParameter() {
 	now := <-vod.ticker.C
-	vod.baseDuration = now.Sub(vod.baseStart)
-	if vod.scale >= 0 {
-		vod.baseDuration = (vod.baseDuration << uint(vod.scale))
-	} else {
-		vod.baseDuration = (vod.baseDuration >> uint(-vod.scale))
+	vod.timerLock.Lock()
+	defer vod.timerLock.Unlock()
+	if !vod.pausedAt.IsZero() {
+		// nothing more to send until resumed
+		return
+	}
+	vod.baseDuration = time.Duration(float64(now.Sub(vod.baseStart)) * vod.scale)
+}
+
+// setScale of playback, base duration goes on from where it is
+func (vod *VOD) setScale(scale float64) {
+	vod.timerLock.Lock()
+	defer vod.timerLock.Unlock()
+
+	now := time.Now()
+	vod.baseStart = now.Add(-time.Duration(float64(vod.baseDuration) / scale))
+	if !vod.pausedAt.IsZero() {
+		vod.pausedAt = now
+	}
+	vod.scale = scale
+}
+
+func (vod *VOD) pause() {
+	vod.timerLock.Lock()
+	defer vod.timerLock.Unlock()
+
+	if vod.pausedAt.IsZero() {
+		vod.pausedAt = time.Now()
+	}
+}
+
+func (vod *VOD) resume() {
+	vod.timerLock.Lock()
+	defer vod.timerLock.Unlock()
+
+	if !vod.pausedAt.IsZero() {
+		vod.baseStart = vod.baseStart.Add(time.Since(vod.pausedAt))
+		vod.pausedAt = time.Time{}
 	}
 }
 
@@ -346,6 +503,11 @@ func (vod *VOD) sendControlLoop() {
 			// usually after all packets has been sent according to timestamp
 			handled = false
 			for !handled {
+				select {
+				case <-vod.stopChannel:
+					return
+				default:
+				}
 				// the entry of change send parameter
 				vod.updateBaseDurationOrParameter()
 				handled, err = vod.handleRTPPacket(packet, info)
@@ -390,15 +552,10 @@ func (cmd *vodCommandStop) Do() error {
 	}
 
 	// Stop message loop
-	close(cmd.VOD.messageChannel)
+	close(cmd.VOD.doneChannel)
 
 	// Stop read and send loop
-	select {
-	case cmd.VOD.stopChannel <- 1:
-	default:
-		log.Warn("VOD.Stop may be called twice")
-	}
-	cmd.VOD.stopWaitGroup.Wait()
+	cmd.VOD.stopLoops()
 	log.WithField("id", cmd.VOD.ID()).Debug("All vod loop closed")
 
 	cmd.VOD.state = _VODStateStop
@@ -410,6 +567,145 @@ func (cmd *vodCommandStop) Do() error {
 	return nil
 }
 
+type vodCommandSeek struct {
+	*VOD
+	block *models.Block
+	// seekTime in second, not before start of block
+	seekTime int64
+}
+
+func (cmd *vodCommandSeek) Do() error {
+	if cmd.VOD.state != _VODStateRun {
+		return nil
+	}
+
+	cmd.VOD.stopLoops()
+	log.WithFields(logrus.Fields{
+		"id":        cmd.VOD.ID(),
+		"blockID":   cmd.block.ID,
+		"startTime": cmd.block.StartTime,
+		"seekTime":  cmd.seekTime,
+	}).Info("VOD seek")
+	cmd.VOD.startBlock = cmd.block
+	cmd.VOD.skip = time.Duration(cmd.seekTime - cmd.block.StartTime)
+	cmd.VOD.timerLock.Lock()
+	cmd.VOD.seekAt = cmd.seekTime
+	cmd.VOD.timerLock.Unlock()
+	cmd.VOD.start()
+
+	return nil
+}
+
+// parseRange of RTSP PLAY to time in second, npt is relative to start of VOD.
+// ok is false if Range does not seek, like npt=now-
+func (vod *VOD) parseRange(rangeValue string) (seekTime int64, ok bool, err error) {
+	rangeValue = strings.TrimSpace(strings.Split(rangeValue, ";")[0])
+	switch {
+	case strings.HasPrefix(rangeValue, "npt="):
+		start := strings.TrimSpace(strings.Split(strings.TrimPrefix(rangeValue, "npt="), "-")[0])
+		if start == "" || start == "now" {
+			return 0, false, nil
+		}
+		var seconds float64
+		parts := strings.Split(start, ":")
+		if len(parts) > 3 {
+			return 0, false, fmt.Errorf("Range %s malformed", rangeValue)
+		}
+		// npt-sec or npt-hhmmss
+		for _, part := range parts {
+			value, err := strconv.ParseFloat(part, 64)
+			if nil != err || value < 0 {
+				return 0, false, fmt.Errorf("Range %s malformed", rangeValue)
+			}
+			seconds = seconds*60 + value
+		}
+		return vod.nptStart + int64(seconds), true, nil
+	case strings.HasPrefix(rangeValue, "clock="):
+		start := strings.TrimSpace(strings.Split(strings.TrimPrefix(rangeValue, "clock="), "-")[0])
+		t, err := time.Parse("20060102T150405Z", start)
+		if nil != err {
+			return 0, false, fmt.Errorf("Range %s malformed", rangeValue)
+		}
+		return t.Unix(), true, nil
+	}
+	return 0, false, fmt.Errorf("Range %s not supported", rangeValue)
+}
+
+// position in second from where loops start, it is the start before the first PLAY
+func (vod *VOD) position() float64 {
+	vod.timerLock.Lock()
+	defer vod.timerLock.Unlock()
+
+	position := float64(vod.seekAt)
+	if vod.played {
+		position += vod.baseDuration.Seconds()
+	}
+	return position
+}
+
+// seek to time in second, packets in block before it are skipped. The actual time is returned
+func (vod *VOD) seek(seekTime int64) (int64, error) {
+	block := record.NewEmptyBlock()
+	block.TaskExecute = &models.TaskExecute{}
+	block.TaskExecute.ID = vod.startBlock.TaskExecute.ID
+	block.TaskExecute.TaskID = vod.startBlock.TaskExecute.TaskID
+	err := models.GetBlockBeforeTime(block, seekTime)
+	if models.ErrorBlockNotFount == err {
+		// before the first block
+		err = models.GetBlockByTime(block, seekTime)
+	}
+	if nil != err {
+		return 0, fmt.Errorf("block at %d, %v", seekTime, err)
+	}
+	if seekTime < block.StartTime {
+		seekTime = block.StartTime
+	}
+	if err := vod.command(&vodCommandSeek{VOD: vod, block: block, seekTime: seekTime}); nil != err {
+		return 0, err
+	}
+	return seekTime, nil
+}
+
+// ControlPlay of VOD, seek to Range, play in Scale and resume if paused.
+// Range of position playing from is returned
+func (vod *VOD) ControlPlay(player Player, rangeValue string, scale string) (string, error) {
+	if scale != "" {
+		value, err := strconv.ParseFloat(strings.TrimSpace(scale), 64)
+		if nil != err || value <= 0 {
+			return "", fmt.Errorf("Scale %s not supported", scale)
+		}
+		vod.setScale(value)
+	}
+
+	position := vod.position()
+	if rangeValue != "" {
+		seekTime, ok, err := vod.parseRange(rangeValue)
+		if nil != err {
+			return "", err
+		}
+		// like npt=0.000- of the first PLAY, the position is playing already
+		if ok && math.Abs(float64(seekTime)-position) >= 1 {
+			seekTime, err = vod.seek(seekTime)
+			if nil != err {
+				return "", err
+			}
+			position = float64(seekTime)
+		}
+	}
+
+	vod.timerLock.Lock()
+	vod.played = true
+	vod.timerLock.Unlock()
+	vod.resume()
+	return fmt.Sprintf("npt=%.3f-", position-float64(vod.nptStart)), nil
+}
+
+// ControlPause of VOD, stop sending until PLAY again
+func (vod *VOD) ControlPause(player Player) error {
+	vod.pause()
+	return nil
+}
+
 // AddOnStopHandle of VOD
 func (vod *VOD) AddOnStopHandle(handle func()) {
 	vod.StopHandles = append(vod.StopHandles, handle)
@@ -417,8 +713,7 @@ func (vod *VOD) AddOnStopHandle(handle func()) {
 
 // Stop vod
 func (vod *VOD) Stop() {
-	cmd := &vodCommandStop{VOD: vod}
-	vod.messageChannel <- cmd
+	vod.command(&vodCommandStop{VOD: vod})
 }
 
 // AControl of VOD
@@ -528,7 +823,7 @@ func getVOD(server *Server, session *Session, path string, pusher Pusher) Pusher
 
 	// IMPORTANT: Add vod to server, unlike the RTSP real pusher added in rtsp-session
 	if server.AddPusher(vod, false) {
-		session.StopHandles = append(session.StopHandles, vod.stopIfNonePlayer)
+		session.addStopHandle(vod.stopIfNonePlayer)
 	} else {
 		// Maybe there is a same name RTSP vod request at same time, return it
 		if samePusher := server.GetPusher(path, nil); nil != samePusher {
@@ -0,0 +1,75 @@
+package rtsp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVODParseRange(t *testing.T) {
+	tests := []struct {
+		rangeValue string
+		seekTime   int64
+		ok         bool
+		err        bool
+	}{
+		{rangeValue: "npt=0.000-", seekTime: 1000, ok: true},
+		{rangeValue: "npt=12.5-30", seekTime: 1012, ok: true},
+		{rangeValue: "npt=1:02:03-", seekTime: 1000 + 3723, ok: true},
+		{rangeValue: "npt=10-;time=20201231T000000Z", seekTime: 1010, ok: true},
+		{rangeValue: "npt=now-", ok: false},
+		{rangeValue: "npt=-20", ok: false},
+		{rangeValue: "clock=20201231T000000Z-", seekTime: 1609372800, ok: true},
+		{rangeValue: "npt=1:2:3:4-", err: true},
+		{rangeValue: "npt=abc-", err: true},
+		{rangeValue: "clock=2020-12-31-", err: true},
+		{rangeValue: "smpte=10:07:00-", err: true},
+	}
+	vod := &VOD{nptStart: 1000}
+	for _, test := range tests {
+		t.Run(test.rangeValue, func(t *testing.T) {
+			seekTime, ok, err := vod.parseRange(test.rangeValue)
+			if test.err {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, test.ok, ok)
+			if test.ok {
+				assert.Equal(t, test.seekTime, seekTime)
+			}
+		})
+	}
+}
+
+func TestRTPKeyFrameStart(t *testing.T) {
+	tests := []struct {
+		name    string
+		codec   string
+		payload []byte
+		start   bool
+	}{
+		{name: "h264 SPS", codec: "H264", payload: []byte{0x67, 0x42, 0x00}, start: true},
+		{name: "h264 IDR", codec: "H264", payload: []byte{0x65, 0x88, 0x84}, start: true},
+		{name: "h264 non-IDR", codec: "H264", payload: []byte{0x41, 0x9a, 0x00}},
+		{name: "h264 STAP-A of SPS and PPS", codec: "H264", payload: []byte{0x78, 0x00, 0x02, 0x67, 0x42, 0x00, 0x02, 0x68, 0xce}, start: true},
+		{name: "h264 STAP-A of SEI", codec: "H264", payload: []byte{0x78, 0x00, 0x02, 0x06, 0x05}},
+		{name: "h264 STAP-A truncated", codec: "H264", payload: []byte{0x78, 0x00, 0x05, 0x67, 0x42}},
+		{name: "h264 FU-A start of IDR", codec: "H264", payload: []byte{0x7c, 0x85, 0x88}, start: true},
+		{name: "h264 FU-A middle of IDR", codec: "H264", payload: []byte{0x7c, 0x05, 0x88}},
+		{name: "h264 FU-A start of non-IDR", codec: "H264", payload: []byte{0x5c, 0x81, 0x9a}},
+		{name: "h265 VPS", codec: "H265", payload: []byte{0x40, 0x01, 0x0c}, start: true},
+		{name: "h265 IDR", codec: "H265", payload: []byte{0x26, 0x01, 0xaf}, start: true},
+		{name: "h265 TRAIL", codec: "H265", payload: []byte{0x02, 0x01, 0xd0}},
+		{name: "h265 AP of VPS", codec: "H265", payload: []byte{0x60, 0x01, 0x00, 0x02, 0x40, 0x01}, start: true},
+		{name: "h265 FU start of IDR", codec: "H265", payload: []byte{0x62, 0x01, 0x93, 0xaf}, start: true},
+		{name: "h265 FU middle of IDR", codec: "H265", payload: []byte{0x62, 0x01, 0x13, 0xaf}},
+		{name: "too short", codec: "H264", payload: []byte{0x65}},
+		{name: "codec unknown", codec: "MP4V-ES", payload: []byte{0x00, 0x00, 0x01}, start: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.start, rtpKeyFrameStart(test.codec, test.payload))
+		})
+	}
+}
@@ -4,19 +4,64 @@ import (
 	"crypto/tls"
 	"log"
 	"net"
+	"sync"
 )
 
-func NewTlsListener(certFile string, keyFile string, addr *net.TCPAddr) (net.Listener, error) {
-	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
-	if err != nil {
-		log.Println(err)
+// Certificate of cert and key files, it can be reloaded without restarting listeners
+type Certificate struct {
+	certFile string
+	keyFile  string
+	lock     sync.RWMutex
+	cert     *tls.Certificate
+}
+
+// NewCertificate loaded from cert and key files
+func NewCertificate(certFile string, keyFile string) (*Certificate, error) {
+	certificate := &Certificate{
+		certFile: certFile,
+		keyFile:  keyFile,
+	}
+	if err := certificate.Reload(); err != nil {
 		return nil, err
 	}
+	return certificate, nil
+}
+
+// Reload cert and key files, the old one is kept on error
+func (certificate *Certificate) Reload() error {
+	cert, err := tls.LoadX509KeyPair(certificate.certFile, certificate.keyFile)
+	if err != nil {
+		return err
+	}
+	certificate.lock.Lock()
+	certificate.cert = &cert
+	certificate.lock.Unlock()
+	return nil
+}
 
-	config := &tls.Config{Certificates: []tls.Certificate{cert}}
+// GetCertificate for tls.Config, new connections use the last loaded one
+func (certificate *Certificate) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	certificate.lock.RLock()
+	defer certificate.lock.RUnlock()
+	return certificate.cert, nil
+}
 
+// NewListener of TLS on addr with reloadable certificate
+func NewListener(certificate *Certificate, addr *net.TCPAddr) (net.Listener, error) {
 	listener, err := net.ListenTCP("tcp", addr)
-	ln := tls.NewListener(listener, config)
+	if err != nil {
+		return nil, err
+	}
+	config := &tls.Config{GetCertificate: certificate.GetCertificate}
+	return tls.NewListener(listener, config), nil
+}
+
+func NewTlsListener(certFile string, keyFile string, addr *net.TCPAddr) (net.Listener, error) {
+	certificate, err := NewCertificate(certFile, keyFile)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
 
-	return ln, nil
+	return NewListener(certificate, addr)
 }